@@ -0,0 +1,179 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.companyinfo.dev/ptr"
+)
+
+// AuditEvent describes a successful mutating operation, as passed to the WithAuditHook
+// callback.
+//
+// Actions are "create", "update" and "delete" for the resource itself, "move" when an
+// existing group is re-parented, "add_member" and "remove_member" for group membership
+// changes, "add_default" and "remove_default" for default groups, "update_permissions",
+// "reset_password", "impersonate", "add_protocol_mapper", "delete_protocol_mapper" and
+// "partial_import".
+type AuditEvent struct {
+	Time         time.Time         // When the operation completed
+	Realm        string            // Realm the operation applied to
+	ResourceType string            // "group", "user", "client" or "realm"
+	ResourceID   string            // ID of the affected resource (the new ID for creates), if known
+	Action       string            // Operation performed, e.g. "create" or "add_member"
+	Actor        string            // Subject (sub claim) of the access token, empty if not a JWT
+	Endpoint     string            // Endpoint identifier, as used by WithEndpointTimeouts
+	Params       map[string]string // Other path parameters, e.g. the userID of membership changes
+}
+
+// WithAuditHook registers a callback invoked after each successful mutating operation
+// (creates, updates, deletes, membership changes, ...), e.g. to keep an audit trail of
+// changes made by the application. Failed operations are not reported.
+//
+// The callback runs on its own goroutine, so it never blocks or fails the request; events
+// may therefore be delivered out of order, and the callback must be safe for concurrent
+// use. Each attempt is inspected separately, so an operation performing several writes
+// (e.g. Groups.Reconcile) reports one event per write.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithAuditHook(func(event keycloak.AuditEvent) {
+//	        slog.Info("keycloak change", "action", event.Action,
+//	            "type", event.ResourceType, "id", event.ResourceID, "actor", event.Actor)
+//	    }),
+//	)
+func WithAuditHook(hook func(AuditEvent)) Option {
+	return func(c *Client) error {
+		if hook == nil {
+			return fmt.Errorf("audit hook cannot be nil")
+		}
+		c.auditHook = hook
+		return nil
+	}
+}
+
+// auditOperation describes how a mutating endpoint is reported.
+type auditOperation struct {
+	resourceType string
+	action       string
+	idParam      string // path parameter holding the resource ID; empty for creates
+}
+
+// auditOperations lists the audited endpoints. Endpoints not listed are not reported.
+var auditOperations = map[endpoint]auditOperation{
+	endpointGroupsCreate:       {resourceType: "group", action: "create"},
+	endpointGroupChildCreate:   {resourceType: "group", action: "create"},
+	endpointGroupUpdate:        {resourceType: "group", action: "update", idParam: "groupID"},
+	endpointGroupDelete:        {resourceType: "group", action: "delete", idParam: "groupID"},
+	endpointGroupPermsUpdate:   {resourceType: "group", action: "update_permissions", idParam: "groupID"},
+	endpointDefaultGroupAdd:    {resourceType: "group", action: "add_default", idParam: "groupID"},
+	endpointDefaultGroupRemove: {resourceType: "group", action: "remove_default", idParam: "groupID"},
+	endpointUserGroupJoin:      {resourceType: "group", action: "add_member", idParam: "groupID"},
+	endpointUserGroupLeave:     {resourceType: "group", action: "remove_member", idParam: "groupID"},
+
+	endpointUsersCreate:     {resourceType: "user", action: "create"},
+	endpointUserUpdate:      {resourceType: "user", action: "update", idParam: "userID"},
+	endpointUserDelete:      {resourceType: "user", action: "delete", idParam: "userID"},
+	endpointUserResetPass:   {resourceType: "user", action: "reset_password", idParam: "userID"},
+	endpointUserImpersonate: {resourceType: "user", action: "impersonate", idParam: "userID"},
+
+	endpointClientProtocolMapperCreate: {resourceType: "client", action: "add_protocol_mapper", idParam: "id"},
+	endpointClientProtocolMapperDelete: {resourceType: "client", action: "delete_protocol_mapper", idParam: "id"},
+
+	endpointPartialImport: {resourceType: "realm", action: "partial_import", idParam: "realm"},
+}
+
+// auditResponse is a resty middleware that reports successful mutating operations to the
+// audit hook. It is registered last, so responses rejected by the success validator are
+// not reported.
+func (c *Client) auditResponse(_ *resty.Client, resp *resty.Response) error {
+	if !resp.IsSuccess() || resp.RawResponse == nil || resp.RawResponse.Request == nil {
+		return nil
+	}
+	sent := resp.RawResponse.Request
+	template := matchEndpoint(sent.Method, sent.URL.EscapedPath())
+	if template == nil {
+		return nil
+	}
+	op, ok := auditOperations[template.endpoint]
+	if !ok {
+		return nil
+	}
+
+	params := template.params(sent.URL.EscapedPath())
+	event := AuditEvent{
+		Time:         time.Now(),
+		Realm:        params["realm"],
+		ResourceType: op.resourceType,
+		Action:       op.action,
+		Endpoint:     template.name,
+	}
+	if op.idParam != "" {
+		event.ResourceID = params[op.idParam]
+	} else {
+		// The resource was created even if its Location is unusable; report it without an ID
+		event.ResourceID, _ = createdID(resp, event.Realm, op.resourceType+"s")
+		// Posting an existing group moves it instead of creating one (see Detach)
+		if event.ResourceID == "" && op.resourceType == "group" && resp.StatusCode() != http.StatusCreated {
+			event.ResourceID = postedGroupID(resp.Request.Body)
+			event.Action = "move"
+		}
+	}
+	delete(params, "realm")
+	delete(params, op.idParam)
+	if len(params) > 0 {
+		event.Params = params
+	}
+
+	authorization := sent.Header.Get("Authorization")
+	c.runBackground(func() {
+		event.Actor = tokenSubject(authorization)
+		c.auditHook(event)
+	})
+	return nil
+}
+
+// postedGroupID returns the ID of a group request body, or an empty string.
+func postedGroupID(body any) string {
+	switch group := body.(type) {
+	case Group:
+		return ptr.ToString(group.ID)
+	case *Group:
+		if group != nil {
+			return ptr.ToString(group.ID)
+		}
+	}
+	return ""
+}
+
+// tokenSubject returns the sub claim of a bearer Authorization header value, or an empty
+// string if the header holds no JWT.
+func tokenSubject(authorization string) string {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return ""
+	}
+	claims, err := parseTokenClaims(token)
+	if err != nil {
+		return ""
+	}
+	return claims.Subject
+}
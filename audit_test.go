@@ -0,0 +1,159 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAuditHook(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	assert.Error(t, WithAuditHook(nil)(client))
+	assert.NoError(t, WithAuditHook(func(AuditEvent) {})(client))
+	assert.NotNil(t, client.auditHook)
+}
+
+// newAuditServer returns a server accepting group creates, deletes and membership changes
+// for every ID except "missing", which is not found.
+func newAuditServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/admin/realms/test-realm/groups/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost:
+			w.Header().Set("Location", "http://"+r.Host+r.URL.Path+"/new-group")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+// receiveAuditEvent waits for the next audit event.
+func receiveAuditEvent(t *testing.T, events <-chan AuditEvent) AuditEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no audit event received")
+		return AuditEvent{}
+	}
+}
+
+func TestAuditHook_GroupOperations(t *testing.T) {
+	server := newAuditServer()
+	defer server.Close()
+
+	events := make(chan AuditEvent, 10)
+	client := newTestClient(server.URL, WithAuditHook(func(event AuditEvent) {
+		events <- event
+	}))
+	encode := func(v string) string { return base64.RawURLEncoding.EncodeToString([]byte(v)) }
+	client.resty.SetAuthToken(encode(`{"alg":"none"}`) + "." + encode(`{"sub":"service-account"}`) + ".sig")
+	ctx := context.Background()
+
+	id, err := client.Groups.Create(ctx, "team", nil)
+	require.NoError(t, err)
+	require.Equal(t, "new-group", id)
+
+	event := receiveAuditEvent(t, events)
+	assert.Equal(t, "test-realm", event.Realm)
+	assert.Equal(t, "group", event.ResourceType)
+	assert.Equal(t, "new-group", event.ResourceID)
+	assert.Equal(t, "create", event.Action)
+	assert.Equal(t, "service-account", event.Actor)
+	assert.Equal(t, "Groups.Create", event.Endpoint)
+	assert.False(t, event.Time.IsZero())
+
+	require.NoError(t, client.Groups.Delete(ctx, "group-1"))
+	event = receiveAuditEvent(t, events)
+	assert.Equal(t, "group-1", event.ResourceID)
+	assert.Equal(t, "delete", event.Action)
+	assert.Equal(t, "Groups.Delete", event.Endpoint)
+	assert.Nil(t, event.Params)
+
+	require.NoError(t, client.Users.AddToGroup(ctx, "user-1", "group 2"))
+	event = receiveAuditEvent(t, events)
+	assert.Equal(t, "group 2", event.ResourceID)
+	assert.Equal(t, "add_member", event.Action)
+	assert.Equal(t, map[string]string{"userID": "user-1"}, event.Params)
+}
+
+func TestAuditHook_OnlySuccessfulMutations(t *testing.T) {
+	server := newAuditServer()
+	defer server.Close()
+
+	events := make(chan AuditEvent, 10)
+	client := newTestClient(server.URL, WithAuditHook(func(event AuditEvent) {
+		events <- event
+	}))
+	ctx := context.Background()
+
+	assert.Error(t, client.Groups.Delete(ctx, "missing"))
+
+	require.NoError(t, client.Groups.Delete(ctx, "group-1"))
+	event := receiveAuditEvent(t, events)
+	assert.Equal(t, "group-1", event.ResourceID)
+	assert.Empty(t, event.Actor)
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected audit event: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAuditHook_DoesNotBlock(t *testing.T) {
+	server := newAuditServer()
+	defer server.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	client := newTestClient(server.URL, WithAuditHook(func(AuditEvent) {
+		<-release
+	}))
+
+	done := make(chan error, 1)
+	go func() { done <- client.Groups.Delete(context.Background(), "group-1") }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("request blocked by audit hook")
+	}
+}
+
+func TestEndpointTemplate_Params(t *testing.T) {
+	template := matchEndpoint(http.MethodPut, "/auth/admin/realms/my%20realm/users/u1/groups/g%2F1")
+	require.NotNil(t, template)
+	assert.Equal(t, "Users.AddToGroup", template.name)
+	assert.Equal(t, map[string]string{"realm": "my realm", "userID": "u1", "groupID": "g/1"},
+		template.params("/auth/admin/realms/my%20realm/users/u1/groups/g%2F1"))
+	assert.Nil(t, matchEndpoint(http.MethodPut, "/admin/realms/r/unknown"))
+
+	// Endpoints used only as a step of other methods are named after the endpoint
+	template = matchEndpoint(http.MethodGet, "/admin/realms/r/users/u1/role-mappings/realm/composite")
+	require.NotNil(t, template)
+	assert.Equal(t, "Users.ListEffectiveRealmRoles", template.name)
+}
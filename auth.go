@@ -0,0 +1,463 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// WithAfterTokenRefresh registers a callback invoked whenever a new access token is obtained,
+// for example to persist or audit tokens. The callback runs in its own goroutine, outside of
+// any lock, so a slow callback never delays requests. It must be safe for concurrent use.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithAfterTokenRefresh(func(token *oauth2.Token) {
+//	        log.Printf("token refreshed, expires at %s", token.Expiry)
+//	    }),
+//	)
+func WithAfterTokenRefresh(callback func(*oauth2.Token)) Option {
+	return func(c *Client) error {
+		if callback == nil {
+			return fmt.Errorf("token refresh callback cannot be nil")
+		}
+		c.afterTokenRefresh = callback
+		return nil
+	}
+}
+
+// WithTokenSource authenticates requests with access tokens from source instead of the
+// client credentials flow, e.g. for a public client whose bearer token is obtained elsewhere.
+// Config.ClientSecret is then optional, and New neither discovers nor contacts the token
+// endpoint (unless WithRealmFromToken asks for the first token). The source is called for
+// every request, so it should cache its token (see oauth2.ReuseTokenSource).
+// WithTokenURL, WithTokenExpiryBuffer and WithTokenCacheFile do not apply to it.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithTokenSource(oauth2.ReuseTokenSource(nil, mySource)),
+//	)
+func WithTokenSource(source oauth2.TokenSource) Option {
+	return func(c *Client) error {
+		if source == nil {
+			return fmt.Errorf("token source cannot be nil")
+		}
+		c.userTokenSource = source
+		return nil
+	}
+}
+
+// WithStaticToken authenticates every request with the given bearer token, which is never
+// refreshed. It is a shorthand for WithTokenSource with an oauth2.StaticTokenSource, for
+// short-lived tools and tests; Config.ClientSecret is then optional.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithStaticToken(os.Getenv("KEYCLOAK_TOKEN")))
+func WithStaticToken(accessToken string) Option {
+	return func(c *Client) error {
+		if accessToken == "" {
+			return fmt.Errorf("static token cannot be empty")
+		}
+		return WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken, TokenType: "Bearer"}))(c)
+	}
+}
+
+// WithTokenURL sets the OAuth2 token endpoint directly, e.g.
+// https://keycloak.example.com/realms/my-realm/protocol/openid-connect/token.
+// OIDC discovery is then skipped entirely, so New neither contacts the realm's well-known
+// endpoint nor fails when it is unreachable, which helps in air-gapped or locked-down
+// environments and speeds up construction. When set, it takes precedence over discovery.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+func WithTokenURL(tokenURL string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(tokenURL)
+		if err != nil {
+			return fmt.Errorf("invalid token URL: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid token URL %q: must be an absolute http(s) URL", tokenURL)
+		}
+		c.tokenURL = tokenURL
+		return nil
+	}
+}
+
+// WithTokenExpiryBuffer makes the client treat access tokens as expired d before their actual
+// expiry and fetch a new one, so that requests starting shortly before expiry do not fail
+// mid-flight with 401. Without it, tokens are refreshed 10 seconds before expiry. A buffer
+// longer than the token lifetime fetches a token for every request. It also applies to tokens
+// reused from WithTokenCacheFile. Has no effect when a custom client is supplied via
+// WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithTokenExpiryBuffer(time.Minute))
+func WithTokenExpiryBuffer(d time.Duration) Option {
+	return func(c *Client) error {
+		if d < 0 {
+			return fmt.Errorf("token expiry buffer must be non-negative, got %s", d)
+		}
+		c.tokenExpiryBuffer = d
+		return nil
+	}
+}
+
+// WithInitialToken seeds the client credentials token source with token, e.g. one just obtained
+// by a bootstrap step, so that the first requests reuse it instead of requesting a token at
+// startup. A new token is only requested once it expires (see WithTokenExpiryBuffer). A token
+// that is already expired when New runs is ignored with a warning (see WithLogger). With
+// WithTokenCacheFile, the seeded token takes precedence over the cached one. Has no effect
+// with WithTokenSource, or when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithInitialToken(bootstrapToken))
+func WithInitialToken(token *oauth2.Token) Option {
+	return func(c *Client) error {
+		if token == nil || token.AccessToken == "" {
+			return fmt.Errorf("initial token cannot be empty")
+		}
+		initial := *token
+		c.initialToken = &initial
+		return nil
+	}
+}
+
+// seedToken returns the token set with WithInitialToken, or nil if there is none or it has
+// already expired.
+func (c *Client) seedToken(ctx context.Context) *oauth2.Token {
+	if c.initialToken == nil {
+		return nil
+	}
+	if !tokenFresh(c.initialToken, c.tokenExpiryBuffer, time.Now()) {
+		c.logEvent(ctx, slog.LevelWarn, "keycloak initial token expired, requesting a new one",
+			"expiry", c.initialToken.Expiry,
+		)
+		return nil
+	}
+	return c.initialToken
+}
+
+// WithRealmFromToken makes New obtain the first access token eagerly and read the realm that
+// issued it from the token's iss claim, available afterwards through AuthRealm. If it differs
+// from Config.Realm, a warning is logged (see WithLogger): mixing up the realm the service
+// account authenticates against with the realm it manages is a common cause of 403 responses.
+// Admin API calls keep using Config.Realm. New fails if the first token cannot be obtained.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithRealmFromToken())
+func WithRealmFromToken() Option {
+	return func(c *Client) error {
+		c.realmFromToken = true
+		return nil
+	}
+}
+
+// AuthRealm returns the realm that issued the client's access tokens, as read from the first
+// token with WithRealmFromToken. Without that option, or when the issuer could not be parsed,
+// it returns the configured realm.
+func (c *Client) AuthRealm() string {
+	if c.authRealm != "" {
+		return c.authRealm
+	}
+	return c.realm
+}
+
+// detectTokenRealm obtains a token from source and records the realm of its issuer,
+// warning if it differs from the configured realm.
+func (c *Client) detectTokenRealm(ctx context.Context, source oauth2.TokenSource) error {
+	token, err := source.Token()
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+
+	issuer, err := tokenIssuer(token.AccessToken)
+	if err != nil {
+		c.logEvent(ctx, slog.LevelWarn, "keycloak token issuer not readable", "error", err)
+		return nil
+	}
+	realm, ok := realmFromIssuer(issuer)
+	if !ok {
+		c.logEvent(ctx, slog.LevelWarn, "keycloak token issuer has no realm", "issuer", issuer)
+		return nil
+	}
+
+	c.authRealm = realm
+	if realm != c.realm {
+		c.logEvent(ctx, slog.LevelWarn, "keycloak token realm differs from configured realm",
+			"token_realm", realm,
+			"realm", c.realm,
+		)
+	}
+	return nil
+}
+
+// jwtClaims holds the claims of an access token inspected by the client.
+type jwtClaims struct {
+	Issuer  string `json:"iss"`
+	Subject string `json:"sub"`
+}
+
+// parseTokenClaims decodes the claims of a JWT access token. The signature is not verified:
+// tokens are only inspected, never trusted for authorization decisions.
+func parseTokenClaims(accessToken string) (*jwtClaims, error) {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("access token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT payload: %w", err)
+	}
+
+	var claims jwtClaims
+	if err := unmarshalJSON(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	return &claims, nil
+}
+
+// tokenIssuer returns the iss claim of a JWT access token. The signature is not verified:
+// the token was just received from the token endpoint and is only inspected.
+func tokenIssuer(accessToken string) (string, error) {
+	claims, err := parseTokenClaims(accessToken)
+	if err != nil {
+		return "", err
+	}
+	if claims.Issuer == "" {
+		return "", fmt.Errorf("JWT has no iss claim")
+	}
+	return claims.Issuer, nil
+}
+
+// realmFromIssuer extracts the realm name from a Keycloak issuer URL such as
+// https://keycloak.example.com/realms/my-realm.
+func realmFromIssuer(issuer string) (string, bool) {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return "", false
+	}
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	if len(segments) < 2 || segments[len(segments)-2] != realmsPath {
+		return "", false
+	}
+	realm, err := url.PathUnescape(segments[len(segments)-1])
+	if err != nil || realm == "" {
+		return "", false
+	}
+	return realm, true
+}
+
+// configureAuth discovers the token endpoint of the realm (unless set with WithTokenURL) and
+// installs an OAuth2 transport (client credentials flow, or the WithTokenSource source) on the
+// underlying HTTP client. It is skipped when a custom HTTP client was supplied, since
+// authentication is then the caller's responsibility.
+func (c *Client) configureAuth(ctx context.Context, realmURL string) error {
+	if c.customHTTPClient {
+		return nil
+	}
+
+	// Token requests (and discovery) use the same transport as API requests,
+	// so transport-level options apply to both.
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: c.transport})
+
+	if c.userTokenSource != nil {
+		transport, err := c.oauthTransport(ctx, c.userTokenSource)
+		if err != nil {
+			return err
+		}
+		c.resty.SetTransport(&tokenOverrideTransport{auth: transport, base: c.transport})
+		return nil
+	}
+
+	tokenURL := c.tokenURL
+	if tokenURL == "" {
+		var err error
+		if tokenURL, err = c.discoverTokenURL(ctx, realmURL); err != nil {
+			return err
+		}
+	}
+
+	oauthConfig := clientcredentials.Config{
+		ClientID:     c.config.ClientID,
+		ClientSecret: c.config.ClientSecret,
+		TokenURL:     tokenURL,
+	}
+
+	fetch := func() (*oauth2.Token, error) {
+		return oauthConfig.Token(ctx)
+	}
+	initial := c.seedToken(ctx)
+	source := oauthConfig.TokenSource(ctx)
+	if initial != nil {
+		source = oauth2.ReuseTokenSource(initial, source)
+	}
+	if c.tokenExpiryBuffer > 0 {
+		// Replaces oauth2's own reuse, which refreshes a fixed 10 seconds before expiry
+		early := newEarlyExpiryTokenSource(fetch, c.tokenExpiryBuffer)
+		early.token = initial
+		source = early
+	}
+	var cache *fileTokenSource
+	if c.tokenCacheFile != "" {
+		// The cache decides when to fetch, so it must not sit behind oauth2's own reuse
+		cache = newFileTokenSource(c.tokenCacheFile, oauthConfig.TokenURL+" "+oauthConfig.ClientID, fetch)
+		cache.expiryBuffer = c.tokenExpiryBuffer
+		cache.token = initial
+		source = cache
+	}
+
+	transport, err := c.oauthTransport(ctx, source)
+	if err != nil {
+		return err
+	}
+	if cache != nil {
+		transport = &invalidatingTransport{base: transport, source: cache}
+	}
+	// Outermost, so that a 401 for an overriding token does not invalidate the cache
+	c.resty.SetTransport(&tokenOverrideTransport{auth: transport, base: c.transport})
+	return nil
+}
+
+// tokenOverrideTransport sends requests carrying a WithToken token directly through the base
+// transport, since the OAuth2 transport would replace their Authorization header.
+type tokenOverrideTransport struct {
+	auth http.RoundTripper
+	base http.RoundTripper
+}
+
+// RoundTrip sends the request with the client's token unless its context overrides it.
+func (t *tokenOverrideTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := overrideToken(req.Context()); ok {
+		return t.base.RoundTrip(req)
+	}
+	return t.auth.RoundTrip(req)
+}
+
+// oauthTransport returns a transport authenticating requests with tokens from source, wrapped
+// with the behavior requested by options. With WithRealmFromToken, the first token is
+// obtained here.
+func (c *Client) oauthTransport(ctx context.Context, source oauth2.TokenSource) (http.RoundTripper, error) {
+	tokenSource := c.tokenSource(source)
+	if c.realmFromToken {
+		if err := c.detectTokenRealm(ctx, tokenSource); err != nil {
+			return nil, err
+		}
+	}
+	return &oauth2.Transport{Source: tokenSource, Base: c.transport}, nil
+}
+
+// tokenSource wraps the base token source with the behavior requested by options.
+func (c *Client) tokenSource(base oauth2.TokenSource) oauth2.TokenSource {
+	if c.afterTokenRefresh != nil {
+		base = &notifyingTokenSource{base: base, notify: func(token *oauth2.Token) {
+			c.runBackground(func() { c.afterTokenRefresh(token) })
+		}}
+	}
+	return base
+}
+
+// earlyExpiryTokenSource reuses the token returned by fetch until it expires within buffer,
+// then fetches a new one.
+type earlyExpiryTokenSource struct {
+	fetch  func() (*oauth2.Token, error)
+	buffer time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// newEarlyExpiryTokenSource creates a token source that refreshes buffer before expiry.
+func newEarlyExpiryTokenSource(fetch func() (*oauth2.Token, error), buffer time.Duration) *earlyExpiryTokenSource {
+	return &earlyExpiryTokenSource{fetch: fetch, buffer: buffer, now: time.Now}
+}
+
+// Token returns the current token, fetching a new one if it expires within the buffer.
+func (s *earlyExpiryTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tokenFresh(s.token, s.buffer, s.now()) {
+		return s.token, nil
+	}
+
+	token, err := s.fetch()
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// tokenFresh reports whether the token is still usable at now when it is treated as expired
+// buffer before its expiry. Tokens without an expiry never expire. A zero buffer falls back
+// to oauth2's own validity check.
+func tokenFresh(token *oauth2.Token, buffer time.Duration, now time.Time) bool {
+	if buffer <= 0 {
+		return token.Valid()
+	}
+	if token == nil || token.AccessToken == "" {
+		return false
+	}
+	return token.Expiry.IsZero() || now.Add(buffer).Before(token.Expiry)
+}
+
+// notifyingTokenSource calls notify whenever the wrapped source returns a token
+// different from the previous one, i.e. after every refresh. notify is called on the
+// request path, so it must not block.
+type notifyingTokenSource struct {
+	base   oauth2.TokenSource
+	notify func(*oauth2.Token)
+
+	mu   sync.Mutex
+	last string
+}
+
+// Token returns the current token and triggers the callback if it has changed.
+func (s *notifyingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.base.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	changed := token.AccessToken != s.last
+	s.last = token.AccessToken
+	s.mu.Unlock()
+
+	if changed {
+		s.notify(token)
+	}
+	return token, nil
+}
@@ -18,18 +18,25 @@ package keycloak
 import (
 	"context"
 	"fmt"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/go-resty/resty/v2"
-	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2"
 )
 
 const (
-	defaultSize = 50
-	realmsPath  = "realms"
+	defaultSize     = 50
+	defaultMaxPages = 1000
+	realmsPath      = "realms"
+
+	defaultReconcileConcurrency = 4
 )
 
 // Client is the main entry point for the Keycloak Admin API.
@@ -52,21 +59,110 @@ type Client struct {
 	// Groups provides access to group management operations
 	Groups GroupsClient
 
+	// Users provides access to user management operations
+	Users UsersClient
+
+	// Clients provides access to client (application) operations
+	Clients ClientsClient
+
 	// Internal shared state
-	resty    *resty.Client
-	config   Config
-	baseURL  string
-	realm    string
-	pageSize int
+	resty          *resty.Client
+	transport      *http.Transport
+	dialer         *net.Dialer
+	proxyURL       *url.URL
+	proxyAuth      *url.Userinfo
+	config         Config
+	baseURL        string
+	realm          string
+	pageSize       int
+	maxPages       int
+	minSearchLen   int
+	maxConcurrent  int
+	networkRetry   int
+	maxRetryAfter  time.Duration
+	strictAttrs    bool
+	attrWildcard   bool
+	defaultAttrs   map[string][]string
+	attrLimits     attributeLimits
+	defaults       ResourceDefaults
+	validator      func(*http.Response, []byte) error
+	recorder       *httpRecorder
+	logger         Logger
+	logFields      func(context.Context) []any
+	maxLoggedBody  int
+	errorTransform func(error) error
+
+	// Warn about successful responses carrying an error body
+	logSuccessErrors bool
+
+	// Reject successful responses that are not JSON
+	checkContentType bool
+
+	// Streaming list decoding
+	streamingDecode    bool
+	responseMiddleware []resty.ResponseMiddleware
+
+	// Client IDs resolved by New
+	warmupClientIDs []string
+
+	// Sensitive operations
+	impersonation bool
+
+	// Membership reconciliation
+	reconcileConcurrency int
+
+	// Per-endpoint deadlines
+	endpointTimeouts map[endpoint]time.Duration
+
+	// Leave deadlines to the caller's context
+	contextOnlyTimeouts bool
+
+	// Slow call detection
+	slowThreshold   time.Duration
+	slowCallHook    func(SlowCall)
+	cancelSlowCalls bool
+
+	// Audit trail of mutating operations
+	auditHook func(AuditEvent)
+
+	// Rate limit headers of the most recent response carrying them
+	trackRateLimit bool
+	rateLimitHook  func(RateLimitInfo)
+	lastRateLimit  atomic.Pointer[RateLimitInfo]
+
+	// Callbacks running in the background, awaited by Close
+	background sync.WaitGroup
+
+	// Server information, fetched lazily by ServerVersion
+	serverVersionMu sync.Mutex
+	serverVersion   string
+
+	// Authentication state
+	customHTTPClient   bool
+	tokenURL           string
+	realmFromToken     bool
+	authRealm          string
+	afterTokenRefresh  func(*oauth2.Token)
+	tokenCacheFile     string
+	discoveryCacheFile string
+	tokenExpiryBuffer  time.Duration
+	initialToken       *oauth2.Token
+	userTokenSource    oauth2.TokenSource
 }
 
 // Config contains the required configuration for creating a Keycloak client.
-// Only required fields are included; optional configuration uses functional options.
+// Apart from TokenURLBase, only required fields are included; optional configuration
+// uses functional options.
 type Config struct {
 	URL          string // Base URL of the Keycloak server (required, e.g., https://keycloak.example.com)
 	Realm        string // Keycloak realm name (required)
 	ClientID     string // OAuth2 client ID (required)
-	ClientSecret string // OAuth2 client secret (required)
+	ClientSecret string // OAuth2 client secret (required unless WithTokenSource or WithStaticToken is used)
+
+	// TokenURLBase is the base URL used for OIDC discovery and token requests, for deployments
+	// that expose the token endpoint on a different host than the admin API (e.g.
+	// https://sso.internal.example.com). Admin API calls always use URL. Optional; defaults to URL.
+	TokenURLBase string
 }
 
 // Option is a functional option for configuring the Client.
@@ -88,9 +184,64 @@ func WithPageSize(size int) Option {
 	}
 }
 
+// WithMaxPages caps the number of pages fetched by methods that page through results
+// automatically (such as BuildAttributeIndex). When the cap is hit, the method returns
+// ErrPageLimitExceeded instead of looping on a server that keeps returning full pages.
+// Default is 1000 pages if not specified.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithMaxPages(200))
+func WithMaxPages(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("max pages must be positive, got %d", n)
+		}
+		c.maxPages = n
+		return nil
+	}
+}
+
+// WithMinSearchLength rejects group name searches (the search parameter of List,
+// ListPaginated, ListWithParams and ListWithSubGroups) shorter than n characters with
+// ErrSearchTooShort before sending them, for servers or proxies that refuse very short terms.
+// Empty searches, which match all groups, are always allowed. Default is 0 (no minimum).
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithMinSearchLength(2))
+func WithMinSearchLength(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("min search length must be non-negative, got %d", n)
+		}
+		c.minSearchLen = n
+		return nil
+	}
+}
+
+// WithReconcileConcurrency bounds the number of changes that bulk operations apply
+// concurrently: membership changes of Groups.ReconcileMembers and group updates of
+// Groups.TagMatching. Default is 4 if not specified.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithReconcileConcurrency(8))
+func WithReconcileConcurrency(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("reconcile concurrency must be positive, got %d", n)
+		}
+		c.reconcileConcurrency = n
+		return nil
+	}
+}
+
 // WithHTTPClient sets a custom HTTP client for the underlying transport.
 // This is useful for custom timeouts, proxies, or TLS configuration.
 // Note: This will override the OAuth2 client, so you need to handle authentication separately.
+// The client is copied, so options wrapping its transport (e.g. WithMaxConcurrentRequests)
+// leave the caller's client untouched and safe to share with other code.
 //
 // Example:
 //
@@ -106,7 +257,9 @@ func WithHTTPClient(httpClient *http.Client) Option {
 		if httpClient == nil {
 			return fmt.Errorf("http client cannot be nil")
 		}
-		c.resty = resty.NewWithClient(httpClient)
+		hc := *httpClient
+		c.resty = resty.NewWithClient(&hc)
+		c.customHTTPClient = true
 		return nil
 	}
 }
@@ -127,7 +280,30 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
-// WithRetry configures retry behavior for failed requests.
+// WithContextOnlyTimeouts clears the HTTP client timeout, set by WithTimeout or on the client
+// passed to WithHTTPClient, so that only the deadline of the context passed to each call
+// bounds it. Without it, the shorter of the two applies, which cuts off calls made with a
+// deliberately longer context. Per-endpoint deadlines (WithEndpointTimeouts) still apply.
+//
+// Calls made with a context without deadline can then hang indefinitely.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithContextOnlyTimeouts())
+//	...
+//	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+//	defer cancel()
+//	members, err := client.Groups.ListMembers(ctx, groupID, keycloak.GroupMembersParams{})
+func WithContextOnlyTimeouts() Option {
+	return func(c *Client) error {
+		c.contextOnlyTimeouts = true
+		return nil
+	}
+}
+
+// WithRetry configures retry behavior for failed requests. Requests that fail without a
+// response are retried, as are 429 and 503 responses carrying a Retry-After header, which
+// is honored up to maxWaitTime (see WithMaxRetryAfter).
 //
 // Example:
 //
@@ -160,6 +336,7 @@ func WithDebug(debug bool) Option {
 }
 
 // WithHeaders adds custom headers to all requests.
+// Use WithRequestHeaders to set headers for a single call instead.
 //
 // Example:
 //
@@ -189,7 +366,8 @@ func WithUserAgent(userAgent string) Option {
 	}
 }
 
-// WithProxy sets a proxy URL for all requests.
+// WithProxy sets a proxy URL for all requests, including token requests. With
+// WithHTTPClient, the proxy is set on the transport of the supplied client instead.
 //
 // Example:
 //
@@ -198,14 +376,364 @@ func WithUserAgent(userAgent string) Option {
 //	)
 func WithProxy(proxyURL string) Option {
 	return func(c *Client) error {
-		c.resty.SetProxy(proxyURL)
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		// Applied by configureProxy, once it is known whether a custom client is used
+		c.proxyURL = u
+		return nil
+	}
+}
+
+// WithProxyBasicAuth authenticates to the proxy with Basic credentials, sent as the
+// Proxy-Authorization header on CONNECT (HTTPS targets) and on proxied HTTP requests. It
+// applies to the proxy set with WithProxy or taken from the environment (HTTPS_PROXY and
+// friends), and avoids embedding credentials in the proxy URL, where special characters
+// must be escaped. Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithProxy("http://proxy.example.com:8080"),
+//	    keycloak.WithProxyBasicAuth("user", os.Getenv("PROXY_PASSWORD")),
+//	)
+func WithProxyBasicAuth(username, password string) Option {
+	return func(c *Client) error {
+		if username == "" {
+			return fmt.Errorf("proxy username cannot be empty")
+		}
+		if c.transport == nil {
+			return fmt.Errorf("transport is not configurable")
+		}
+		c.proxyAuth = url.UserPassword(username, password)
+		return nil
+	}
+}
+
+// WithMaxConcurrentRequests limits the number of requests that may be in flight at the same time.
+// Once the limit is reached, further requests block until a slot is released or their
+// context is cancelled. This protects a shared Keycloak instance (and the local connection
+// pool) from bursts of goroutines using the same client.
+// Default is no limit if not specified.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithMaxConcurrentRequests(10))
+func WithMaxConcurrentRequests(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("max concurrent requests must be positive, got %d", n)
+		}
+		c.maxConcurrent = n
+		return nil
+	}
+}
+
+// WithRetryOnNetworkError retries idempotent requests (GET, HEAD, OPTIONS, PUT, DELETE) up to
+// count times when they fail with a transport-level error such as "connection reset by peer"
+// or an unexpected EOF. This typically happens when a load balancer in front of Keycloak closes
+// idle connections. Unlike WithRetry, which retries based on the outcome of a request, these
+// retries happen immediately and only for dropped connections.
+// Default is no network error retries if not specified.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithRetryOnNetworkError(2))
+func WithRetryOnNetworkError(count int) Option {
+	return func(c *Client) error {
+		if count < 0 {
+			return fmt.Errorf("network retry count must be non-negative, got %d", count)
+		}
+		c.networkRetry = count
+		return nil
+	}
+}
+
+// WithStrictAttributeMatching controls how attribute lookups treat multi-value attributes.
+// By default, GetByAttribute and GetSubGroupByAttribute match a group if any value of the
+// attribute equals the searched value. In strict mode, only single-value attributes match,
+// and ErrAmbiguousAttribute is returned when the value was found only within a multi-value
+// attribute, so callers can tell an ambiguous match apart from a missing one.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithStrictAttributeMatching(true))
+func WithStrictAttributeMatching(strict bool) Option {
+	return func(c *Client) error {
+		c.strictAttrs = strict
+		return nil
+	}
+}
+
+// WithAttributeWildcardSearch makes GroupsClient.ListWithAttribute search with q=key:* instead
+// of filtering a full listing client-side. Keycloak matches q values literally, so only enable
+// this for servers (or server extensions) that treat * as "any value". Default is false.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithAttributeWildcardSearch(true))
+func WithAttributeWildcardSearch(enabled bool) Option {
+	return func(c *Client) error {
+		c.attrWildcard = enabled
+		return nil
+	}
+}
+
+// WithImpersonationEnabled allows UsersClient.Impersonate. Impersonation grants full access
+// to a user's account, so it is disabled by default to prevent accidental exposure, e.g.
+// through a generic admin tool. Each impersonation is logged as a warning (see WithLogger).
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithImpersonationEnabled(true))
+func WithImpersonationEnabled(enabled bool) Option {
+	return func(c *Client) error {
+		c.impersonation = enabled
+		return nil
+	}
+}
+
+// WithDefaultAttributes sets attributes that are added to every group created with
+// Groups.Create or Groups.CreateSubGroup, e.g. to tag groups with their provenance.
+// Attributes passed to the create call override defaults with the same key.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithDefaultAttributes(map[string][]string{
+//	        "managedBy": {"automation"},
+//	    }),
+//	)
+func WithDefaultAttributes(attributes map[string][]string) Option {
+	return func(c *Client) error {
+		c.defaultAttrs = make(map[string][]string, len(attributes))
+		for key, values := range attributes {
+			c.defaultAttrs[key] = append([]string(nil), values...)
+		}
+		return nil
+	}
+}
+
+// ResourceDefaults holds per-resource defaults for optional request parameters, set with
+// WithResourceDefaults. A default applies only to its resource, and only when the parameter
+// of a call is nil; nil defaults leave Keycloak's own defaults in place.
+type ResourceDefaults struct {
+	// GroupsBrief is the BriefRepresentation of group listings taking SearchGroupParams
+	// (ListWithParams, ListTopLevel, ListSorted, ...).
+	GroupsBrief *bool
+
+	// SubGroupsBrief is the BriefRepresentation of ListSubGroupsPaginated.
+	SubGroupsBrief *bool
+
+	// MembersBrief is the BriefRepresentation of member listings (ListMembers,
+	// ListMembersPage and IterateMembers).
+	MembersBrief *bool
+}
+
+// WithResourceDefaults sets per-resource defaults for optional request parameters, e.g. to
+// always list members briefly while fetching groups in full. Parameters set on a call take
+// precedence, and methods that need a particular representation (such as ListWithAttribute,
+// which needs attributes) keep requesting it.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithResourceDefaults(keycloak.ResourceDefaults{
+//	        GroupsBrief:  ptr.Bool(false),
+//	        MembersBrief: ptr.Bool(true),
+//	    }),
+//	)
+func WithResourceDefaults(defaults ResourceDefaults) Option {
+	return func(c *Client) error {
+		c.defaults = defaults
+		return nil
+	}
+}
+
+// WithAttributeLimits validates group attributes on the client before Groups.Create,
+// Groups.CreateSubGroup and Groups.Update send them, so that attribute sets Keycloak would
+// reject are reported with ErrAttributeLimitExceeded instead of a bare 400. The limits are
+// the number of attribute keys, the number of values per key and the length of each value
+// in characters; zero disables a limit. By default, no limits are enforced.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithAttributeLimits(50, 10, 255))
+func WithAttributeLimits(maxKeys, maxValuesPerKey, maxValueLen int) Option {
+	return func(c *Client) error {
+		if maxKeys < 0 || maxValuesPerKey < 0 || maxValueLen < 0 {
+			return fmt.Errorf("attribute limits cannot be negative, got %d, %d, %d", maxKeys, maxValuesPerKey, maxValueLen)
+		}
+		c.attrLimits = attributeLimits{
+			maxKeys:         maxKeys,
+			maxValuesPerKey: maxValuesPerKey,
+			maxValueLen:     maxValueLen,
+		}
+		return nil
+	}
+}
+
+// WithSuccessValidator sets a function that inspects every successful (2xx) response.
+// Returning a non-nil error turns the response into a failure, which the calling method
+// reports like any other request error. This is useful for gateways that answer 200 with
+// an error envelope. By default, any 2xx response is treated as success.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithSuccessValidator(func(resp *http.Response, body []byte) error {
+//	        if bytes.Contains(body, []byte(`"gatewayError"`)) {
+//	            return errors.New("gateway rejected the request")
+//	        }
+//	        return nil
+//	    }),
+//	)
+func WithSuccessValidator(validator func(resp *http.Response, body []byte) error) Option {
+	return func(c *Client) error {
+		if validator == nil {
+			return fmt.Errorf("success validator cannot be nil")
+		}
+		c.validator = validator
+		return nil
+	}
+}
+
+// WithResponseContentTypeCheck makes successful (2xx) responses with a body fail with
+// ErrUnexpectedContentType unless their Content-Type is JSON (application/json or a +json
+// type). A proxy or a wrong base URL can answer 200 with an HTML page, e.g. a login page,
+// which would otherwise decode into an empty result without any error. Responses without a
+// body, such as 201 and 204, are not checked.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithResponseContentTypeCheck())
+func WithResponseContentTypeCheck() Option {
+	return func(c *Client) error {
+		c.checkContentType = true
+		return nil
+	}
+}
+
+// WithKeepAlive sets the TCP keep-alive period of connections to Keycloak (and the proxy),
+// for API and token requests alike. Long-lived services can tune it to detect dead
+// connections early, e.g. behind load balancers that drop idle connections silently;
+// short-lived CLIs can disable keep-alive probes with 0. Default is 30 seconds, like
+// http.DefaultTransport. Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithKeepAlive(15*time.Second))
+func WithKeepAlive(d time.Duration) Option {
+	return func(c *Client) error {
+		if d < 0 {
+			return fmt.Errorf("keep-alive period must be non-negative, got %v", d)
+		}
+		if c.dialer == nil {
+			return fmt.Errorf("transport is not configurable")
+		}
+		if d == 0 {
+			d = -1 // a negative period disables keep-alive probes in net.Dialer
+		}
+		c.dialer.KeepAlive = d
+		return nil
+	}
+}
+
+// WithDisableCompression disables transparent gzip compression on the transport
+// used underneath the OAuth2 client. This can work around proxies that mangle
+// compressed responses and makes raw body capture in debug mode easier.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithDisableCompression(true))
+func WithDisableCompression(disable bool) Option {
+	return func(c *Client) error {
+		if c.transport == nil {
+			return fmt.Errorf("transport is not configurable")
+		}
+		c.transport.DisableCompression = disable
+		return nil
+	}
+}
+
+// WithForceGzip always sends Accept-Encoding: gzip, to save bandwidth on large list responses
+// over slow links. Go's transport already requests gzip on its own, but not in every case,
+// e.g. with WithDisableCompression or a custom client supplied via WithHTTPClient whose
+// transport does not. When the header is set explicitly the transport leaves decompression
+// to the caller; gzip responses are then decompressed by the client, including streamed
+// list responses (see WithStreamingDecode).
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithForceGzip(true))
+func WithForceGzip(enabled bool) Option {
+	return func(c *Client) error {
+		if enabled {
+			c.resty.SetHeader("Accept-Encoding", "gzip")
+		} else {
+			c.resty.Header.Del("Accept-Encoding")
+		}
+		return nil
+	}
+}
+
+// WithMaxResponseHeaderBytes limits the size of response headers the transport accepts,
+// protecting the client against misbehaving gateways sending huge headers. Responses
+// exceeding the limit fail with a transport error. Zero restores Go's default (see
+// http.Transport.MaxResponseHeaderBytes); negative values are rejected.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithMaxResponseHeaderBytes(64<<10))
+func WithMaxResponseHeaderBytes(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("max response header bytes cannot be negative, got %d", n)
+		}
+		if c.transport == nil {
+			return fmt.Errorf("transport is not configurable")
+		}
+		c.transport.MaxResponseHeaderBytes = int64(n)
+		return nil
+	}
+}
+
+// WithUnixSocket makes the client connect to Keycloak through the Unix domain socket at path,
+// e.g. one exposed by a sidecar or service mesh, instead of over TCP. URLs keep the host of the
+// configured base URL, so the Host header and TLS server name still route the request. All
+// connections of the client use the socket, including token requests and any proxy; it
+// replaces TCP dialing, so WithDNSCache has no effect alongside it.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithUnixSocket("/run/keycloak/keycloak.sock"))
+func WithUnixSocket(path string) Option {
+	return func(c *Client) error {
+		if path == "" {
+			return fmt.Errorf("unix socket path cannot be empty")
+		}
+		if c.transport == nil {
+			return fmt.Errorf("transport is not configurable")
+		}
+		dialer := c.dialer
+		if dialer == nil {
+			dialer = &net.Dialer{}
+		}
+		c.transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}
 		return nil
 	}
 }
 
 // New creates a new Keycloak client with the provided configuration and options.
 // It establishes OAuth2 authentication using the client credentials flow
-// and returns a ready-to-use client.
+// and returns a ready-to-use client. Options are applied before the realm's
+// OIDC configuration is discovered, so invalid options fail fast.
 //
 // The client automatically manages token refresh and includes the access token
 // in all API requests.
@@ -237,33 +765,29 @@ func New(ctx context.Context, config Config, opts ...Option) (*Client, error) {
 	if config.ClientID == "" {
 		return nil, fmt.Errorf("clientID is required")
 	}
-	if config.ClientSecret == "" {
-		return nil, fmt.Errorf("clientSecret is required")
-	}
 
-	realmURL, err := url.JoinPath(config.URL, realmsPath, config.Realm)
-	if err != nil {
-		return nil, fmt.Errorf("invalid base URL: %w", err)
+	authBaseURL := config.URL
+	if config.TokenURLBase != "" {
+		authBaseURL = config.TokenURLBase
 	}
-
-	oidcProvider, err := oidc.NewProvider(ctx, realmURL)
+	realmURL, err := url.JoinPath(authBaseURL, realmsPath, config.Realm)
 	if err != nil {
-		return nil, fmt.Errorf("login failed: %w", err)
-	}
-
-	oauthClient := clientcredentials.Config{
-		ClientID:     config.ClientID,
-		ClientSecret: config.ClientSecret,
-		TokenURL:     oidcProvider.Endpoint().TokenURL,
+		return nil, fmt.Errorf("invalid base URL: %w", err)
 	}
 
 	// Initialize client with defaults
+	dialer := newDialer()
 	client := &Client{
-		resty:    resty.NewWithClient(oauthClient.Client(ctx)),
-		config:   config,
-		baseURL:  config.URL,
-		realm:    config.Realm,
-		pageSize: defaultSize, // default, can be overridden by options
+		resty:     resty.New(),
+		dialer:    dialer,
+		transport: newTransport(dialer),
+		config:    config,
+		baseURL:   config.URL,
+		realm:     config.Realm,
+		pageSize:  defaultSize,     // default, can be overridden by options
+		maxPages:  defaultMaxPages, // default, can be overridden by options
+
+		reconcileConcurrency: defaultReconcileConcurrency,
 	}
 
 	// Apply functional options
@@ -273,8 +797,169 @@ func New(ctx context.Context, config Config, opts ...Option) (*Client, error) {
 		}
 	}
 
+	// Public clients authenticate with a token obtained elsewhere
+	if config.ClientSecret == "" && client.userTokenSource == nil {
+		return nil, fmt.Errorf("clientSecret is required")
+	}
+
+	client.configureProxy()
+
+	// Authentication is configured after the options, since several of them
+	// affect the transport and the token source.
+	if err := client.configureAuth(ctx, realmURL); err != nil {
+		return nil, err
+	}
+
+	client.setup()
+
 	// Initialize resource clients (after all options applied)
-	client.Groups = newGroupsClient(client)
+	client.initResourceClients()
+	client.warmup(ctx)
 
 	return client, nil
 }
+
+// Close releases the resources held by the client, for a clean shutdown of services and
+// tests. It closes the idle connections of the transport and waits for running
+// WithAfterTokenRefresh and WithAuditHook callbacks to return; the client starts no other
+// background goroutines (the DNS cache of WithDNSCache works without one).
+//
+// In-flight requests are not aborted by Close: cancel their contexts for that. Connections
+// they return to the pool afterwards stay open until they time out or Close is called again.
+// The client remains usable after Close, opening new connections as needed; Close can be
+// called any number of times and always returns nil.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config)
+//	if err != nil {
+//	    return err
+//	}
+//	defer client.Close()
+func (c *Client) Close() error {
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
+	}
+	if c.customHTTPClient {
+		c.resty.GetClient().CloseIdleConnections()
+	}
+	c.background.Wait()
+	return nil
+}
+
+// runBackground runs fn on its own goroutine, off the request path, and lets Close wait for it.
+func (c *Client) runBackground(fn func()) {
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		fn()
+	}()
+}
+
+// setup wires the transport wrappers and response middleware required by the
+// configured options. It must be called once, after all options have been applied.
+func (c *Client) setup() {
+	c.resty.SetJSONUnmarshaler(unmarshalJSON)
+	c.resty.OnBeforeRequest(checkContext)
+	c.resty.OnBeforeRequest(applyRequestHeaders)
+	c.resty.OnBeforeRequest(applyTokenOverride)
+
+	// Only matters with WithRetry, which sets the retry count
+	c.resty.AddRetryCondition(retryOnRetryAfter)
+	c.resty.SetRetryAfter(c.retryAfter)
+
+	httpClient := c.resty.GetClient()
+	// Applied here so that it wins over WithTimeout regardless of the option order
+	if c.contextOnlyTimeouts {
+		httpClient.Timeout = 0
+	}
+	// Endpoint deadlines apply per attempt, like the global timeout
+	if len(c.endpointTimeouts) > 0 {
+		httpClient.Transport = newEndpointTimeoutTransport(httpClient.Transport, c.endpointTimeouts)
+	}
+	// Slow calls are cancelled per attempt, so this wraps the transport before the retries
+	if c.slowThreshold > 0 && c.cancelSlowCalls {
+		httpClient.Transport = newSlowCallTransport(httpClient.Transport, c.slowThreshold, c.reportSlowCall)
+	}
+	if c.networkRetry > 0 {
+		httpClient.Transport = newNetworkRetryTransport(httpClient.Transport, c.networkRetry)
+	}
+	// The limiter wraps the retries so that a retried request keeps its slot
+	if c.maxConcurrent > 0 {
+		httpClient.Transport = newLimitTransport(httpClient.Transport, c.maxConcurrent)
+	}
+
+	// Header capture, the recorder and rate limit tracking run first so that responses rejected
+	// by later middleware are still captured, recorded and tracked
+	c.onAfterResponse(captureHeaders)
+	if c.recorder != nil && c.recorder.w != nil {
+		c.onAfterResponse(c.recorder.onResponse)
+		c.resty.OnError(c.recorder.onError)
+	}
+	if c.trackRateLimit {
+		c.onAfterResponse(c.recordRateLimit)
+	}
+
+	if c.maxLoggedBody > 0 {
+		c.truncateDebugBodies()
+	}
+
+	if c.logger != nil {
+		c.onAfterResponse(c.logResponse)
+		c.resty.OnError(c.logError)
+		if c.logSuccessErrors {
+			c.onAfterResponse(c.logSuccessError)
+		}
+	}
+
+	if c.slowThreshold > 0 {
+		c.onAfterResponse(c.checkSlowCall)
+	}
+
+	c.onAfterResponse(checkMethodAllowed)
+
+	if c.checkContentType {
+		c.onAfterResponse(checkJSONContentType)
+	}
+
+	if c.validator != nil {
+		c.onAfterResponse(c.validateResponse)
+	}
+
+	if c.auditHook != nil {
+		c.onAfterResponse(c.auditResponse)
+	}
+}
+
+// checkJSONContentType is a resty middleware that rejects successful responses whose body is
+// not JSON. Responses without a body are accepted. Streamed responses are judged by their
+// headers alone, since their body has not been read yet.
+func checkJSONContentType(_ *resty.Client, resp *resty.Response) error {
+	if !resp.IsSuccess() || resp.StatusCode() == http.StatusNoContent ||
+		resp.RawResponse == nil || resp.RawResponse.ContentLength == 0 {
+		return nil
+	}
+	contentType := resp.Header().Get("Content-Type")
+	if contentType == "" && len(resp.Body()) == 0 {
+		return nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+		(mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+	return fmt.Errorf("%w %q from %s %s: expected JSON; the request may have reached a login page or a proxy "+
+		"instead of the Admin API, so check the base URL, realm and credentials",
+		ErrUnexpectedContentType, contentType, resp.Request.Method, requestPath(resp.Request))
+}
+
+// validateResponse applies the configured success validator to successful responses.
+// Failing responses are left to the regular error handling of each method.
+func (c *Client) validateResponse(_ *resty.Client, resp *resty.Response) error {
+	if !resp.IsSuccess() {
+		return nil
+	}
+	if err := c.validator(resp.RawResponse, resp.Body()); err != nil {
+		return fmt.Errorf("response validation failed: %w", err)
+	}
+	return nil
+}
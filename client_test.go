@@ -17,13 +17,29 @@
 package keycloak
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.companyinfo.dev/ptr"
+	"golang.org/x/oauth2"
 )
 
 func TestWithPageSize(t *testing.T) {
@@ -68,6 +84,64 @@ func TestWithPageSize(t *testing.T) {
 	}
 }
 
+func TestWithMaxPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int
+		wantErr   bool
+		wantValue int
+	}{
+		{name: "valid limit", n: 10, wantValue: 10},
+		{name: "zero limit", n: 0, wantErr: true},
+		{name: "negative limit", n: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{maxPages: defaultMaxPages}
+			err := WithMaxPages(tt.n)(client)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, defaultMaxPages, client.maxPages)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValue, client.maxPages)
+		})
+	}
+}
+
+func TestWithAttributeLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    [3]int
+		wantErr   bool
+		wantValue attributeLimits
+	}{
+		{name: "all limits", limits: [3]int{10, 5, 255}, wantValue: attributeLimits{maxKeys: 10, maxValuesPerKey: 5, maxValueLen: 255}},
+		{name: "zero disables", limits: [3]int{0, 0, 0}, wantValue: attributeLimits{}},
+		{name: "negative keys", limits: [3]int{-1, 0, 0}, wantErr: true},
+		{name: "negative values per key", limits: [3]int{0, -1, 0}, wantErr: true},
+		{name: "negative value length", limits: [3]int{0, 0, -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{}
+			err := WithAttributeLimits(tt.limits[0], tt.limits[1], tt.limits[2])(client)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, attributeLimits{}, client.attrLimits)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValue, client.attrLimits)
+		})
+	}
+}
+
 func TestWithTimeout(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -108,6 +182,46 @@ func TestWithTimeout(t *testing.T) {
 	}
 }
 
+// TestWithContextOnlyTimeouts tests that a longer context deadline is not cut short by the
+// client timeout, whichever order the options are given in
+func TestWithContextOnlyTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"g1","name":"team"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := newTestClient(server.URL, WithTimeout(50*time.Millisecond)).Groups.Get(ctx, "g1")
+	require.Error(t, err, "the client timeout should apply without the option")
+
+	for _, opts := range [][]Option{
+		{WithTimeout(50 * time.Millisecond), WithContextOnlyTimeouts()},
+		{WithContextOnlyTimeouts(), WithTimeout(50 * time.Millisecond)},
+	} {
+		client := newTestClient(server.URL, opts...)
+		assert.Zero(t, client.resty.GetClient().Timeout)
+		group, err := client.Groups.Get(ctx, "g1")
+		require.NoError(t, err)
+		assert.Equal(t, "team", ptr.ToString(group.Name))
+	}
+
+	t.Run("context deadline still applies", func(t *testing.T) {
+		client := newTestClient(server.URL, WithContextOnlyTimeouts())
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := client.Groups.Get(ctx, "g1")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
 func TestWithRetry(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -214,6 +328,90 @@ func TestWithProxy(t *testing.T) {
 	// At least verify no error occurred
 }
 
+// TestWithProxy_CustomHTTPClient tests that WithProxy applies to the client supplied with
+// WithHTTPClient, regardless of the option order, without changing the caller's transport
+func TestWithProxy_CustomHTTPClient(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxied request carries the absolute URL of the target
+		assert.Equal(t, "keycloak.invalid", r.URL.Host)
+		proxied.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer proxy.Close()
+
+	config := Config{URL: "http://keycloak.invalid", Realm: "test-realm", ClientID: "test-client", ClientSecret: "secret"}
+	for _, tt := range []struct {
+		name string
+		opts func(*http.Client) []Option
+	}{
+		{name: "client first", opts: func(hc *http.Client) []Option { return []Option{WithHTTPClient(hc), WithProxy(proxy.URL)} }},
+		{name: "proxy first", opts: func(hc *http.Client) []Option { return []Option{WithProxy(proxy.URL), WithHTTPClient(hc)} }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			proxied.Store(0)
+			transport := &http.Transport{}
+			client, err := New(context.Background(), config, tt.opts(&http.Client{Transport: transport})...)
+			require.NoError(t, err)
+
+			_, err = client.Groups.List(context.Background(), nil, true)
+			require.NoError(t, err)
+			assert.Equal(t, int32(1), proxied.Load())
+			assert.Nil(t, transport.Proxy)
+		})
+	}
+}
+
+func TestWithProxyBasicAuth(t *testing.T) {
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, WithProxyBasicAuth("", "secret")(&Client{transport: newTransport(newDialer())}))
+		assert.Error(t, WithProxyBasicAuth("user", "secret")(&Client{resty: newTestRestyClient()}))
+	})
+
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:p@ss:word"))
+
+	t.Run("HTTP request", func(t *testing.T) {
+		var gotAuth, gotHost string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = r.Header.Get("Proxy-Authorization")
+			gotHost = r.Host
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+		defer proxy.Close()
+
+		// Credentials apply regardless of the option order
+		client := newTestClient("http://keycloak.invalid",
+			WithProxyBasicAuth("user", "p@ss:word"),
+			WithProxy(proxy.URL),
+		)
+		_, err := client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, wantAuth, gotAuth)
+		assert.Equal(t, "keycloak.invalid", gotHost)
+	})
+
+	t.Run("CONNECT", func(t *testing.T) {
+		var gotMethod, gotAuth string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotMethod = r.Method
+			gotAuth = r.Header.Get("Proxy-Authorization")
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer proxy.Close()
+
+		client := newTestClient("https://keycloak.invalid",
+			WithProxy(proxy.URL),
+			WithProxyBasicAuth("user", "p@ss:word"),
+		)
+		_, err := client.Groups.List(context.Background(), nil, true)
+		assert.Error(t, err)
+		assert.Equal(t, http.MethodConnect, gotMethod)
+		assert.Equal(t, wantAuth, gotAuth)
+	})
+}
+
 func TestWithHTTPClient(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -241,13 +439,1022 @@ func TestWithHTTPClient(t *testing.T) {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				// Verify HTTP client was set
-				assert.Equal(t, tt.httpClient, client.resty.GetClient())
+				// Verify a copy of the HTTP client was set
+				assert.NotSame(t, tt.httpClient, client.resty.GetClient())
+				assert.Equal(t, tt.httpClient.Timeout, client.resty.GetClient().Timeout)
+			}
+		})
+	}
+
+	t.Run("caller's client untouched", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		transport := &http.Transport{}
+		custom := &http.Client{Transport: transport, Timeout: time.Minute}
+		client := newTestClient(server.URL, WithHTTPClient(custom), WithMaxConcurrentRequests(2))
+		_, err := client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+
+		assert.Same(t, transport, custom.Transport)
+		assert.IsType(t, &limitTransport{}, client.resty.GetClient().Transport)
+		assert.Equal(t, time.Minute, client.resty.GetClient().Timeout)
+	})
+}
+
+func TestWithMaxConcurrentRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		wantErr bool
+	}{
+		{
+			name:    "valid limit",
+			limit:   5,
+			wantErr: false,
+		},
+		{
+			name:    "zero limit",
+			limit:   0,
+			wantErr: true,
+		},
+		{
+			name:    "negative limit",
+			limit:   -1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{resty: newTestRestyClient()}
+			err := WithMaxConcurrentRequests(tt.limit)(client)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.limit, client.maxConcurrent)
+			}
+		})
+	}
+}
+
+func TestWithMaxConcurrentRequests_BoundsConcurrency(t *testing.T) {
+	const limit = 2
+	const requests = 10
+
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithMaxConcurrentRequests(limit))
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Groups.List(context.Background(), nil, true)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(limit))
+	assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(0))
+}
+
+func TestWithMaxConcurrentRequests_ContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := newTestClient(server.URL, WithMaxConcurrentRequests(1))
+
+	// Occupy the only slot
+	go client.Groups.List(context.Background(), nil, true)
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Groups.List(ctx, nil, true)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWithKeepAlive(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       time.Duration
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "custom period", d: 15 * time.Second, want: 15 * time.Second},
+		{name: "zero disables", d: 0, want: -1},
+		{name: "negative period", d: -time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := newDialer()
+			client := &Client{dialer: dialer, transport: newTransport(dialer)}
+			err := WithKeepAlive(tt.d)(client)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, 30*time.Second, dialer.KeepAlive)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, dialer.KeepAlive)
+		})
+	}
+
+	t.Run("no dialer", func(t *testing.T) {
+		assert.Error(t, WithKeepAlive(time.Second)(&Client{}))
+	})
+
+	t.Run("applied to the transport under the OAuth2 client", func(t *testing.T) {
+		server := newTestOIDCServer(3600, nil)
+		defer server.Close()
+
+		client, err := New(context.Background(), server.config(), WithKeepAlive(15*time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Second, client.dialer.KeepAlive)
+
+		overrideTransport, ok := client.resty.GetClient().Transport.(*tokenOverrideTransport)
+		require.True(t, ok)
+		assert.Same(t, client.transport, overrideTransport.base)
+		oauthTransport, ok := overrideTransport.auth.(*oauth2.Transport)
+		require.True(t, ok)
+		assert.Same(t, client.transport, oauthTransport.Base)
+
+		// Connections are dialed with the configured dialer
+		var dialed atomic.Int32
+		dial := client.transport.DialContext
+		client.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed.Add(1)
+			return dial(ctx, network, addr)
+		}
+		client.transport.CloseIdleConnections()
+		_, err = client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+		assert.Positive(t, dialed.Load())
+	})
+}
+
+func TestWithDisableCompression(t *testing.T) {
+	tests := []struct {
+		name    string
+		disable bool
+	}{
+		{
+			name:    "disable compression",
+			disable: true,
+		},
+		{
+			name:    "enable compression",
+			disable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{resty: newTestRestyClient(), transport: newTransport(newDialer())}
+			err := WithDisableCompression(tt.disable)(client)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.disable, client.transport.DisableCompression)
+		})
+	}
+
+	t.Run("no transport", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		err := WithDisableCompression(true)(client)
+		assert.Error(t, err)
+	})
+
+	t.Run("gzip not requested when disabled", func(t *testing.T) {
+		var acceptEncoding string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			acceptEncoding = r.Header.Get("Accept-Encoding")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithDisableCompression(true))
+		_, err := client.Groups.List(context.Background(), nil, true)
+		assert.NoError(t, err)
+		assert.Empty(t, acceptEncoding)
+	})
+}
+
+func TestWithMaxResponseHeaderBytes(t *testing.T) {
+	client := &Client{resty: newTestRestyClient(), transport: newTransport(newDialer())}
+	assert.NoError(t, WithMaxResponseHeaderBytes(4096)(client))
+	assert.Equal(t, int64(4096), client.transport.MaxResponseHeaderBytes)
+	assert.NoError(t, WithMaxResponseHeaderBytes(0)(client))
+	assert.Zero(t, client.transport.MaxResponseHeaderBytes)
+	assert.Error(t, WithMaxResponseHeaderBytes(-1)(client))
+
+	t.Run("no transport", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithMaxResponseHeaderBytes(4096)(client))
+	})
+
+	t.Run("oversized headers rejected", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Padding", strings.Repeat("x", 8192))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithMaxResponseHeaderBytes(1024))
+		_, err := client.Groups.List(context.Background(), nil, true)
+		assert.ErrorContains(t, err, "header")
+
+		client = newTestClient(server.URL)
+		_, err = client.Groups.List(context.Background(), nil, true)
+		assert.NoError(t, err)
+	})
+}
+
+func TestWithUnixSocket(t *testing.T) {
+	client := &Client{resty: newTestRestyClient(), transport: newTransport(newDialer())}
+	assert.Error(t, WithUnixSocket("")(client))
+
+	t.Run("no transport", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithUnixSocket("/tmp/keycloak.sock")(client))
+	})
+
+	t.Run("requests use the socket", func(t *testing.T) {
+		// Keep the path short: socket paths are limited to about 100 bytes
+		dir, err := os.MkdirTemp("", "kc")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		listener, err := net.Listen("unix", filepath.Join(dir, "keycloak.sock"))
+		require.NoError(t, err)
+		hosts := make(chan string, 1)
+		server := &httptest.Server{
+			Listener: listener,
+			Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hosts <- r.Host
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[]`))
+			})},
+		}
+		server.Start()
+		defer server.Close()
+
+		client := newTestClient("http://keycloak.internal:8080", WithUnixSocket(listener.Addr().String()))
+		_, err = client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, "keycloak.internal:8080", <-hosts)
+	})
+}
+
+func TestWithForceGzip(t *testing.T) {
+	var acceptEncoding atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding.Store(r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte(`[{"id":"g1","name":"plain"}]`))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`[{"id":"g1","name":"compressed"}]`))
+		gz.Close()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantName string
+	}{
+		{name: "buffered", opts: []Option{WithForceGzip(true)}, wantName: "compressed"},
+		{name: "streamed", opts: []Option{WithForceGzip(true), WithStreamingDecode(true)}, wantName: "compressed"},
+		{name: "with compression disabled", opts: []Option{WithDisableCompression(true), WithForceGzip(true)}, wantName: "compressed"},
+		{name: "compression disabled without force", opts: []Option{WithDisableCompression(true)}, wantName: "plain"},
+		{name: "force turned off", opts: []Option{WithDisableCompression(true), WithForceGzip(true), WithForceGzip(false)}, wantName: "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(server.URL, tt.opts...)
+			groups, err := client.Groups.List(context.Background(), nil, true)
+			require.NoError(t, err)
+			require.Len(t, groups, 1)
+			assert.Equal(t, tt.wantName, *groups[0].Name)
+			if tt.wantName == "compressed" {
+				assert.Equal(t, "gzip", acceptEncoding.Load())
+			}
+		})
+	}
+}
+
+func TestWithResourceDefaults(t *testing.T) {
+	var mu sync.Mutex
+	brief := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		value, ok := r.URL.Query()["briefRepresentation"]
+		if ok {
+			brief[r.URL.Path] = value[0]
+		} else {
+			brief[r.URL.Path] = "unset"
+		}
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	const (
+		groupsPath    = "/admin/realms/test-realm/groups"
+		subGroupsPath = "/admin/realms/test-realm/groups/g1/children"
+		membersPath   = "/admin/realms/test-realm/groups/g1/members"
+	)
+	listAll := func(t *testing.T, client *Client, explicit *bool) map[string]string {
+		t.Helper()
+		mu.Lock()
+		clear(brief)
+		mu.Unlock()
+		ctx := context.Background()
+		_, err := client.Groups.ListWithParams(ctx, SearchGroupParams{BriefRepresentation: explicit})
+		require.NoError(t, err)
+		_, err = client.Groups.ListSubGroupsPaginated(ctx, "g1", SubGroupSearchParams{BriefRepresentation: explicit})
+		require.NoError(t, err)
+		_, err = client.Groups.ListMembers(ctx, "g1", GroupMembersParams{BriefRepresentation: explicit})
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		return maps.Clone(brief)
+	}
+
+	tests := []struct {
+		name     string
+		defaults ResourceDefaults
+		explicit *bool
+		want     map[string]string
+	}{
+		{
+			name: "no defaults",
+			want: map[string]string{groupsPath: "unset", subGroupsPath: "unset", membersPath: "unset"},
+		},
+		{
+			name:     "groups default only",
+			defaults: ResourceDefaults{GroupsBrief: ptr.Bool(false)},
+			want:     map[string]string{groupsPath: "false", subGroupsPath: "unset", membersPath: "unset"},
+		},
+		{
+			name:     "subgroups default only",
+			defaults: ResourceDefaults{SubGroupsBrief: ptr.Bool(true)},
+			want:     map[string]string{groupsPath: "unset", subGroupsPath: "true", membersPath: "unset"},
+		},
+		{
+			name:     "members default only",
+			defaults: ResourceDefaults{MembersBrief: ptr.Bool(true)},
+			want:     map[string]string{groupsPath: "unset", subGroupsPath: "unset", membersPath: "true"},
+		},
+		{
+			name:     "all defaults",
+			defaults: ResourceDefaults{GroupsBrief: ptr.Bool(false), SubGroupsBrief: ptr.Bool(false), MembersBrief: ptr.Bool(true)},
+			want:     map[string]string{groupsPath: "false", subGroupsPath: "false", membersPath: "true"},
+		},
+		{
+			name:     "explicit parameters win",
+			defaults: ResourceDefaults{GroupsBrief: ptr.Bool(false), SubGroupsBrief: ptr.Bool(false), MembersBrief: ptr.Bool(false)},
+			explicit: ptr.Bool(true),
+			want:     map[string]string{groupsPath: "true", subGroupsPath: "true", membersPath: "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(server.URL, WithResourceDefaults(tt.defaults))
+			assert.Equal(t, tt.want, listAll(t, client, tt.explicit))
+		})
+	}
+
+	t.Run("methods requiring a representation keep it", func(t *testing.T) {
+		client := newTestClient(server.URL, WithResourceDefaults(ResourceDefaults{GroupsBrief: ptr.Bool(true)}))
+		_, err := client.Groups.ListWithAttribute(context.Background(), "dept", SearchGroupParams{})
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "false", brief[groupsPath])
+	})
+}
+
+// TestWithResponseContentTypeCheck tests that successful non-JSON responses fail instead of
+// decoding into empty results
+func TestWithResponseContentTypeCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("search") == "html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><body>Sign in to your account</body></html>`))
+		case r.Method == http.MethodPost:
+			w.Header().Set("Location", "http://"+r.Host+r.URL.Path+"/new-id")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+			w.Write([]byte(`[{"id":"g1"}]`))
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	// Without the check, the HTML page silently yields no groups
+	groups, err := newTestClient(server.URL).Groups.List(ctx, ptr.String("html"), true)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	for _, streaming := range []bool{false, true} {
+		t.Run(fmt.Sprintf("streaming %v", streaming), func(t *testing.T) {
+			client := newTestClient(server.URL, WithResponseContentTypeCheck(), WithStreamingDecode(streaming))
+
+			_, err := client.Groups.List(ctx, ptr.String("html"), true)
+			assert.ErrorIs(t, err, ErrUnexpectedContentType)
+			assert.ErrorContains(t, err, `"text/html; charset=utf-8"`)
+
+			groups, err := client.Groups.List(ctx, nil, true)
+			require.NoError(t, err)
+			assert.Len(t, groups, 1)
+
+			_, err = client.Groups.Create(ctx, "team", nil)
+			assert.NoError(t, err)
+			assert.NoError(t, client.Groups.Delete(ctx, "g1"))
+		})
+	}
+}
+
+func TestWithSuccessValidator(t *testing.T) {
+	errGatewayEnvelope := errors.New("gateway error envelope")
+	validator := func(resp *http.Response, body []byte) error {
+		if strings.Contains(string(body), `"gatewayError"`) {
+			return errGatewayEnvelope
+		}
+		return nil
+	}
+
+	t.Run("nil validator", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		err := WithSuccessValidator(nil)(client)
+		assert.Error(t, err)
+	})
+
+	t.Run("200 with error envelope fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"gatewayError":"upstream unavailable"}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithSuccessValidator(validator))
+		_, err := client.Groups.Get(context.Background(), "group-id")
+		assert.ErrorIs(t, err, errGatewayEnvelope)
+	})
+
+	t.Run("valid 200 passes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"group-id","name":"Group"}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithSuccessValidator(validator))
+		group, err := client.Groups.Get(context.Background(), "group-id")
+		assert.NoError(t, err)
+		assert.Equal(t, "group-id", *group.ID)
+	})
+
+	t.Run("error responses are not validated", func(t *testing.T) {
+		called := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithSuccessValidator(func(*http.Response, []byte) error {
+			called = true
+			return nil
+		}))
+		_, err := client.Groups.Get(context.Background(), "group-id")
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+		assert.False(t, called)
+	})
+}
+
+func TestWithAfterTokenRefresh(t *testing.T) {
+	t.Run("nil callback", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithAfterTokenRefresh(nil)(client))
+	})
+
+	t.Run("callback fires on refresh", func(t *testing.T) {
+		var authHeaders []string
+		var mu sync.Mutex
+		// Tokens expiring within oauth2's 10s expiry delta are refreshed on every request
+		server := newTestOIDCServer(1, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		})
+		defer server.Close()
+
+		refreshed := make(chan string, 10)
+		client, err := New(context.Background(), server.config(),
+			WithAfterTokenRefresh(func(token *oauth2.Token) {
+				refreshed <- token.AccessToken
+			}),
+		)
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Groups.List(context.Background(), nil, true)
+			require.NoError(t, err)
+		}
+
+		var tokens []string
+		for i := 0; i < 2; i++ {
+			select {
+			case token := <-refreshed:
+				tokens = append(tokens, token)
+			case <-time.After(time.Second):
+				t.Fatal("token refresh callback was not called")
+			}
+		}
+		assert.ElementsMatch(t, []string{"token-1", "token-2"}, tokens)
+		assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, authHeaders)
+	})
+
+	t.Run("callback does not fire for cached token", func(t *testing.T) {
+		server := newTestOIDCServer(3600, nil)
+		defer server.Close()
+
+		var calls atomic.Int32
+		client, err := New(context.Background(), server.config(),
+			WithAfterTokenRefresh(func(*oauth2.Token) {
+				calls.Add(1)
+			}),
+		)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := client.Groups.List(context.Background(), nil, true)
+			require.NoError(t, err)
+		}
+
+		assert.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(1), server.tokenRequests.Load())
+	})
+}
+
+func TestWithTokenExpiryBuffer(t *testing.T) {
+	t.Run("negative buffer", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithTokenExpiryBuffer(-time.Second)(client))
+	})
+
+	t.Run("refreshes within buffer", func(t *testing.T) {
+		start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		now := start
+		var fetches int
+		source := newEarlyExpiryTokenSource(func() (*oauth2.Token, error) {
+			fetches++
+			return &oauth2.Token{AccessToken: fmt.Sprintf("token-%d", fetches), Expiry: now.Add(time.Minute)}, nil
+		}, 30*time.Second)
+		source.now = func() time.Time { return now }
+
+		for _, step := range []struct {
+			at   time.Duration
+			want string
+		}{
+			{at: 0, want: "token-1"},
+			{at: 29 * time.Second, want: "token-1"},
+			{at: 31 * time.Second, want: "token-2"}, // 29s before expiry, inside the buffer
+			{at: 60 * time.Second, want: "token-2"},
+		} {
+			now = start.Add(step.at)
+			token, err := source.Token()
+			require.NoError(t, err)
+			assert.Equal(t, step.want, token.AccessToken, "at %s", step.at)
+		}
+	})
+
+	t.Run("fetch error", func(t *testing.T) {
+		source := newEarlyExpiryTokenSource(func() (*oauth2.Token, error) {
+			return nil, errors.New("unavailable")
+		}, time.Minute)
+		_, err := source.Token()
+		assert.Error(t, err)
+	})
+
+	t.Run("applied by New", func(t *testing.T) {
+		// Tokens live for 60s; a two minute buffer treats each one as expired at once
+		server := newTestOIDCServer(60, nil)
+		defer server.Close()
+
+		client, err := New(context.Background(), server.config(), WithTokenExpiryBuffer(2*time.Minute))
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err := client.Groups.List(context.Background(), nil, true)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), server.tokenRequests.Load())
+	})
+}
+
+func TestWithInitialToken(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	assert.Error(t, WithInitialToken(nil)(client))
+	assert.Error(t, WithInitialToken(&oauth2.Token{})(client))
+
+	ctx := context.Background()
+	var mu sync.Mutex
+	var received []string
+	server := newTestOIDCServer(3600, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+	defer server.Close()
+	lastAuthorization := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return received[len(received)-1]
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "default"},
+		{name: "expiry buffer", opts: []Option{WithTokenExpiryBuffer(time.Minute)}},
+		{name: "token cache", opts: []Option{WithTokenCacheFile(filepath.Join(t.TempDir(), "token.json"))}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server.tokenRequests.Store(0)
+			seed := &oauth2.Token{AccessToken: "seeded", TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)}
+			client, err := New(ctx, server.config(), append(tt.opts, WithInitialToken(seed))...)
+			require.NoError(t, err)
+			for i := 0; i < 2; i++ {
+				_, err := client.Groups.List(ctx, nil, true)
+				require.NoError(t, err)
+				assert.Equal(t, "Bearer seeded", lastAuthorization())
+			}
+			assert.Zero(t, server.tokenRequests.Load())
+		})
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		server.tokenRequests.Store(0)
+		logger := &testLogger{}
+		expired := &oauth2.Token{AccessToken: "expired", Expiry: time.Now().Add(-time.Minute)}
+		client, err := New(ctx, server.config(), WithInitialToken(expired), WithLogger(logger))
+		require.NoError(t, err)
+		_, err = client.Groups.List(ctx, nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-1", lastAuthorization())
+		assert.Equal(t, int32(1), server.tokenRequests.Load())
+		assert.Contains(t, strings.Join(logger.Lines(), "\n"), "WARN keycloak initial token expired")
+	})
+}
+
+func TestTokenFresh(t *testing.T) {
+	now := time.Now()
+	assert.False(t, tokenFresh(nil, time.Minute, now))
+	assert.False(t, tokenFresh(&oauth2.Token{}, time.Minute, now))
+	assert.True(t, tokenFresh(&oauth2.Token{AccessToken: "t"}, time.Minute, now))
+	assert.True(t, tokenFresh(&oauth2.Token{AccessToken: "t", Expiry: now.Add(2 * time.Minute)}, time.Minute, now))
+	assert.False(t, tokenFresh(&oauth2.Token{AccessToken: "t", Expiry: now.Add(30 * time.Second)}, time.Minute, now))
+	// Without a buffer, oauth2's 10s expiry delta applies
+	assert.True(t, tokenFresh(&oauth2.Token{AccessToken: "t", Expiry: now.Add(30 * time.Second)}, 0, now))
+	assert.False(t, tokenFresh(&oauth2.Token{AccessToken: "t", Expiry: now.Add(5 * time.Second)}, 0, now))
+}
+
+// TestClient_ConcurrentUse shares one client between many goroutines. Run with -race, it
+// checks for data races; it also checks that concurrent requests share a single token fetch,
+// both for the first token and when the token expires under load.
+func TestClient_ConcurrentUse(t *testing.T) {
+	const workers = 50
+	burst := func(t *testing.T, client *Client) {
+		t.Helper()
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.Groups.List(context.Background(), nil, true)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	}
+
+	t.Run("first token", func(t *testing.T) {
+		server := newTestOIDCServer(3600, nil)
+		defer server.Close()
+		client, err := New(context.Background(), server.config())
+		require.NoError(t, err)
+
+		burst(t, client)
+		burst(t, client)
+		assert.Equal(t, int32(1), server.tokenRequests.Load())
+	})
+
+	t.Run("refresh after expiry", func(t *testing.T) {
+		// Tokens live for an hour; the buffer leaves them fresh for one second
+		server := newTestOIDCServer(3600, nil)
+		defer server.Close()
+		client, err := New(context.Background(), server.config(), WithTokenExpiryBuffer(time.Hour-time.Second))
+		require.NoError(t, err)
+
+		burst(t, client)
+		require.Equal(t, int32(1), server.tokenRequests.Load())
+		time.Sleep(1200 * time.Millisecond)
+		burst(t, client)
+		assert.Equal(t, int32(2), server.tokenRequests.Load())
+	})
+}
+
+func TestWithTokenURL(t *testing.T) {
+	t.Run("invalid URL", func(t *testing.T) {
+		for _, tokenURL := range []string{"", "/realms/test-realm/token", "ftp://keycloak/token", "https://"} {
+			client := &Client{resty: newTestRestyClient()}
+			assert.Error(t, WithTokenURL(tokenURL)(client), tokenURL)
+		}
+	})
+
+	t.Run("skips discovery", func(t *testing.T) {
+		var discoveryRequests, tokenRequests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/realms/test-realm/.well-known/openid-configuration":
+				discoveryRequests.Add(1)
+				w.WriteHeader(http.StatusNotFound)
+			case "/custom/token":
+				tokenRequests.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token":"custom-token","token_type":"Bearer","expires_in":3600}`))
+			default:
+				assert.Equal(t, "Bearer custom-token", r.Header.Get("Authorization"))
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[]`))
+			}
+		}))
+		defer server.Close()
+
+		client, err := New(context.Background(), Config{
+			URL:          server.URL,
+			Realm:        "test-realm",
+			ClientID:     "test-client",
+			ClientSecret: "test-secret",
+		}, WithTokenURL(server.URL+"/custom/token"))
+		require.NoError(t, err)
+
+		_, err = client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, int32(0), discoveryRequests.Load())
+		assert.Equal(t, int32(1), tokenRequests.Load())
+	})
+}
+
+// TestNew_TokenURLBase tests that discovery and token requests use TokenURLBase while API calls use URL
+func TestNew_TokenURLBase(t *testing.T) {
+	var apiRequests atomic.Int32
+	tokenServer := newTestOIDCServer(3600, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected API request to the token host: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer tokenServer.Close()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiRequests.Add(1)
+		assert.Equal(t, "/admin/realms/test-realm/groups", r.URL.Path)
+		assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer apiServer.Close()
+
+	config := tokenServer.config()
+	config.URL = apiServer.URL
+	config.TokenURLBase = tokenServer.URL
+
+	client, err := New(context.Background(), config)
+	require.NoError(t, err)
+
+	_, err = client.Groups.List(context.Background(), nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), tokenServer.tokenRequests.Load())
+	assert.Equal(t, int32(1), apiRequests.Load())
+}
+
+// testJWT returns an unsigned JWT carrying the given issuer.
+func testJWT(issuer string) string {
+	encode := func(v string) string { return base64.RawURLEncoding.EncodeToString([]byte(v)) }
+	return encode(`{"alg":"none"}`) + "." + encode(fmt.Sprintf(`{"iss":%q}`, issuer)) + ".sig"
+}
+
+func TestWithRealmFromToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		issuer    string
+		wantRealm string
+		wantWarn  string
+	}{
+		{name: "matching realm", issuer: "/realms/test-realm", wantRealm: "test-realm"},
+		{name: "different realm", issuer: "/realms/master", wantRealm: "master", wantWarn: "WARN keycloak token realm differs from configured realm token_realm=master realm=test-realm"},
+		{name: "issuer without realm", issuer: "/issuer", wantRealm: "test-realm", wantWarn: "WARN keycloak token issuer has no realm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tokenRequests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/token" {
+					tokenRequests.Add(1)
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600}`, testJWT("http://"+r.Host+tt.issuer))
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[]`))
+			}))
+			defer server.Close()
+
+			logger := &testLogger{}
+			client, err := New(context.Background(), Config{
+				URL:          server.URL,
+				Realm:        "test-realm",
+				ClientID:     "test-client",
+				ClientSecret: "test-secret",
+			}, WithTokenURL(server.URL+"/token"), WithLogger(logger), WithRealmFromToken())
+			require.NoError(t, err)
+			assert.Equal(t, int32(1), tokenRequests.Load(), "the first token is obtained by New")
+			assert.Equal(t, tt.wantRealm, client.AuthRealm())
+
+			var warnings []string
+			for _, line := range logger.Lines() {
+				if strings.HasPrefix(line, "WARN") {
+					warnings = append(warnings, line)
+				}
 			}
+			if tt.wantWarn == "" {
+				assert.Empty(t, warnings)
+			} else {
+				require.Len(t, warnings, 1)
+				assert.True(t, strings.HasPrefix(warnings[0], tt.wantWarn), warnings[0])
+			}
+
+			// The token obtained by New is reused for API calls
+			_, err = client.Groups.List(context.Background(), nil, true)
+			require.NoError(t, err)
+			assert.Equal(t, int32(1), tokenRequests.Load())
+		})
+	}
+
+	t.Run("token request fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		_, err := New(context.Background(), Config{
+			URL:          server.URL,
+			Realm:        "test-realm",
+			ClientID:     "test-client",
+			ClientSecret: "test-secret",
+		}, WithTokenURL(server.URL+"/token"), WithRealmFromToken())
+		assert.ErrorContains(t, err, "login failed")
+	})
+}
+
+func TestRealmFromIssuer(t *testing.T) {
+	tests := []struct {
+		issuer string
+		want   string
+		wantOK bool
+	}{
+		{issuer: "https://keycloak.example.com/realms/my-realm", want: "my-realm", wantOK: true},
+		{issuer: "https://keycloak.example.com/auth/realms/master/", want: "master", wantOK: true},
+		{issuer: "https://keycloak.example.com/realms/my%20realm", want: "my realm", wantOK: true},
+		{issuer: "https://keycloak.example.com/realms", wantOK: false},
+		{issuer: "https://issuer.example.com", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.issuer, func(t *testing.T) {
+			realm, ok := realmFromIssuer(tt.issuer)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, realm)
 		})
 	}
 }
 
+func TestWithRetryOnNetworkError(t *testing.T) {
+	t.Run("negative count", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithRetryOnNetworkError(-1)(client))
+	})
+
+	// newFlakyServer returns a server that drops the first failures connections without responding
+	newFlakyServer := func(failures int32) (*httptest.Server, *atomic.Int32) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) <= failures {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				require.NoError(t, err)
+				conn.Close()
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+		return server, &requests
+	}
+
+	t.Run("retries dropped connection", func(t *testing.T) {
+		server, requests := newFlakyServer(1)
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithRetryOnNetworkError(2))
+		_, err := client.Groups.List(context.Background(), nil, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("gives up after count retries", func(t *testing.T) {
+		server, requests := newFlakyServer(10)
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithRetryOnNetworkError(2))
+		_, err := client.Groups.List(context.Background(), nil, true)
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("does not retry non-idempotent requests", func(t *testing.T) {
+		server, requests := newFlakyServer(1)
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithRetryOnNetworkError(2))
+		_, err := client.Groups.Create(context.Background(), "group", nil)
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("does not retry without option", func(t *testing.T) {
+		server, requests := newFlakyServer(1)
+		defer server.Close()
+
+		client := newTestClient(server.URL)
+		_, err := client.Groups.List(context.Background(), nil, true)
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -316,12 +1523,300 @@ func TestNew(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, client)
 				assert.NotNil(t, client.Groups)
+				assert.NotNil(t, client.Users)
+				assert.NotNil(t, client.Clients)
 			}
 		})
 	}
 }
 
+func TestWithStaticToken(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	assert.Error(t, WithStaticToken("")(client))
+	assert.Error(t, WithTokenSource(nil)(client))
+
+	authorization := make(chan string, 1)
+	server := newTestOIDCServer(3600, func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+	defer server.Close()
+
+	// A public client: no secret, the token is obtained elsewhere
+	config := server.config()
+	config.ClientSecret = ""
+	ctx := context.Background()
+
+	client, err := New(ctx, config, WithStaticToken("external-token"))
+	require.NoError(t, err)
+	_, err = client.Groups.List(ctx, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer external-token", <-authorization)
+	assert.Zero(t, server.tokenRequests.Load())
+
+	client, err = New(ctx, config, WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "source-token"})))
+	require.NoError(t, err)
+	_, err = client.Groups.List(ctx, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer source-token", <-authorization)
+
+	_, err = New(ctx, config)
+	assert.ErrorContains(t, err, "clientSecret is required")
+}
+
 // newTestRestyClient creates a basic resty client for testing
 func newTestRestyClient() *resty.Client {
 	return resty.New()
 }
+
+// newConnStateServer returns a server answering with an empty list that counts its open
+// (idle or active) connections.
+func newConnStateServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var open atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			open.Add(1)
+		case http.StateClosed, http.StateHijacked:
+			open.Add(-1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, &open
+}
+
+func TestClient_Close(t *testing.T) {
+	t.Run("closes idle connections", func(t *testing.T) {
+		server, open := newConnStateServer(t)
+		client := newTestClient(server.URL)
+
+		_, err := client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), open.Load(), "connection kept alive after the request")
+
+		require.NoError(t, client.Close())
+		assert.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, 5*time.Millisecond)
+
+		// The client stays usable
+		_, err = client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+		require.NoError(t, client.Close())
+		assert.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("custom HTTP client", func(t *testing.T) {
+		server, open := newConnStateServer(t)
+		client := newTestClient(server.URL, WithHTTPClient(&http.Client{Transport: &http.Transport{}}))
+
+		_, err := client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+		require.NoError(t, client.Close())
+		assert.Eventually(t, func() bool { return open.Load() == 0 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("waits for background callbacks", func(t *testing.T) {
+		server := newAuditServer()
+		defer server.Close()
+
+		var finished atomic.Bool
+		client := newTestClient(server.URL, WithAuditHook(func(AuditEvent) {
+			time.Sleep(50 * time.Millisecond)
+			finished.Store(true)
+		}))
+		require.NoError(t, client.Groups.Delete(context.Background(), "group-1"))
+
+		require.NoError(t, client.Close())
+		assert.True(t, finished.Load())
+	})
+}
+
+// newTestClient creates a fully wired client pointing at a mock server without
+// performing OAuth2 discovery. Options are applied the same way New applies them.
+func newTestClient(serverURL string, opts ...Option) *Client {
+	dialer := newDialer()
+	transport := newTransport(dialer)
+	client := &Client{
+		baseURL:   serverURL,
+		realm:     "test-realm",
+		pageSize:  defaultSize,
+		maxPages:  defaultMaxPages,
+		resty:     newTestRestyClient().SetTransport(transport),
+		transport: transport,
+		dialer:    dialer,
+
+		reconcileConcurrency: defaultReconcileConcurrency,
+	}
+	for _, opt := range opts {
+		if err := opt(client); err != nil {
+			panic(err)
+		}
+	}
+	client.configureProxy()
+	client.setup()
+	client.initResourceClients()
+	return client
+}
+
+// testOIDCServer is a mock Keycloak server that serves OIDC discovery and a client
+// credentials token endpoint for the test realm, delegating other paths to api.
+type testOIDCServer struct {
+	*httptest.Server
+	tokenRequests atomic.Int32
+	expiresIn     int
+}
+
+// newTestOIDCServer starts a mock Keycloak server. Tokens are issued with the given
+// lifetime in seconds; each token request returns a distinct access token.
+func newTestOIDCServer(expiresIn int, api http.HandlerFunc) *testOIDCServer {
+	s := &testOIDCServer{expiresIn: expiresIn}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuer := s.URL + "/realms/test-realm"
+		switch r.URL.Path {
+		case "/realms/test-realm/.well-known/openid-configuration":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"issuer":%q,"token_endpoint":%q}`, issuer, issuer+"/protocol/openid-connect/token")
+		case "/realms/test-realm/protocol/openid-connect/token":
+			n := s.tokenRequests.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, s.expiresIn)
+		default:
+			if api != nil {
+				api(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}
+	}))
+	return s
+}
+
+// config returns a client configuration pointing at the mock server.
+func (s *testOIDCServer) config() Config {
+	return Config{
+		URL:          s.URL,
+		Realm:        "test-realm",
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+	}
+}
+
+// TestWithToken tests that a per-request token replaces the client's token for that request only
+func TestWithToken(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := newTestOIDCServer(3600, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"g1"}`))
+	})
+	defer server.Close()
+
+	client, err := New(context.Background(), server.config())
+	require.NoError(t, err)
+
+	_, err = client.Groups.Get(WithToken(context.Background(), "user-token"), "g1")
+	require.NoError(t, err)
+	_, err = client.Groups.Get(context.Background(), "g1")
+	require.NoError(t, err)
+	_, err = client.Groups.Get(WithToken(context.Background(), ""), "g1")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Bearer user-token", "Bearer token-1", "Bearer token-1"}, received)
+	assert.Equal(t, int32(1), server.tokenRequests.Load())
+
+	t.Run("without token source", func(t *testing.T) {
+		received = nil
+		client := newTestClient(server.URL)
+		_, err := client.Groups.Get(WithToken(context.Background(), "user-token"), "g1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bearer user-token"}, received)
+	})
+}
+
+// TestWithRequestHeaders tests that per-request headers override client headers and do not leak
+func TestWithRequestHeaders(t *testing.T) {
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Group{ID: ptr.String("g1")})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithHeaders(map[string]string{
+		"X-Tenant":  "default",
+		"X-Service": "svc",
+	}))
+
+	ctx := WithRequestHeaders(context.Background(), map[string]string{"X-Tenant": "acme"})
+	ctx = WithRequestHeaders(ctx, map[string]string{"X-Request-ID": "req-1"})
+
+	_, err := client.Groups.Get(ctx, "g1")
+	require.NoError(t, err)
+	_, err = client.Groups.Get(context.Background(), "g1")
+	require.NoError(t, err)
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "acme", received[0].Get("X-Tenant"))
+	assert.Equal(t, "req-1", received[0].Get("X-Request-ID"))
+	assert.Equal(t, "svc", received[0].Get("X-Service"))
+
+	assert.Equal(t, "default", received[1].Get("X-Tenant"))
+	assert.Empty(t, received[1].Get("X-Request-ID"))
+}
+
+// TestCaptureHeaders tests that selected response headers are copied into the caller's header map
+func TestCaptureHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "41")
+		w.Header().Add("X-Trace", "a")
+		w.Header().Add("X-Trace", "b")
+		w.Header().Set("X-Other", "ignored")
+		if r.URL.Path == "/admin/realms/test-realm/groups/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Group{ID: ptr.String("g1")})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	t.Run("successful response", func(t *testing.T) {
+		var headers http.Header
+		ctx := CaptureHeaders(context.Background(), &headers, "X-RateLimit-Remaining", "X-Trace", "X-Missing")
+
+		_, err := client.Groups.Get(ctx, "g1")
+		require.NoError(t, err)
+		assert.Equal(t, "41", headers.Get("X-RateLimit-Remaining"))
+		assert.Equal(t, []string{"a", "b"}, headers.Values("X-Trace"))
+		assert.Empty(t, headers.Get("X-Other"))
+		assert.NotContains(t, headers, "X-Missing")
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		headers := http.Header{"X-Existing": {"kept"}}
+		ctx := CaptureHeaders(context.Background(), &headers, "X-RateLimit-Remaining")
+
+		_, err := client.Groups.Get(ctx, "missing")
+		assert.Error(t, err)
+		assert.Equal(t, "41", headers.Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "kept", headers.Get("X-Existing"))
+	})
+
+	t.Run("without capture", func(t *testing.T) {
+		_, err := client.Groups.Get(context.Background(), "g1")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,315 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"go.companyinfo.dev/ptr"
+)
+
+var (
+	// ErrClientNotFound is returned when a requested client cannot be found.
+	ErrClientNotFound = errors.New("client not found")
+
+	// ErrRoleNotFound is returned when a requested role cannot be found.
+	ErrRoleNotFound = errors.New("role not found")
+)
+
+// ClientsClient provides methods for working with Keycloak clients (applications).
+// Clients are addressed by their internal ID (the "id" field), not by their clientId.
+type ClientsClient interface {
+	// InternalID resolves a human-readable clientId to the client's internal ID.
+	// Results are cached for the lifetime of the Client.
+	// Returns ErrClientNotFound if no client has the given clientId.
+	InternalID(ctx context.Context, clientID string) (string, error)
+
+	// ListRoles returns all roles defined by the client.
+	// Returns ErrClientNotFound if the client does not exist.
+	ListRoles(ctx context.Context, clientInternalID string) ([]*Role, error)
+
+	// GetRole retrieves a single client role by name.
+	// Returns ErrClientNotFound if the client does not exist and ErrRoleNotFound if the role does not.
+	GetRole(ctx context.Context, clientInternalID, roleName string) (*Role, error)
+
+	// ListProtocolMappers returns the protocol mappers configured on the client.
+	// Returns ErrClientNotFound if the client does not exist.
+	ListProtocolMappers(ctx context.Context, clientInternalID string) ([]*ProtocolMapper, error)
+
+	// AddProtocolMapper creates a protocol mapper on the client and returns its ID.
+	// Name, Protocol and ProtocolMapper are required.
+	AddProtocolMapper(ctx context.Context, clientInternalID string, mapper ProtocolMapper) (string, error)
+
+	// DeleteProtocolMapper removes a protocol mapper from the client.
+	DeleteProtocolMapper(ctx context.Context, clientInternalID, mapperID string) error
+}
+
+// clientsClient implements the ClientsClient interface.
+type clientsClient struct {
+	client *Client
+
+	mu          sync.RWMutex
+	internalIDs map[string]string // clientId -> internal ID
+}
+
+// newClientsClient creates a new ClientsClient implementation.
+func newClientsClient(client *Client) ClientsClient {
+	return &clientsClient{
+		client:      client,
+		internalIDs: make(map[string]string),
+	}
+}
+
+// WithWarmup makes New resolve the internal IDs of the given clients (by clientId) and cache
+// them, so that later calls taking an internal ID can skip the lookup through InternalID.
+// This trades a little startup time for lower latency per operation. Failures are not fatal:
+// they are logged as warnings (see WithLogger) and the client is looked up again on first use.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithWarmup("frontend", "backend"))
+func WithWarmup(clientIDs ...string) Option {
+	return func(c *Client) error {
+		for _, clientID := range clientIDs {
+			if clientID == "" {
+				return fmt.Errorf("warmup client IDs cannot be empty")
+			}
+		}
+		c.warmupClientIDs = append(c.warmupClientIDs, clientIDs...)
+		return nil
+	}
+}
+
+// warmup resolves the internal IDs of the clients configured with WithWarmup,
+// logging failures instead of returning them.
+func (c *Client) warmup(ctx context.Context) {
+	for _, clientID := range c.warmupClientIDs {
+		if _, err := c.Clients.InternalID(ctx, clientID); err != nil {
+			c.logEvent(ctx, slog.LevelWarn, "keycloak warmup failed",
+				"client_id", clientID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// InternalID resolves a human-readable clientId to the client's internal ID using the
+// clientId filter of the clients endpoint. Successful lookups are cached for the lifetime
+// of the Client, since internal IDs never change; misses are not cached.
+func (c *clientsClient) InternalID(ctx context.Context, clientID string) (string, error) {
+	if clientID == "" {
+		return "", fmt.Errorf("clientID parameter cannot be empty")
+	}
+
+	c.mu.RLock()
+	id, ok := c.internalIDs[clientID]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	var result []*ClientRepresentation
+
+	resp, err := c.getRequest(ctx).
+		SetQueryParam("clientId", clientID).
+		SetResult(&result).
+		Execute(endpointClientsList.Method, c.client.buildURL(endpointClientsList, nil))
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve client ID: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return "", fmt.Errorf("unable to resolve client ID: %w", newAPIError(resp))
+	}
+
+	// The clientId filter is exact in current Keycloak versions, but verify to be safe
+	for _, client := range result {
+		if client != nil && ptr.ToString(client.ClientID) == clientID && !ptr.IsZero(client.ID) {
+			c.mu.Lock()
+			c.internalIDs[clientID] = *client.ID
+			c.mu.Unlock()
+			return *client.ID, nil
+		}
+	}
+
+	return "", ErrClientNotFound
+}
+
+// ListRoles returns all roles defined by the client.
+func (c *clientsClient) ListRoles(ctx context.Context, clientInternalID string) ([]*Role, error) {
+	if clientInternalID == "" {
+		return nil, fmt.Errorf("clientInternalID parameter cannot be empty")
+	}
+
+	var result []*Role
+
+	resp, err := c.client.executeList(c.getRequest(ctx), &result,
+		endpointClientRoles.Method, c.client.buildURL(endpointClientRoles, map[string]string{"id": clientInternalID}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list client roles: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("unable to list client roles: %w", newAPIError(resp))
+	}
+
+	return result, nil
+}
+
+// GetRole retrieves a single client role by name.
+// The role name is path-escaped, so names containing spaces or slashes are supported.
+func (c *clientsClient) GetRole(ctx context.Context, clientInternalID, roleName string) (*Role, error) {
+	if clientInternalID == "" {
+		return nil, fmt.Errorf("clientInternalID parameter cannot be empty")
+	}
+	if roleName == "" {
+		return nil, fmt.Errorf("roleName parameter cannot be empty")
+	}
+
+	var result Role
+
+	resp, err := c.getRequest(ctx).
+		SetResult(&result).
+		Execute(endpointClientRoleGet.Method, c.client.buildURL(endpointClientRoleGet, map[string]string{
+			"id":       clientInternalID,
+			"roleName": roleName,
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get client role: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, notFoundRoleError(resp)
+		}
+		return nil, fmt.Errorf("unable to get client role: %w", newAPIError(resp))
+	}
+
+	return &result, nil
+}
+
+// ListProtocolMappers returns the protocol mappers configured on the client.
+func (c *clientsClient) ListProtocolMappers(ctx context.Context, clientInternalID string) ([]*ProtocolMapper, error) {
+	if clientInternalID == "" {
+		return nil, fmt.Errorf("clientInternalID parameter cannot be empty")
+	}
+
+	var result []*ProtocolMapper
+
+	resp, err := c.client.executeList(c.getRequest(ctx), &result,
+		endpointClientProtocolMappers.Method, c.client.buildURL(endpointClientProtocolMappers, map[string]string{"id": clientInternalID}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list protocol mappers: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("unable to list protocol mappers: %w", newAPIError(resp))
+	}
+
+	return result, nil
+}
+
+// AddProtocolMapper creates a protocol mapper on the client and returns the ID of the new
+// mapper, taken from the Location header of the response. Any ID set on mapper is ignored.
+func (c *clientsClient) AddProtocolMapper(ctx context.Context, clientInternalID string, mapper ProtocolMapper) (string, error) {
+	if clientInternalID == "" {
+		return "", fmt.Errorf("clientInternalID parameter cannot be empty")
+	}
+	if ptr.IsZero(mapper.Name) {
+		return "", fmt.Errorf("the name of the protocol mapper is required")
+	}
+	if ptr.IsZero(mapper.Protocol) {
+		return "", fmt.Errorf("the protocol of the protocol mapper is required")
+	}
+	if ptr.IsZero(mapper.ProtocolMapper) {
+		return "", fmt.Errorf("the type of the protocol mapper is required")
+	}
+	mapper.ID = nil
+
+	resp, err := c.getRequest(ctx).
+		SetBody(mapper).
+		Execute(endpointClientProtocolMapperCreate.Method, c.client.buildURL(endpointClientProtocolMapperCreate, map[string]string{"id": clientInternalID}))
+	if err != nil {
+		return "", fmt.Errorf("unable to add protocol mapper: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			return "", ErrClientNotFound
+		}
+		return "", fmt.Errorf("unable to add protocol mapper: %w", newAPIError(resp))
+	}
+
+	id, err := createdID(resp, c.client.realm, "models")
+	if err != nil {
+		return "", fmt.Errorf("unable to add protocol mapper: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteProtocolMapper removes a protocol mapper from the client.
+func (c *clientsClient) DeleteProtocolMapper(ctx context.Context, clientInternalID, mapperID string) error {
+	if clientInternalID == "" {
+		return fmt.Errorf("clientInternalID parameter cannot be empty")
+	}
+	if mapperID == "" {
+		return fmt.Errorf("mapperID parameter cannot be empty")
+	}
+
+	resp, err := c.getRequest(ctx).
+		Execute(endpointClientProtocolMapperDelete.Method, c.client.buildURL(endpointClientProtocolMapperDelete, map[string]string{
+			"id":       clientInternalID,
+			"mapperID": mapperID,
+		}))
+	if err != nil {
+		return fmt.Errorf("unable to delete protocol mapper: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return fmt.Errorf("unable to delete protocol mapper: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+// notFoundRoleError tells a missing role apart from a missing client using the error
+// message of a 404 response ("Could not find role" vs "Could not find client").
+func notFoundRoleError(resp *resty.Response) error {
+	if e, ok := resp.Error().(*HTTPErrorResponse); ok {
+		if strings.Contains(strings.ToLower(e.Error+" "+e.Message), "role") {
+			return ErrRoleNotFound
+		}
+	}
+	return ErrClientNotFound
+}
+
+// getRequest creates a new resty request with the given context and error handling configured.
+func (c *clientsClient) getRequest(ctx context.Context) *resty.Request {
+	var err HTTPErrorResponse
+	return c.client.resty.R().SetContext(ctx).SetError(&err)
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+// ClientRepresentation holds the commonly used properties of a Keycloak client (application).
+// This struct maps to a subset of Keycloak's ClientRepresentation.
+type ClientRepresentation struct {
+	ID                     *string            `json:"id,omitempty"`                     // Internal ID of the client
+	ClientID               *string            `json:"clientId,omitempty"`               // Human-readable client identifier
+	Name                   *string            `json:"name,omitempty"`                   // Display name of the client
+	Description            *string            `json:"description,omitempty"`            // Description of the client
+	Enabled                *bool              `json:"enabled,omitempty"`                // Whether the client is enabled
+	Protocol               *string            `json:"protocol,omitempty"`               // Protocol of the client (e.g. "openid-connect" or "saml")
+	PublicClient           *bool              `json:"publicClient,omitempty"`           // Whether the client is public (no secret)
+	ServiceAccountsEnabled *bool              `json:"serviceAccountsEnabled,omitempty"` // Whether the client credentials grant is enabled
+	RedirectURIs           *[]string          `json:"redirectUris,omitempty"`           // Valid redirect URIs
+	WebOrigins             *[]string          `json:"webOrigins,omitempty"`             // Allowed CORS origins
+	Attributes             *map[string]string `json:"attributes,omitempty"`             // Client attributes
+	ProtocolMappers        *[]*ProtocolMapper `json:"protocolMappers,omitempty"`        // Protocol mappers of the client
+}
+
+// ProtocolMapper configures how a client maps user, role or session data into tokens
+// (e.g. adding a claim). This struct maps to Keycloak's ProtocolMapperRepresentation.
+type ProtocolMapper struct {
+	ID             *string            `json:"id,omitempty"`             // Unique identifier for the mapper
+	Name           *string            `json:"name,omitempty"`           // Mapper name, unique within the client
+	Protocol       *string            `json:"protocol,omitempty"`       // Protocol of the mapper (e.g. "openid-connect" or "saml")
+	ProtocolMapper *string            `json:"protocolMapper,omitempty"` // Mapper type (e.g. "oidc-usermodel-attribute-mapper")
+	Config         *map[string]string `json:"config,omitempty"`         // Mapper type specific configuration
+}
@@ -0,0 +1,309 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.companyinfo.dev/ptr"
+)
+
+// TestClientsClient_ListRolesWithServer tests ListRoles against a mock HTTP server
+func TestClientsClient_ListRolesWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		wantErr        error
+		wantCount      int
+	}{
+		{
+			name:           "roles found",
+			mockStatusCode: http.StatusOK,
+			wantCount:      2,
+		},
+		{
+			name:           "client not found",
+			mockStatusCode: http.StatusNotFound,
+			wantErr:        ErrClientNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/clients/c-1/roles", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.mockStatusCode)
+				if tt.mockStatusCode == http.StatusOK {
+					json.NewEncoder(w).Encode([]*Role{{Name: ptr.String("view")}, {Name: ptr.String("edit")}})
+				} else {
+					w.Write([]byte(`{"error":"Could not find client"}`))
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			roles, err := client.Clients.ListRoles(context.Background(), "c-1")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, roles)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, roles, tt.wantCount)
+		})
+	}
+}
+
+// TestClientsClient_GetRoleWithServer tests GetRole against a mock HTTP server
+func TestClientsClient_GetRoleWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		mockError      string
+		wantErr        error
+	}{
+		{
+			name:           "role found",
+			mockStatusCode: http.StatusOK,
+		},
+		{
+			name:           "role not found",
+			mockStatusCode: http.StatusNotFound,
+			mockError:      "Could not find role",
+			wantErr:        ErrRoleNotFound,
+		},
+		{
+			name:           "client not found",
+			mockStatusCode: http.StatusNotFound,
+			mockError:      "Could not find client",
+			wantErr:        ErrClientNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/clients/c-1/roles/read%20only", r.URL.EscapedPath())
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.mockStatusCode)
+				if tt.mockStatusCode == http.StatusOK {
+					json.NewEncoder(w).Encode(Role{ID: ptr.String("r-1"), Name: ptr.String("read only"), ClientRole: ptr.Bool(true)})
+				} else {
+					json.NewEncoder(w).Encode(HTTPErrorResponse{Error: tt.mockError})
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			role, err := client.Clients.GetRole(context.Background(), "c-1", "read only")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, role)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "read only", *role.Name)
+			assert.True(t, *role.ClientRole)
+		})
+	}
+}
+
+// TestClientsClient_InternalIDWithServer tests InternalID lookups and caching against a mock HTTP server
+func TestClientsClient_InternalIDWithServer(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "/admin/realms/test-realm/clients", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("clientId") {
+		case "account":
+			json.NewEncoder(w).Encode([]ClientRepresentation{
+				{ID: ptr.String("uuid-2"), ClientID: ptr.String("account-console")},
+				{ID: ptr.String("uuid-1"), ClientID: ptr.String("account")},
+			})
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	ctx := context.Background()
+
+	id, err := client.Clients.InternalID(ctx, "account")
+	require.NoError(t, err)
+	assert.Equal(t, "uuid-1", id)
+
+	id, err = client.Clients.InternalID(ctx, "account")
+	require.NoError(t, err)
+	assert.Equal(t, "uuid-1", id)
+	assert.Equal(t, int32(1), requests.Load(), "second lookup should be served from cache")
+
+	for range 2 {
+		id, err = client.Clients.InternalID(ctx, "missing")
+		assert.ErrorIs(t, err, ErrClientNotFound)
+		assert.Empty(t, id)
+	}
+	assert.Equal(t, int32(3), requests.Load(), "misses should not be cached")
+}
+
+// TestClientsClient_ProtocolMappersWithServer tests listing, adding and deleting protocol mappers against a mock HTTP server
+func TestClientsClient_ProtocolMappersWithServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/admin/realms/test-realm/clients/c-1/protocol-mappers/models":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]*ProtocolMapper{{
+				ID:             ptr.String("m-1"),
+				Name:           ptr.String("department"),
+				Protocol:       ptr.String("openid-connect"),
+				ProtocolMapper: ptr.String("oidc-usermodel-attribute-mapper"),
+				Config:         &map[string]string{"claim.name": "department"},
+			}})
+		case r.Method == http.MethodPost && r.URL.Path == "/admin/realms/test-realm/clients/c-1/protocol-mappers/models":
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "department", body["name"])
+			assert.NotContains(t, body, "id")
+			w.Header().Set("Location", "http://"+r.Host+r.URL.Path+"/m-2")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/admin/realms/test-realm/clients/c-1/protocol-mappers/models/m-1":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/admin/realms/test-realm/clients/missing/protocol-mappers/models":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Could not find client"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	ctx := context.Background()
+
+	mappers, err := client.Clients.ListProtocolMappers(ctx, "c-1")
+	require.NoError(t, err)
+	require.Len(t, mappers, 1)
+	assert.Equal(t, "oidc-usermodel-attribute-mapper", ptr.ToString(mappers[0].ProtocolMapper))
+	assert.Equal(t, "department", (*mappers[0].Config)["claim.name"])
+
+	id, err := client.Clients.AddProtocolMapper(ctx, "c-1", ProtocolMapper{
+		ID:             ptr.String("ignored"),
+		Name:           ptr.String("department"),
+		Protocol:       ptr.String("openid-connect"),
+		ProtocolMapper: ptr.String("oidc-usermodel-attribute-mapper"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "m-2", id)
+
+	require.NoError(t, client.Clients.DeleteProtocolMapper(ctx, "c-1", "m-1"))
+
+	mappers, err = client.Clients.ListProtocolMappers(ctx, "missing")
+	assert.ErrorIs(t, err, ErrClientNotFound)
+	assert.Nil(t, mappers)
+}
+
+// TestClientsClient_Validation tests ClientsClient input validation
+func TestClientsClient_Validation(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	cc := &clientsClient{client: client}
+	ctx := context.Background()
+
+	id, err := cc.InternalID(ctx, "")
+	assert.Error(t, err)
+	assert.Empty(t, id)
+
+	roles, err := cc.ListRoles(ctx, "")
+	assert.Error(t, err)
+	assert.Nil(t, roles)
+
+	role, err := cc.GetRole(ctx, "", "view")
+	assert.Error(t, err)
+	assert.Nil(t, role)
+
+	role, err = cc.GetRole(ctx, "c-1", "")
+	assert.Error(t, err)
+	assert.Nil(t, role)
+
+	mappers, err := cc.ListProtocolMappers(ctx, "")
+	assert.Error(t, err)
+	assert.Nil(t, mappers)
+
+	valid := ProtocolMapper{
+		Name:           ptr.String("department"),
+		Protocol:       ptr.String("openid-connect"),
+		ProtocolMapper: ptr.String("oidc-usermodel-attribute-mapper"),
+	}
+	_, err = cc.AddProtocolMapper(ctx, "", valid)
+	assert.Error(t, err)
+	for _, mapper := range []ProtocolMapper{
+		{Protocol: valid.Protocol, ProtocolMapper: valid.ProtocolMapper},
+		{Name: valid.Name, ProtocolMapper: valid.ProtocolMapper},
+		{Name: valid.Name, Protocol: valid.Protocol},
+	} {
+		_, err = cc.AddProtocolMapper(ctx, "c-1", mapper)
+		assert.Error(t, err)
+	}
+
+	assert.Error(t, cc.DeleteProtocolMapper(ctx, "", "m-1"))
+	assert.Error(t, cc.DeleteProtocolMapper(ctx, "c-1", ""))
+}
+
+// TestWithWarmup tests that New resolves and caches the internal IDs of warmup clients
+func TestWithWarmup(t *testing.T) {
+	t.Run("empty client ID", func(t *testing.T) {
+		assert.Error(t, WithWarmup("frontend", "")(&Client{}))
+	})
+
+	var lookups atomic.Int32
+	server := newTestOIDCServer(3600, func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		assert.Equal(t, "/admin/realms/test-realm/clients", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch clientID := r.URL.Query().Get("clientId"); clientID {
+		case "frontend", "backend":
+			json.NewEncoder(w).Encode([]*ClientRepresentation{{ID: ptr.String(clientID + "-uuid"), ClientID: ptr.String(clientID)}})
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+	defer server.Close()
+
+	logger := &testLogger{}
+	client, err := New(context.Background(), server.config(), WithWarmup("frontend", "backend", "missing"), WithLogger(logger))
+	require.NoError(t, err, "warmup failures are not fatal")
+	assert.Equal(t, int32(3), lookups.Load())
+
+	clients := client.Clients.(*clientsClient)
+	assert.Equal(t, map[string]string{"frontend": "frontend-uuid", "backend": "backend-uuid"}, clients.internalIDs)
+	assert.Contains(t, logger.Lines(), "WARN keycloak warmup failed client_id=missing error="+ErrClientNotFound.Error())
+
+	id, err := client.Clients.InternalID(context.Background(), "backend")
+	require.NoError(t, err)
+	assert.Equal(t, "backend-uuid", id)
+	assert.Equal(t, int32(3), lookups.Load(), "cached IDs are not looked up again")
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.companyinfo.dev/ptr"
+)
+
+// GroupComparison is the result of CompareGroups. All lists are sorted by path.
+type GroupComparison struct {
+	OnlyInA        []string             // Paths of the groups found only through the first client
+	OnlyInB        []string             // Paths of the groups found only through the second client
+	AttributeDiffs []GroupAttributeDiff // Groups found on both sides whose attributes differ
+}
+
+// Equal reports whether both sides hold the same groups with the same attributes.
+func (c *GroupComparison) Equal() bool {
+	return len(c.OnlyInA) == 0 && len(c.OnlyInB) == 0 && len(c.AttributeDiffs) == 0
+}
+
+// GroupAttributeDiff describes the attribute differences of a group found on both sides of a
+// comparison.
+type GroupAttributeDiff struct {
+	Path string              // Path of the group, e.g. "/org/dept"
+	Keys []string            // Sorted attribute keys whose values differ or that only one side has
+	A    map[string][]string // Attributes of the group on the first side
+	B    map[string][]string // Attributes of the group on the second side
+}
+
+// CompareGroups lists the groups of the realms behind two clients and reports the groups
+// present on only one side and the attribute differences of the groups present on both, e.g.
+// to check that a migration copied everything. Groups are matched by path. Attribute values
+// are compared in order, like ReconcileTree does.
+//
+// If root is non-nil, only the group at that path (e.g. "/org") and its subgroups are compared;
+// a root missing on one side makes all groups of the other side one-sided. A nil root, or "/",
+// compares whole realms.
+//
+// This is a scan: every page of top-level groups costs a request on each side, and so does
+// every page of children of each group with subgroups. Bound it with a deadline for large
+// realms. Returns ErrPageLimitExceeded if a listing reaches a client's page limit.
+func CompareGroups(ctx context.Context, a, b *Client, root *string) (*GroupComparison, error) {
+	if a == nil || b == nil {
+		return nil, errors.New("both clients are required")
+	}
+	rootPath := strings.TrimSuffix(ptr.ToString(root), "/")
+
+	groupsA, err := scanGroupAttributes(ctx, a, rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compare groups: first client: %w", err)
+	}
+	groupsB, err := scanGroupAttributes(ctx, b, rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compare groups: second client: %w", err)
+	}
+
+	comparison := &GroupComparison{}
+	for _, path := range slices.Sorted(maps.Keys(groupsA)) {
+		attributesB, ok := groupsB[path]
+		if !ok {
+			comparison.OnlyInA = append(comparison.OnlyInA, path)
+			continue
+		}
+		if keys := differingAttributeKeys(groupsA[path], attributesB); len(keys) > 0 {
+			comparison.AttributeDiffs = append(comparison.AttributeDiffs, GroupAttributeDiff{
+				Path: path,
+				Keys: keys,
+				A:    groupsA[path],
+				B:    attributesB,
+			})
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(groupsB)) {
+		if _, ok := groupsA[path]; !ok {
+			comparison.OnlyInB = append(comparison.OnlyInB, path)
+		}
+	}
+
+	return comparison, nil
+}
+
+// scanGroupAttributes returns the attributes of every group at or below rootPath, keyed by
+// path. Only the groups on the way to rootPath are descended into outside of its subtree.
+// An empty rootPath selects all groups.
+func scanGroupAttributes(ctx context.Context, c *Client, rootPath string) (map[string]map[string][]string, error) {
+	result := make(map[string]map[string][]string)
+
+	var visit func(groups []*Group, parentPath string) error
+	visit = func(groups []*Group, parentPath string) error {
+		for _, group := range groups {
+			if group == nil || ptr.IsZero(group.ID) {
+				continue
+			}
+			path := ptr.ToString(group.Path)
+			if path == "" {
+				path = parentPath + "/" + ptr.ToString(group.Name)
+			}
+
+			inSubtree := rootPath == "" || path == rootPath || strings.HasPrefix(path, rootPath+"/")
+			if !inSubtree && !strings.HasPrefix(rootPath, path+"/") {
+				continue
+			}
+			if inSubtree {
+				attributes := map[string][]string{}
+				if group.Attributes != nil {
+					attributes = *group.Attributes
+				}
+				result[path] = attributes
+			}
+
+			// Skip the children request when Keycloak already told us there is nothing below
+			if group.SubGroupCount != nil && *group.SubGroupCount == 0 {
+				continue
+			}
+			children, err := listGroupPages(c, "unable to list all sub-groups", func(first, max int) ([]*Group, error) {
+				return c.Groups.ListSubGroupsPaginated(ctx, *group.ID, SubGroupSearchParams{
+					BriefRepresentation: ptr.Bool(false),
+					First:               &first,
+					Max:                 &max,
+				})
+			})
+			if err != nil {
+				return err
+			}
+			if err := visit(children, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	topLevel, err := listGroupPages(c, "unable to list all groups", func(first, max int) ([]*Group, error) {
+		return c.Groups.ListWithParams(ctx, SearchGroupParams{
+			BriefRepresentation: ptr.Bool(false),
+			First:               &first,
+			Max:                 &max,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := visit(topLevel, ""); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// differingAttributeKeys returns the sorted keys whose values differ between a and b,
+// including keys only one of them has.
+func differingAttributeKeys(a, b map[string][]string) []string {
+	var keys []string
+	for key := range a {
+		if values, ok := b[key]; !ok || !slices.Equal(a[key], values) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
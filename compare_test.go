@@ -0,0 +1,130 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.companyinfo.dev/ptr"
+)
+
+// newListServer returns a server answering group listings from fixed responses, paged with
+// the first and max query parameters. Top-level groups are keyed by "", children by parent ID.
+func newListServer(t *testing.T, listings map[string][]*Group) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/admin/realms/test-realm/groups")
+		parentID := strings.TrimSuffix(strings.TrimPrefix(rest, "/"), "/children")
+		assert.Equal(t, "false", r.URL.Query().Get("briefRepresentation"))
+
+		groups := listings[parentID]
+		first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+		max, _ := strconv.Atoi(r.URL.Query().Get("max"))
+		groups = groups[min(first, len(groups)):min(first+max, len(groups))]
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(groups)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// listedGroup returns a group as listed by Keycloak.
+func listedGroup(id, path string, subGroups int64, attributes map[string][]string) *Group {
+	name := path[strings.LastIndex(path, "/")+1:]
+	return &Group{ID: ptr.String(id), Name: &name, Path: ptr.String(path), SubGroupCount: &subGroups, Attributes: &attributes}
+}
+
+func TestCompareGroups(t *testing.T) {
+	source := newListServer(t, map[string][]*Group{
+		"": {
+			listedGroup("a1", "/org", 3, map[string][]string{"code": {"org"}}),
+			listedGroup("a2", "/legacy", 0, nil),
+			listedGroup("a3", "/shared", 0, map[string][]string{"tier": {"gold"}}),
+		},
+		"a1": {
+			listedGroup("a11", "/org/dept", 0, map[string][]string{"code": {"d1"}, "cost": {"100"}}),
+			listedGroup("a12", "/org/ops", 0, nil),
+			listedGroup("a13", "/org/sales", 0, nil),
+		},
+	})
+	target := newListServer(t, map[string][]*Group{
+		"": {
+			// Path omitted: derived from the parent path and name
+			{ID: ptr.String("b1"), Name: ptr.String("org"), Attributes: &map[string][]string{"code": {"org"}}},
+			listedGroup("b3", "/shared", 0, map[string][]string{"tier": {"silver"}}),
+			listedGroup("b4", "/new", 0, nil),
+		},
+		"b1": {
+			listedGroup("b11", "/org/dept", 0, map[string][]string{"code": {"d1"}, "owner": {"x"}}),
+			listedGroup("b12", "/org/ops", 0, nil),
+		},
+	})
+	// A page size of 2 makes both sides page through top-level groups and children
+	a := newTestClient(source.URL, WithPageSize(2))
+	b := newTestClient(target.URL, WithPageSize(2))
+	ctx := context.Background()
+
+	comparison, err := CompareGroups(ctx, a, b, nil)
+	require.NoError(t, err)
+	assert.False(t, comparison.Equal())
+	assert.Equal(t, []string{"/legacy", "/org/sales"}, comparison.OnlyInA)
+	assert.Equal(t, []string{"/new"}, comparison.OnlyInB)
+	require.Len(t, comparison.AttributeDiffs, 2)
+	assert.Equal(t, GroupAttributeDiff{
+		Path: "/org/dept",
+		Keys: []string{"cost", "owner"},
+		A:    map[string][]string{"code": {"d1"}, "cost": {"100"}},
+		B:    map[string][]string{"code": {"d1"}, "owner": {"x"}},
+	}, comparison.AttributeDiffs[0])
+	assert.Equal(t, "/shared", comparison.AttributeDiffs[1].Path)
+	assert.Equal(t, []string{"tier"}, comparison.AttributeDiffs[1].Keys)
+
+	t.Run("root", func(t *testing.T) {
+		comparison, err := CompareGroups(ctx, a, b, ptr.String("/org"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/org/sales"}, comparison.OnlyInA)
+		assert.Empty(t, comparison.OnlyInB)
+		require.Len(t, comparison.AttributeDiffs, 1)
+		assert.Equal(t, "/org/dept", comparison.AttributeDiffs[0].Path)
+
+		comparison, err = CompareGroups(ctx, a, b, ptr.String("/org/ops"))
+		require.NoError(t, err)
+		assert.True(t, comparison.Equal())
+	})
+
+	t.Run("same realm", func(t *testing.T) {
+		comparison, err := CompareGroups(ctx, a, a, nil)
+		require.NoError(t, err)
+		assert.True(t, comparison.Equal())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := CompareGroups(ctx, a, nil, nil)
+		assert.Error(t, err)
+
+		limited := newTestClient(source.URL, WithPageSize(1), WithMaxPages(2))
+		_, err = CompareGroups(ctx, limited, b, nil)
+		assert.ErrorIs(t, err, ErrPageLimitExceeded)
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// requestHeadersKey is the context key for per-request headers.
+type requestHeadersKey struct{}
+
+// WithRequestHeaders returns a copy of ctx carrying headers that are added to every request
+// made with that context, for example correlation or tenant headers. They override headers
+// configured at construction time with WithHeaders. Calling it again on a derived context
+// merges the headers, with the innermost values winning.
+//
+// Example:
+//
+//	ctx = keycloak.WithRequestHeaders(ctx, map[string]string{
+//	    "X-Request-ID": requestID,
+//	})
+//	group, err := client.Groups.Get(ctx, groupID)
+func WithRequestHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string, len(headers))
+	for k, v := range requestHeaders(ctx) {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, requestHeadersKey{}, merged)
+}
+
+// requestHeaders returns the per-request headers stored in ctx, if any.
+func requestHeaders(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(requestHeadersKey{}).(map[string]string)
+	return headers
+}
+
+// tokenOverrideKey is the context key for the per-request bearer token.
+type tokenOverrideKey struct{}
+
+// WithToken returns a copy of ctx whose requests are authenticated with the given bearer
+// token instead of a token from the client's token source, e.g. an end-user token for a call
+// made on the user's behalf. It only applies to requests made with the returned context;
+// nothing is stored on the client, and the token is never refreshed. An empty token leaves
+// ctx unchanged.
+//
+// The call runs with the permissions of that token, not the client's, so only pass tokens
+// the caller is entitled to use, and do not let such contexts outlive the request they were
+// created for. The token is sent as is and may be reported as the actor of audit events
+// (see WithAuditHook). With WithHTTPClient, the header is set on the request, but the
+// caller's transport may still replace it.
+//
+// Example:
+//
+//	ctx = keycloak.WithToken(ctx, userAccessToken)
+//	group, err := client.Groups.Get(ctx, groupID)
+func WithToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tokenOverrideKey{}, token)
+}
+
+// overrideToken returns the per-request bearer token stored in ctx, if any.
+func overrideToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenOverrideKey{}).(string)
+	return token, ok
+}
+
+// applyTokenOverride is a resty middleware that authenticates the request with the token
+// set with WithToken, if any.
+func applyTokenOverride(_ *resty.Client, req *resty.Request) error {
+	if token, ok := overrideToken(req.Context()); ok {
+		req.SetAuthScheme("Bearer").SetAuthToken(token)
+	}
+	return nil
+}
+
+// checkContext is a resty middleware that fails requests whose context is already done before
+// they are sent. Since it runs before every attempt, it also stops retries immediately, and
+// callers get the context error instead of a transport error mentioning the URL.
+func checkContext(_ *resty.Client, req *resty.Request) error {
+	return req.Context().Err()
+}
+
+// applyRequestHeaders is a resty middleware that sets the per-request headers from the
+// request context. Request headers take precedence over client headers in resty.
+func applyRequestHeaders(_ *resty.Client, req *resty.Request) error {
+	for k, v := range requestHeaders(req.Context()) {
+		req.SetHeader(k, v)
+	}
+	return nil
+}
+
+// capturedHeadersKey is the context key for response header capture.
+type capturedHeadersKey struct{}
+
+// headerCapture holds the destination and names of the response headers to capture.
+type headerCapture struct {
+	mu      sync.Mutex
+	headers *http.Header
+	names   []string
+}
+
+// CaptureHeaders returns a copy of ctx that makes the client copy the named response headers
+// into headers, e.g. to read rate limit information without access to the raw response.
+// Headers are captured from every response received with the context, including error
+// responses; when a method makes several requests, the values of the last response that
+// carried a header win. Headers missing from all responses are left untouched.
+//
+// Example:
+//
+//	var headers http.Header
+//	ctx = keycloak.CaptureHeaders(ctx, &headers, "X-RateLimit-Remaining")
+//	group, err := client.Groups.Get(ctx, groupID)
+//	remaining := headers.Get("X-RateLimit-Remaining")
+func CaptureHeaders(ctx context.Context, headers *http.Header, names ...string) context.Context {
+	return context.WithValue(ctx, capturedHeadersKey{}, &headerCapture{
+		headers: headers,
+		names:   names,
+	})
+}
+
+// captureHeaders is a resty middleware that copies the response headers requested with
+// CaptureHeaders into the caller's header map.
+func captureHeaders(_ *resty.Client, resp *resty.Response) error {
+	capture, ok := resp.Request.Context().Value(capturedHeadersKey{}).(*headerCapture)
+	if !ok || capture.headers == nil {
+		return nil
+	}
+
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	for _, name := range capture.names {
+		values := resp.Header().Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if *capture.headers == nil {
+			*capture.headers = make(http.Header)
+		}
+		capture.headers.Del(name)
+		for _, value := range values {
+			capture.headers.Add(name, value)
+		}
+	}
+	return nil
+}
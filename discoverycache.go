@@ -0,0 +1,116 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// WithDiscoveryCache persists the OIDC configuration discovered by New (the realm's token
+// endpoint) to the given file, and falls back to it when discovery fails, e.g. because
+// Keycloak is briefly down while the service restarts. Combined with WithTokenCacheFile, the
+// service can then start and serve requests with a still valid token. The cached
+// configuration is only used for the realm URL it was discovered from. The file is written
+// with 0600 permissions; failing to write it is logged as a warning (see WithLogger) and
+// does not fail New. Has no effect with WithTokenURL or WithTokenSource, which skip
+// discovery, or when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	cacheDir, _ := os.UserCacheDir()
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithDiscoveryCache(filepath.Join(cacheDir, "my-service", "oidc.json")),
+//	    keycloak.WithTokenCacheFile(filepath.Join(cacheDir, "my-service", "token.json")),
+//	)
+func WithDiscoveryCache(path string) Option {
+	return func(c *Client) error {
+		if path == "" {
+			return fmt.Errorf("discovery cache file path cannot be empty")
+		}
+		c.discoveryCacheFile = path
+		return nil
+	}
+}
+
+// cachedDiscovery is the on-disk format of the discovery cache file, a subset of the OIDC
+// discovery document.
+type cachedDiscovery struct {
+	Issuer        string `json:"issuer"`         // Realm URL the configuration was discovered from
+	TokenEndpoint string `json:"token_endpoint"` // Token endpoint of the realm
+}
+
+// discoverTokenURL returns the token endpoint of the realm at realmURL using OIDC discovery,
+// falling back to the discovery cache, if configured, when discovery fails.
+func (c *Client) discoverTokenURL(ctx context.Context, realmURL string) (string, error) {
+	provider, err := oidc.NewProvider(ctx, realmURL)
+	if err != nil {
+		if c.discoveryCacheFile == "" {
+			return "", fmt.Errorf("login failed: %w", err)
+		}
+		cached, cacheErr := loadDiscovery(c.discoveryCacheFile, realmURL)
+		if cacheErr != nil {
+			return "", fmt.Errorf("login failed: %w", errors.Join(err, cacheErr))
+		}
+		c.logEvent(ctx, slog.LevelWarn, "keycloak discovery failed, using cached configuration",
+			"error", err,
+			"token_url", cached.TokenEndpoint,
+		)
+		return cached.TokenEndpoint, nil
+	}
+
+	tokenURL := provider.Endpoint().TokenURL
+	if c.discoveryCacheFile != "" {
+		// NewProvider checked that the discovered issuer is the realm URL
+		if err := saveDiscovery(c.discoveryCacheFile, cachedDiscovery{Issuer: realmURL, TokenEndpoint: tokenURL}); err != nil {
+			c.logEvent(ctx, slog.LevelWarn, "keycloak discovery cache not written", "error", err)
+		}
+	}
+	return tokenURL, nil
+}
+
+// loadDiscovery reads the discovery cache file and checks that it was written for realmURL.
+func loadDiscovery(path, realmURL string) (*cachedDiscovery, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read discovery cache: %w", err)
+	}
+	var cached cachedDiscovery
+	if err := unmarshalJSON(b, &cached); err != nil {
+		return nil, fmt.Errorf("invalid discovery cache: %w", err)
+	}
+	if cached.Issuer != realmURL {
+		return nil, fmt.Errorf("discovery cache is for issuer %q, not %q", cached.Issuer, realmURL)
+	}
+	if cached.TokenEndpoint == "" {
+		return nil, fmt.Errorf("discovery cache has no token endpoint")
+	}
+	return &cached, nil
+}
+
+// saveDiscovery writes the discovery cache file atomically with 0600 permissions.
+func saveDiscovery(path string, cached cachedDiscovery) error {
+	b, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b)
+}
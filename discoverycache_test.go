@@ -0,0 +1,95 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDiscoveryCache(t *testing.T) {
+	t.Run("empty path", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithDiscoveryCache("")(client))
+	})
+
+	server := newTestOIDCServer(3600, nil)
+	defer server.Close()
+	// Discovery fails while Keycloak is "down"; the token endpoint keeps working so that
+	// tests can tell whether a cached token was reused
+	var down atomic.Bool
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() && strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache", "oidc.json")
+	tokenPath := filepath.Join(dir, "token.json")
+	ctx := context.Background()
+
+	client, err := New(ctx, server.config(), WithDiscoveryCache(path), WithTokenCacheFile(tokenPath))
+	require.NoError(t, err)
+	_, err = client.Groups.List(ctx, nil, true)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), server.URL+"/realms/test-realm/protocol/openid-connect/token")
+	assert.NotContains(t, string(b), server.config().ClientSecret)
+
+	down.Store(true)
+
+	t.Run("fallback to cache", func(t *testing.T) {
+		logger := &testLogger{}
+		client, err := New(ctx, server.config(), WithDiscoveryCache(path), WithTokenCacheFile(tokenPath), WithLogger(logger))
+		require.NoError(t, err)
+		_, err = client.Groups.List(ctx, nil, true)
+		require.NoError(t, err)
+		// The token cached by the first client is still valid
+		assert.Equal(t, int32(1), server.tokenRequests.Load())
+		assert.Contains(t, strings.Join(logger.Lines(), "\n"), "WARN keycloak discovery failed, using cached configuration")
+	})
+
+	t.Run("no cache", func(t *testing.T) {
+		_, err := New(ctx, server.config())
+		assert.Error(t, err)
+
+		_, err = New(ctx, server.config(), WithDiscoveryCache(filepath.Join(dir, "missing.json")))
+		assert.Error(t, err)
+	})
+
+	t.Run("cache for another realm", func(t *testing.T) {
+		config := server.config()
+		config.Realm = "other-realm"
+		_, err := New(ctx, config, WithDiscoveryCache(path))
+		assert.ErrorContains(t, err, "discovery cache is for issuer")
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// WithDNSCache caches DNS lookups of the hosts the client connects to (the Keycloak server
+// and, if configured, the proxy) for the given TTL. This saves a resolver round trip per new
+// connection for services making many calls. Failed lookups are not cached.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config, keycloak.WithDNSCache(time.Minute))
+func WithDNSCache(ttl time.Duration) Option {
+	return func(c *Client) error {
+		if ttl <= 0 {
+			return fmt.Errorf("DNS cache TTL must be positive, got %s", ttl)
+		}
+		if c.transport == nil {
+			return fmt.Errorf("transport is not configurable")
+		}
+		cache := newDNSCache(net.DefaultResolver, ttl)
+		c.transport.DialContext = cache.dialContext(c.transport.DialContext)
+		return nil
+	}
+}
+
+// hostResolver resolves host names to IP addresses. *net.Resolver implements it.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// dnsCache caches the addresses returned by a resolver for a fixed TTL.
+// It is safe for concurrent use. Concurrent lookups of the same uncached host may each
+// reach the resolver; the last result wins.
+type dnsCache struct {
+	resolver hostResolver
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]dnsCacheEntry
+}
+
+// dnsCacheEntry holds the cached addresses of a host and when they expire.
+type dnsCacheEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// newDNSCache creates a cache in front of resolver that keeps results for ttl.
+func newDNSCache(resolver hostResolver, ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		resolver: resolver,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]dnsCacheEntry),
+	}
+}
+
+// lookup returns the addresses of host, from the cache if they have not expired.
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := c.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[host] = dnsCacheEntry{addrs: addrs, expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// dialContext wraps dial so that host names are resolved through the cache. The resolved
+// addresses are tried in order until one connects, like net.Dialer does. IP addresses are
+// dialed directly. A nil dial uses a zero net.Dialer.
+func (c *dnsCache) dialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+
+		ips, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if lastErr == nil {
+			lastErr = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return nil, lastErr
+	}
+}
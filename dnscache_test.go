@@ -0,0 +1,162 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingResolver is a hostResolver returning fixed addresses and counting lookups per host.
+type countingResolver struct {
+	mu      sync.Mutex
+	lookups map[string]int
+	addrs   []string
+	err     error
+}
+
+func (r *countingResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lookups == nil {
+		r.lookups = make(map[string]int)
+	}
+	r.lookups[host]++
+	return r.addrs, r.err
+}
+
+func (r *countingResolver) count(host string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lookups[host]
+}
+
+func TestDNSCache_Lookup(t *testing.T) {
+	t.Run("cached until TTL expires", func(t *testing.T) {
+		resolver := &countingResolver{addrs: []string{"10.0.0.1"}}
+		cache := newDNSCache(resolver, time.Minute)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		for i := 0; i < 5; i++ {
+			addrs, err := cache.lookup(context.Background(), "keycloak.example.com")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"10.0.0.1"}, addrs)
+		}
+		assert.Equal(t, 1, resolver.count("keycloak.example.com"))
+
+		now = now.Add(time.Minute)
+		_, err := cache.lookup(context.Background(), "keycloak.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 2, resolver.count("keycloak.example.com"))
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		resolver := &countingResolver{err: errors.New("lookup failed")}
+		cache := newDNSCache(resolver, time.Minute)
+
+		for i := 0; i < 2; i++ {
+			_, err := cache.lookup(context.Background(), "keycloak.example.com")
+			assert.Error(t, err)
+		}
+		assert.Equal(t, 2, resolver.count("keycloak.example.com"))
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		resolver := &countingResolver{addrs: []string{"10.0.0.1"}}
+		cache := newDNSCache(resolver, time.Minute)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := cache.lookup(context.Background(), "keycloak.example.com")
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, resolver.count("keycloak.example.com"), 20)
+	})
+}
+
+func TestDNSCache_DialContext(t *testing.T) {
+	var dialed []string
+	dial := func(_ context.Context, _, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		if addr == "10.0.0.1:443" {
+			return nil, errors.New("connection refused")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+
+	resolver := &countingResolver{addrs: []string{"10.0.0.1", "10.0.0.2"}}
+	dialContext := newDNSCache(resolver, time.Minute).dialContext(dial)
+
+	for i := 0; i < 2; i++ {
+		conn, err := dialContext(context.Background(), "tcp", "keycloak.example.com:443")
+		require.NoError(t, err)
+		conn.Close()
+	}
+	assert.Equal(t, 1, resolver.count("keycloak.example.com"))
+	assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.2:443", "10.0.0.1:443", "10.0.0.2:443"}, dialed)
+
+	dialed = nil
+	conn, err := dialContext(context.Background(), "tcp", "192.168.1.1:8443")
+	require.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"192.168.1.1:8443"}, dialed)
+	assert.Equal(t, 0, resolver.count("192.168.1.1"))
+}
+
+func TestWithDNSCache(t *testing.T) {
+	t.Run("invalid TTL", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient(), transport: newTransport(newDialer())}
+		assert.Error(t, WithDNSCache(0)(client))
+		assert.Error(t, WithDNSCache(-time.Second)(client))
+	})
+
+	t.Run("no transport", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithDNSCache(time.Minute)(client))
+	})
+
+	t.Run("requests succeed through the cache", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		client := newTestClient("http://localhost:"+u.Port(), WithDNSCache(time.Minute))
+
+		_, err = client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+	})
+}
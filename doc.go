@@ -27,6 +27,8 @@
 //   - Comprehensive error handling with detailed error responses
 //   - Configurable timeouts, retries, and debugging
 //   - Group member management
+//   - User management and provisioning
+//   - Client role lookups and group role mappings
 //   - Management permissions control
 //   - Type-safe API with pointer-based optional fields
 //
@@ -225,6 +227,8 @@
 //
 // The Client is safe for concurrent use by multiple goroutines. The underlying
 // HTTP client handles connection pooling and the OAuth2 token source is thread-safe.
+// Token fetches are serialized: when the token is missing or expired, concurrent requests
+// wait for a single refresh instead of each requesting a token.
 //
 // # Best Practices
 //
@@ -16,6 +16,7 @@ package keycloak
 
 import (
 	"net/http"
+	"net/url"
 	"strings"
 )
 
@@ -41,13 +42,193 @@ var (
 	endpointGroupMembers     = endpoint{http.MethodGet, "/admin/realms/{realm}/groups/{groupID}/members"}
 	endpointGroupPermsGet    = endpoint{http.MethodGet, "/admin/realms/{realm}/groups/{groupID}/management/permissions"}
 	endpointGroupPermsUpdate = endpoint{http.MethodPut, "/admin/realms/{realm}/groups/{groupID}/management/permissions"}
+	endpointGroupRoleMaps    = endpoint{http.MethodGet, "/admin/realms/{realm}/groups/{groupID}/role-mappings"}
+
+	endpointDefaultGroupAdd    = endpoint{http.MethodPut, "/admin/realms/{realm}/default-groups/{groupID}"}
+	endpointDefaultGroupRemove = endpoint{http.MethodDelete, "/admin/realms/{realm}/default-groups/{groupID}"}
+)
+
+// Keycloak Admin API endpoints for Users resource.
+// See: https://www.keycloak.org/docs-api/latest/rest-api/index.html#_users
+var (
+	endpointUsersCreate     = endpoint{http.MethodPost, "/admin/realms/{realm}/users"}
+	endpointUsersCount      = endpoint{http.MethodGet, "/admin/realms/{realm}/users/count"}
+	endpointUserGet         = endpoint{http.MethodGet, "/admin/realms/{realm}/users/{userID}"}
+	endpointUserUpdate      = endpoint{http.MethodPut, "/admin/realms/{realm}/users/{userID}"}
+	endpointUserDelete      = endpoint{http.MethodDelete, "/admin/realms/{realm}/users/{userID}"}
+	endpointUserResetPass   = endpoint{http.MethodPut, "/admin/realms/{realm}/users/{userID}/reset-password"}
+	endpointUserGroups      = endpoint{http.MethodGet, "/admin/realms/{realm}/users/{userID}/groups"}
+	endpointUserGroupJoin   = endpoint{http.MethodPut, "/admin/realms/{realm}/users/{userID}/groups/{groupID}"}
+	endpointUserGroupLeave  = endpoint{http.MethodDelete, "/admin/realms/{realm}/users/{userID}/groups/{groupID}"}
+	endpointUserImpersonate = endpoint{http.MethodPost, "/admin/realms/{realm}/users/{userID}/impersonation"}
+
+	endpointUserRealmRolesEffective = endpoint{http.MethodGet, "/admin/realms/{realm}/users/{userID}/role-mappings/realm/composite"}
+)
+
+// Keycloak Admin API endpoints for Realms resource.
+// See: https://www.keycloak.org/docs-api/latest/rest-api/index.html#_realms_admin
+var (
+	endpointRealmsList    = endpoint{http.MethodGet, "/admin/realms"}
+	endpointPartialImport = endpoint{http.MethodPost, "/admin/realms/{realm}/partialImport"}
+	endpointServerInfo    = endpoint{http.MethodGet, "/admin/serverinfo"}
+)
+
+// Keycloak Admin API endpoints for Clients resource.
+// See: https://www.keycloak.org/docs-api/latest/rest-api/index.html#_clients
+var (
+	endpointClientsList   = endpoint{http.MethodGet, "/admin/realms/{realm}/clients"}
+	endpointClientRoles   = endpoint{http.MethodGet, "/admin/realms/{realm}/clients/{id}/roles"}
+	endpointClientRoleGet = endpoint{http.MethodGet, "/admin/realms/{realm}/clients/{id}/roles/{roleName}"}
+
+	endpointClientProtocolMappers      = endpoint{http.MethodGet, "/admin/realms/{realm}/clients/{id}/protocol-mappers/models"}
+	endpointClientProtocolMapperCreate = endpoint{http.MethodPost, "/admin/realms/{realm}/clients/{id}/protocol-mappers/models"}
+	endpointClientProtocolMapperDelete = endpoint{http.MethodDelete, "/admin/realms/{realm}/clients/{id}/protocol-mappers/models/{mapperID}"}
 )
 
+// endpointNames maps stable endpoint identifiers, as accepted by WithEndpointTimeouts, to
+// endpoints. Identifiers are named after the method primarily using the endpoint, or, for
+// endpoints only used as a step of other methods, after what the endpoint itself does (e.g.
+// "Users.Count"), so that a name never suggests it bounds a whole multi-request method. Once
+// published they must not change.
+var endpointNames = map[string]endpoint{
+	"Groups.List":                        endpointGroupsList,
+	"Groups.Create":                      endpointGroupsCreate,
+	"Groups.Count":                       endpointGroupsCount,
+	"Groups.Get":                         endpointGroupGet,
+	"Groups.Update":                      endpointGroupUpdate,
+	"Groups.Delete":                      endpointGroupDelete,
+	"Groups.ListSubGroups":               endpointGroupChildren,
+	"Groups.CreateSubGroup":              endpointGroupChildCreate,
+	"Groups.ListMembers":                 endpointGroupMembers,
+	"Groups.GetManagementPermissions":    endpointGroupPermsGet,
+	"Groups.UpdateManagementPermissions": endpointGroupPermsUpdate,
+	"Groups.GetRoleMappings":             endpointGroupRoleMaps,
+	"Groups.AddDefaultGroup":             endpointDefaultGroupAdd,
+	"Groups.RemoveDefaultGroup":          endpointDefaultGroupRemove,
+
+	"Users.Create":          endpointUsersCreate,
+	"Users.Count":           endpointUsersCount,
+	"Users.Get":             endpointUserGet,
+	"Users.Update":          endpointUserUpdate,
+	"Users.Delete":          endpointUserDelete,
+	"Users.ResetPassword":   endpointUserResetPass,
+	"Users.AddToGroup":      endpointUserGroupJoin,
+	"Users.RemoveFromGroup": endpointUserGroupLeave,
+	"Users.ListGroups":      endpointUserGroups,
+	"Users.Impersonate":     endpointUserImpersonate,
+
+	"Users.ListEffectiveRealmRoles": endpointUserRealmRolesEffective,
+
+	"ListRealms":    endpointRealmsList,
+	"PartialImport": endpointPartialImport,
+	"ServerVersion": endpointServerInfo,
+
+	"Clients.InternalID":           endpointClientsList,
+	"Clients.ListRoles":            endpointClientRoles,
+	"Clients.GetRole":              endpointClientRoleGet,
+	"Clients.ListProtocolMappers":  endpointClientProtocolMappers,
+	"Clients.AddProtocolMapper":    endpointClientProtocolMapperCreate,
+	"Clients.DeleteProtocolMapper": endpointClientProtocolMapperDelete,
+}
+
+// endpointTemplate is an endpoint path template split into segments, used to attribute
+// request paths to endpoints.
+type endpointTemplate struct {
+	name     string
+	endpoint endpoint
+	segments []string
+	literals int
+}
+
+// endpointTemplates holds the templates of all named endpoints.
+var endpointTemplates = newEndpointTemplates()
+
+// newEndpointTemplates splits the path templates of all named endpoints into segments.
+func newEndpointTemplates() []endpointTemplate {
+	templates := make([]endpointTemplate, 0, len(endpointNames))
+	for name, ep := range endpointNames {
+		segments := strings.Split(strings.Trim(ep.Path, "/"), "/")
+		literals := 0
+		for _, segment := range segments {
+			if !isPlaceholder(segment) {
+				literals++
+			}
+		}
+		templates = append(templates, endpointTemplate{name: name, endpoint: ep, segments: segments, literals: literals})
+	}
+	return templates
+}
+
+// matchEndpoint returns the most specific endpoint template matching a request, or nil if
+// none does. Paths are matched by suffix, so a base URL with a path prefix (e.g. /auth) is
+// supported; path must be escaped, as returned by url.URL.EscapedPath.
+func matchEndpoint(method, path string) *endpointTemplate {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	var best *endpointTemplate
+	for i := range endpointTemplates {
+		template := &endpointTemplates[i]
+		if template.endpoint.Method != method || !template.matches(segments) {
+			continue
+		}
+		if best == nil || template.literals > best.literals {
+			best = template
+		}
+	}
+	return best
+}
+
+// matches reports whether the trailing path segments match the template.
+func (e *endpointTemplate) matches(segments []string) bool {
+	if len(segments) < len(e.segments) {
+		return false
+	}
+	segments = segments[len(segments)-len(e.segments):]
+	for i, segment := range e.segments {
+		if !isPlaceholder(segment) && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// params returns the unescaped placeholder values of an escaped path matching the template,
+// keyed by placeholder name without curly braces.
+func (e *endpointTemplate) params(path string) map[string]string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if !e.matches(segments) {
+		return nil
+	}
+	segments = segments[len(segments)-len(e.segments):]
+	params := make(map[string]string)
+	for i, segment := range e.segments {
+		if !isPlaceholder(segment) {
+			continue
+		}
+		value, err := url.PathUnescape(segments[i])
+		if err != nil {
+			value = segments[i]
+		}
+		params[strings.Trim(segment, "{}")] = value
+	}
+	return params
+}
+
+// isPlaceholder reports whether a template segment is a {placeholder}.
+func isPlaceholder(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
 // buildURL constructs a full URL from an endpoint template by replacing placeholders with actual values.
 // The realm is automatically substituted from the client configuration.
 // Additional parameters can be provided via the params map using keys that match the placeholder names
 // (without curly braces). For example, to replace {groupID}, use params["groupID"].
 //
+// Substituted values are path-escaped, so names containing spaces, slashes or non-ASCII characters
+// (e.g. role names or aliases) address a single path segment. The {path} placeholder holds a
+// slash-separated hierarchy path (e.g. a group path); its segments are escaped individually and
+// the separators are kept. Callers must pass raw, unescaped values.
+//
 // Example:
 //
 //	url := c.buildURL(endpointGroupGet, map[string]string{"groupID": "123"})
@@ -56,13 +237,26 @@ func (c *Client) buildURL(ep endpoint, params map[string]string) string {
 	path := ep.Path
 
 	// Always replace realm placeholder with client's configured realm
-	path = strings.ReplaceAll(path, "{realm}", c.realm)
+	path = strings.ReplaceAll(path, "{realm}", url.PathEscape(c.realm))
 
 	// Replace additional placeholders if provided
 	for key, value := range params {
 		placeholder := "{" + key + "}"
-		path = strings.ReplaceAll(path, placeholder, value)
+		path = strings.ReplaceAll(path, placeholder, escapePathParam(key, value))
 	}
 
 	return c.baseURL + path
 }
+
+// escapePathParam escapes a placeholder value for use in a URL path. Values of the {path}
+// placeholder are escaped per segment; all others are escaped as a single segment.
+func escapePathParam(key, value string) string {
+	if key != "path" {
+		return url.PathEscape(value)
+	}
+	segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
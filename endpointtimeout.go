@@ -0,0 +1,107 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WithEndpointTimeouts sets per-endpoint timeouts, keyed by a stable endpoint identifier
+// such as "Groups.ListMembers" or "Groups.Count". Each attempt of a call to a listed endpoint
+// gets a context deadline of the given duration, which covers the round trip and reading
+// the response body. This allows strict deadlines for fast endpoints while slow ones keep
+// the global timeout.
+//
+// Identifiers name endpoints, not methods: "Groups.List" applies to every method listing
+// groups (List, ListPaginated, ListWithParams, ...) and "Groups.Get" also to Exists. The
+// global WithTimeout still bounds every attempt, so an endpoint timeout longer than it has
+// no effect; to give slow endpoints more time, raise WithTimeout and set shorter timeouts
+// for the remaining endpoints. Unknown identifiers and non-positive durations are rejected.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithTimeout(30*time.Second),
+//	    keycloak.WithEndpointTimeouts(map[string]time.Duration{
+//	        "Groups.Count": 2 * time.Second,
+//	        "Groups.Get":   5 * time.Second,
+//	    }),
+//	)
+func WithEndpointTimeouts(timeouts map[string]time.Duration) Option {
+	return func(c *Client) error {
+		resolved := make(map[endpoint]time.Duration, len(timeouts))
+		for name, timeout := range timeouts {
+			ep, ok := endpointNames[name]
+			if !ok {
+				return fmt.Errorf("unknown endpoint %q, expected one of %s", name, strings.Join(slices.Sorted(maps.Keys(endpointNames)), ", "))
+			}
+			if timeout <= 0 {
+				return fmt.Errorf("timeout for endpoint %q must be positive, got %v", name, timeout)
+			}
+			resolved[ep] = timeout
+		}
+		c.endpointTimeouts = resolved
+		return nil
+	}
+}
+
+// endpointTimeoutTransport is an http.RoundTripper that applies per-endpoint deadlines.
+type endpointTimeoutTransport struct {
+	base     http.RoundTripper
+	timeouts map[endpoint]time.Duration
+}
+
+// newEndpointTimeoutTransport wraps base so that requests to the endpoints in timeouts are
+// cancelled after their timeout. Requests are attributed to their most specific endpoint
+// (e.g. groups/count rather than groups/{groupID}), see matchEndpoint.
+func newEndpointTimeoutTransport(base http.RoundTripper, timeouts map[endpoint]time.Duration) *endpointTimeoutTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &endpointTimeoutTransport{base: base, timeouts: timeouts}
+}
+
+// RoundTrip sends the request with the deadline of its endpoint, if any.
+func (t *endpointTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeout := t.timeout(req.Method, req.URL.EscapedPath())
+	if timeout == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: cancel}
+	return resp, nil
+}
+
+// timeout returns the timeout of the most specific endpoint matching the request.
+func (t *endpointTimeoutTransport) timeout(method, path string) time.Duration {
+	template := matchEndpoint(method, path)
+	if template == nil {
+		return 0
+	}
+	return t.timeouts[template.endpoint]
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEndpointTimeouts(t *testing.T) {
+	client := &Client{}
+	assert.Error(t, WithEndpointTimeouts(map[string]time.Duration{"Groups.Unknown": time.Second})(client))
+	assert.Error(t, WithEndpointTimeouts(map[string]time.Duration{"Groups.Get": 0})(client))
+	assert.Nil(t, client.endpointTimeouts)
+
+	require.NoError(t, WithEndpointTimeouts(map[string]time.Duration{"Groups.ListMembers": time.Second})(client))
+	assert.Equal(t, map[endpoint]time.Duration{endpointGroupMembers: time.Second}, client.endpointTimeouts)
+}
+
+func TestEndpointTimeoutTransport_Timeout(t *testing.T) {
+	transport := newEndpointTimeoutTransport(nil, map[endpoint]time.Duration{
+		endpointGroupGet:     time.Second,
+		endpointGroupMembers: 2 * time.Second,
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   time.Duration
+	}{
+		{name: "exact endpoint", method: http.MethodGet, path: "/admin/realms/r/groups/g1/members", want: 2 * time.Second},
+		{name: "placeholder endpoint", method: http.MethodGet, path: "/admin/realms/r/groups/g1", want: time.Second},
+		{name: "base path prefix", method: http.MethodGet, path: "/auth/admin/realms/r/groups/g1", want: time.Second},
+		{name: "more specific endpoint wins", method: http.MethodGet, path: "/admin/realms/r/groups/count", want: 0},
+		{name: "other method", method: http.MethodPut, path: "/admin/realms/r/groups/g1", want: 0},
+		{name: "unknown path", method: http.MethodGet, path: "/admin/realms/r/unknown", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, transport.timeout(tt.method, tt.path))
+		})
+	}
+}
+
+// TestEndpointTimeouts_WithServer tests that an endpoint timeout applies only to its endpoint
+func TestEndpointTimeouts_WithServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/admin/realms/test-realm/groups/g1/members" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`{"id":"g1"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithEndpointTimeouts(map[string]time.Duration{
+		"Groups.ListMembers": 20 * time.Millisecond,
+	}))
+
+	_, err := client.Groups.ListMembers(context.Background(), "g1", GroupMembersParams{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	group, err := client.Groups.Get(context.Background(), "g1")
+	require.NoError(t, err)
+	assert.Equal(t, "g1", *group.ID)
+}
@@ -15,7 +15,49 @@
 package keycloak
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrMethodNotAllowed is returned when Keycloak responds with 405 Method Not Allowed.
+	// This usually means the client is pointed at the wrong base path or at a Keycloak
+	// version where the endpoint has moved.
+	ErrMethodNotAllowed = errors.New("method not allowed")
+
+	// ErrPageLimitExceeded is returned by methods that page through results automatically
+	// when more pages than the WithMaxPages limit would be needed.
+	ErrPageLimitExceeded = errors.New("page limit exceeded")
+
+	// ErrSlowCall is returned when a call is cancelled for exceeding the slow call
+	// threshold (see WithSlowCallThreshold and WithCancelSlowCalls).
+	ErrSlowCall = errors.New("slow call cancelled")
+
+	// ErrRetryAfterExceeded is returned when the server asks to retry later than the cap
+	// set with WithMaxRetryAfter. The error also wraps the APIError of the response.
+	ErrRetryAfterExceeded = errors.New("retry after exceeds limit")
+
+	// ErrForbidden is returned when Keycloak responds with 403 Forbidden to a request
+	// that requires more privileges than the service account has (e.g. listing realms
+	// from a client outside the master realm).
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrUnsupportedServer is returned when a feature is not available on the Keycloak
+	// server the client is connected to, e.g. because the server version is too old.
+	ErrUnsupportedServer = errors.New("unsupported by Keycloak server")
+
+	// ErrUnexpectedLocation is returned when the Location header of a create response does
+	// not point at a resource of the expected kind in the client's realm, e.g. because a
+	// proxy rewrote it. The resource was created, but its ID is unknown.
+	ErrUnexpectedLocation = errors.New("unexpected Location header")
+
+	// ErrUnexpectedContentType is returned, with WithResponseContentTypeCheck, when a
+	// successful response does not carry JSON, e.g. a login page served by a proxy.
+	ErrUnexpectedContentType = errors.New("unexpected response content type")
 )
 
 // HTTPErrorResponse represents an error response from the Keycloak API.
@@ -52,3 +94,44 @@ func (e HTTPErrorResponse) String() string {
 	}
 	return res.String()
 }
+
+// APIError is returned (wrapped) when Keycloak answers a request with a non-success
+// status code. Use errors.As to inspect the status code and error details:
+//
+//	var apiErr *keycloak.APIError
+//	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+//	    // Group already exists
+//	}
+type APIError struct {
+	StatusCode int               // HTTP status code of the response
+	Response   HTTPErrorResponse // Error details from the response body (may be empty)
+	ExistingID string            // ID of the conflicting resource on 409 Conflict, if known (see GroupsClient.Create)
+}
+
+// Error returns the status code and, if present, the error details of the response.
+func (e *APIError) Error() string {
+	status := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Response.Empty() {
+		return status
+	}
+	return status + ": " + e.Response.String()
+}
+
+// newAPIError creates an APIError from a non-success response.
+func newAPIError(resp *resty.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode()}
+	if details, ok := resp.Error().(*HTTPErrorResponse); ok && details != nil {
+		apiErr.Response = *details
+	}
+	return apiErr
+}
+
+// checkMethodAllowed is a response middleware that turns 405 responses into ErrMethodNotAllowed
+// with a hint about the most common cause, a base URL or version mismatch.
+func checkMethodAllowed(_ *resty.Client, resp *resty.Response) error {
+	if resp.StatusCode() != http.StatusMethodNotAllowed {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s (check that the URL and Keycloak version match, e.g. a missing or extra /auth prefix for Keycloak versions before 17)",
+		ErrMethodNotAllowed, resp.Request.Method, resp.Request.URL)
+}
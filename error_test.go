@@ -15,9 +15,15 @@
 package keycloak
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHTTPErrorResponse_Empty(t *testing.T) {
@@ -180,3 +186,126 @@ func TestHTTPErrorResponse_String(t *testing.T) {
 		})
 	}
 }
+
+func TestMethodNotAllowed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	_, err := client.Groups.List(context.Background(), nil, true)
+
+	assert.ErrorIs(t, err, ErrMethodNotAllowed)
+	assert.Contains(t, err.Error(), "/admin/realms/test-realm/groups")
+	assert.Contains(t, err.Error(), "/auth prefix")
+}
+
+func TestAPIError_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      APIError
+		expected string
+	}{
+		{
+			name:     "status only",
+			err:      APIError{StatusCode: http.StatusInternalServerError},
+			expected: "500 Internal Server Error",
+		},
+		{
+			name: "with details",
+			err: APIError{
+				StatusCode: http.StatusConflict,
+				Response:   HTTPErrorResponse{Message: "Top level group named 'x' already exists."},
+			},
+			expected: "409 Conflict: Top level group named 'x' already exists.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.err.Error())
+		})
+	}
+}
+
+// TestAPIError_Unwrap tests that API failures of every kind of groupsClient method can be
+// unwrapped to an APIError
+func TestAPIError_Unwrap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"errorMessage":"conflict"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"Create": func() error {
+			_, err := client.Groups.Create(ctx, "group", nil)
+			return err
+		},
+		"Update": func() error {
+			id := "g1"
+			return client.Groups.Update(ctx, Group{ID: &id})
+		},
+		"Delete": func() error {
+			return client.Groups.Delete(ctx, "g1")
+		},
+		"List": func() error {
+			_, err := client.Groups.List(ctx, nil, true)
+			return err
+		},
+		"Count": func() error {
+			_, err := client.Groups.Count(ctx, nil, nil)
+			return err
+		},
+		"Get": func() error {
+			_, err := client.Groups.Get(ctx, "g1")
+			return err
+		},
+		"ListSubGroups": func() error {
+			_, err := client.Groups.ListSubGroups(ctx, "g1")
+			return err
+		},
+		"ListMembers": func() error {
+			_, err := client.Groups.ListMembers(ctx, "g1", GroupMembersParams{})
+			return err
+		},
+		"GetManagementPermissions": func() error {
+			_, err := client.Groups.GetManagementPermissions(ctx, "g1")
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			err := call()
+			require.Error(t, err)
+
+			var apiErr *APIError
+			require.True(t, errors.As(err, &apiErr), "error %q does not wrap an APIError", err)
+			assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+			assert.Equal(t, "conflict", apiErr.Response.Message)
+			assert.Contains(t, err.Error(), "409 Conflict: conflict")
+		})
+	}
+}
+
+// TestTransportError_Unwrap tests that transport failures can be unwrapped to the underlying error
+func TestTransportError_Unwrap(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := newTestClient(server.URL)
+	_, err := client.Groups.Get(context.Background(), "g1")
+	require.Error(t, err)
+
+	var urlErr *url.Error
+	assert.True(t, errors.As(err, &urlErr), "error %q does not wrap a *url.Error", err)
+
+	var apiErr *APIError
+	assert.False(t, errors.As(err, &apiErr))
+}
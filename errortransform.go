@@ -0,0 +1,405 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+)
+
+// WithErrorTransform sets a function applied to every non-nil error returned by the methods
+// of Groups, Users and Clients before it reaches the caller, e.g. to map Keycloak errors into
+// an application's own error taxonomy in one place. Errors yielded by iterators such as
+// Groups.IterateMembers are transformed too. If the transform returns nil, the original
+// error is returned.
+//
+// The transform replaces the error the caller sees, so errors.Is and errors.As only find
+// this package's sentinels (e.g. ErrGroupNotFound) and *APIError if the transform returns
+// the error unchanged or wraps it with %w. A transform that returns unrelated errors breaks
+// such checks in callers and in code that expects this package's errors.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithErrorTransform(func(err error) error {
+//	        if errors.Is(err, keycloak.ErrGroupNotFound) {
+//	            return fmt.Errorf("%w: %w", domain.ErrNotFound, err)
+//	        }
+//	        return err
+//	    }),
+//	)
+func WithErrorTransform(transform func(error) error) Option {
+	return func(c *Client) error {
+		if transform == nil {
+			return fmt.Errorf("error transform cannot be nil")
+		}
+		c.errorTransform = transform
+		return nil
+	}
+}
+
+// initResourceClients creates the resource clients, wrapping them with the error transform
+// if one is configured. It must be called once, after all options have been applied.
+func (c *Client) initResourceClients() {
+	c.Groups = newGroupsClient(c)
+	c.Users = newUsersClient(c)
+	c.Clients = newClientsClient(c)
+
+	if c.errorTransform != nil {
+		t := errorTransformer(c.errorTransform)
+		c.Groups = &transformingGroupsClient{next: c.Groups, t: t}
+		c.Users = &transformingUsersClient{next: c.Users, t: t}
+		c.Clients = &transformingClientsClient{next: c.Clients, t: t}
+	}
+}
+
+// errorTransformer applies a user-supplied error transform.
+type errorTransformer func(error) error
+
+// apply transforms a non-nil error, keeping the original if the transform returns nil.
+func (t errorTransformer) apply(err error) error {
+	if err == nil {
+		return nil
+	}
+	if transformed := t(err); transformed != nil {
+		return transformed
+	}
+	return err
+}
+
+// transformingGroupsClient applies the error transform to every GroupsClient method.
+type transformingGroupsClient struct {
+	next GroupsClient
+	t    errorTransformer
+}
+
+func (g *transformingGroupsClient) Create(ctx context.Context, name string, attributes map[string][]string) (string, error) {
+	id, err := g.next.Create(ctx, name, attributes)
+	return id, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) CreateWithID(ctx context.Context, group Group) error {
+	return g.t.apply(g.next.CreateWithID(ctx, group))
+}
+
+func (g *transformingGroupsClient) Update(ctx context.Context, group Group) error {
+	return g.t.apply(g.next.Update(ctx, group))
+}
+
+func (g *transformingGroupsClient) RemoveAttributeValue(ctx context.Context, groupID, key, value string) error {
+	return g.t.apply(g.next.RemoveAttributeValue(ctx, groupID, key, value))
+}
+
+func (g *transformingGroupsClient) Delete(ctx context.Context, groupID string) error {
+	return g.t.apply(g.next.Delete(ctx, groupID))
+}
+
+func (g *transformingGroupsClient) List(ctx context.Context, search *string, briefRepresentation bool) ([]*Group, error) {
+	groups, err := g.next.List(ctx, search, briefRepresentation)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListWithParams(ctx context.Context, params SearchGroupParams) ([]*Group, error) {
+	groups, err := g.next.ListWithParams(ctx, params)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListNoCount(ctx context.Context, params SearchGroupParams) ([]*Group, error) {
+	groups, err := g.next.ListNoCount(ctx, params)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListWithSubGroups(ctx context.Context, searchQuery string, briefRepresentation bool, first, max int) ([]*Group, error) {
+	groups, err := g.next.ListWithSubGroups(ctx, searchQuery, briefRepresentation, first, max)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListSorted(ctx context.Context, params SearchGroupParams, less func(a, b *Group) bool) ([]*Group, error) {
+	groups, err := g.next.ListSorted(ctx, params, less)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListTopLevel(ctx context.Context, params SearchGroupParams) ([]*Group, error) {
+	groups, err := g.next.ListTopLevel(ctx, params)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) BuildAttributeIndex(ctx context.Context, key string) (map[string]*Group, error) {
+	index, err := g.next.BuildAttributeIndex(ctx, key)
+	return index, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) Count(ctx context.Context, search *string, top *bool) (int, error) {
+	count, err := g.next.Count(ctx, search, top)
+	return count, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListPaginated(ctx context.Context, search *string, briefRepresentation bool, first, max int) ([]*Group, error) {
+	groups, err := g.next.ListPaginated(ctx, search, briefRepresentation, first, max)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) Get(ctx context.Context, groupID string) (*Group, error) {
+	group, err := g.next.Get(ctx, groupID)
+	return group, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) GetRaw(ctx context.Context, groupID string) (*Group, json.RawMessage, error) {
+	group, raw, err := g.next.GetRaw(ctx, groupID)
+	return group, raw, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) GetByName(ctx context.Context, name string) (*Group, error) {
+	group, err := g.next.GetByName(ctx, name)
+	return group, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) Exists(ctx context.Context, groupID string) (bool, error) {
+	exists, err := g.next.Exists(ctx, groupID)
+	return exists, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) Ancestry(ctx context.Context, groupID string) ([]*Group, error) {
+	groups, err := g.next.Ancestry(ctx, groupID)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) GetWithSubGroups(ctx context.Context, groupID string, depth int) (*Group, error) {
+	group, err := g.next.GetWithSubGroups(ctx, groupID, depth)
+	return group, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) GetByAttribute(ctx context.Context, attribute *GroupAttribute) (*Group, error) {
+	group, err := g.next.GetByAttribute(ctx, attribute)
+	return group, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) SearchByAnyAttribute(ctx context.Context, attrs []GroupAttribute) ([]*Group, error) {
+	groups, err := g.next.SearchByAnyAttribute(ctx, attrs)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListSubGroups(ctx context.Context, groupID string) ([]*Group, error) {
+	groups, err := g.next.ListSubGroups(ctx, groupID)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListSubGroupsPaginated(ctx context.Context, groupID string, params SubGroupSearchParams) ([]*Group, error) {
+	groups, err := g.next.ListSubGroupsPaginated(ctx, groupID, params)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) CreateSubGroup(ctx context.Context, groupID, name string, attributes map[string][]string) (string, error) {
+	id, err := g.next.CreateSubGroup(ctx, groupID, name, attributes)
+	return id, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) Detach(ctx context.Context, groupID string) error {
+	return g.t.apply(g.next.Detach(ctx, groupID))
+}
+
+func (g *transformingGroupsClient) GetSubGroupByAttribute(group Group, attribute GroupAttribute) (*Group, error) {
+	subGroup, err := g.next.GetSubGroupByAttribute(group, attribute)
+	return subGroup, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) FindSubGroupsByAttribute(ctx context.Context, attribute GroupAttribute) ([]*Group, error) {
+	groups, err := g.next.FindSubGroupsByAttribute(ctx, attribute)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListWithAttribute(ctx context.Context, key string, params SearchGroupParams) ([]*Group, error) {
+	groups, err := g.next.ListWithAttribute(ctx, key, params)
+	return groups, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) GetSubGroupByID(group Group, subGroupID string) (*Group, error) {
+	subGroup, err := g.next.GetSubGroupByID(group, subGroupID)
+	return subGroup, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListMembers(ctx context.Context, groupID string, params GroupMembersParams) ([]*User, error) {
+	members, err := g.next.ListMembers(ctx, groupID, params)
+	return members, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListMembersWithRole(ctx context.Context, groupID, roleName string, params GroupMembersParams) ([]*User, error) {
+	members, err := g.next.ListMembersWithRole(ctx, groupID, roleName, params)
+	return members, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ListMembersPage(ctx context.Context, groupID string, params GroupMembersParams) (*MemberPage, error) {
+	page, err := g.next.ListMembersPage(ctx, groupID, params)
+	return page, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) IterateMembers(ctx context.Context, groupID string, params GroupMembersParams) iter.Seq2[*User, error] {
+	return func(yield func(*User, error) bool) {
+		for member, err := range g.next.IterateMembers(ctx, groupID, params) {
+			if !yield(member, g.t.apply(err)) {
+				return
+			}
+		}
+	}
+}
+
+func (g *transformingGroupsClient) SnapshotMembers(ctx context.Context, groupID string, w io.Writer, resume *GroupMembersParams) (*GroupMembersParams, error) {
+	cursor, err := g.next.SnapshotMembers(ctx, groupID, w, resume)
+	return cursor, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ReconcileMembers(ctx context.Context, groupID string, desired []string) ([]string, []string, error) {
+	added, removed, err := g.next.ReconcileMembers(ctx, groupID, desired)
+	return added, removed, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) ReconcileTree(ctx context.Context, parentID string, desired *Group, opts ReconcileTreeOptions) error {
+	return g.t.apply(g.next.ReconcileTree(ctx, parentID, desired, opts))
+}
+
+func (g *transformingGroupsClient) TagMatching(ctx context.Context, params SearchGroupParams, key string, values []string) ([]BatchResult, error) {
+	results, err := g.next.TagMatching(ctx, params, key, values)
+	for i := range results {
+		results[i].Err = g.t.apply(results[i].Err)
+	}
+	return results, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) SubtreeSize(ctx context.Context, groupID string) (int, int, error) {
+	groups, members, err := g.next.SubtreeSize(ctx, groupID)
+	return groups, members, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) GetManagementPermissions(ctx context.Context, groupID string) (*ManagementPermissionReference, error) {
+	ref, err := g.next.GetManagementPermissions(ctx, groupID)
+	return ref, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) UpdateManagementPermissions(ctx context.Context, groupID string, ref ManagementPermissionReference) (*ManagementPermissionReference, error) {
+	updated, err := g.next.UpdateManagementPermissions(ctx, groupID, ref)
+	return updated, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) EnableManagementPermissionsAndWait(ctx context.Context, groupID string, timeout time.Duration) (*ManagementPermissionReference, error) {
+	ref, err := g.next.EnableManagementPermissionsAndWait(ctx, groupID, timeout)
+	return ref, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) PermissionsEnabled(ctx context.Context, groupID string) (bool, error) {
+	enabled, err := g.next.PermissionsEnabled(ctx, groupID)
+	return enabled, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) GetRoleMappings(ctx context.Context, groupID string) (*RoleMappings, error) {
+	mappings, err := g.next.GetRoleMappings(ctx, groupID)
+	return mappings, g.t.apply(err)
+}
+
+func (g *transformingGroupsClient) AddDefaultGroup(ctx context.Context, groupID string, opts DefaultGroupOptions) error {
+	return g.t.apply(g.next.AddDefaultGroup(ctx, groupID, opts))
+}
+
+func (g *transformingGroupsClient) RemoveDefaultGroup(ctx context.Context, groupID string, opts DefaultGroupOptions) error {
+	return g.t.apply(g.next.RemoveDefaultGroup(ctx, groupID, opts))
+}
+
+// transformingUsersClient applies the error transform to every UsersClient method.
+type transformingUsersClient struct {
+	next UsersClient
+	t    errorTransformer
+}
+
+func (u *transformingUsersClient) Create(ctx context.Context, user User) (string, error) {
+	id, err := u.next.Create(ctx, user)
+	return id, u.t.apply(err)
+}
+
+func (u *transformingUsersClient) Get(ctx context.Context, userID string) (*User, error) {
+	user, err := u.next.Get(ctx, userID)
+	return user, u.t.apply(err)
+}
+
+func (u *transformingUsersClient) Update(ctx context.Context, user User) error {
+	return u.t.apply(u.next.Update(ctx, user))
+}
+
+func (u *transformingUsersClient) Delete(ctx context.Context, userID string) error {
+	return u.t.apply(u.next.Delete(ctx, userID))
+}
+
+func (u *transformingUsersClient) DeleteWithCleanup(ctx context.Context, userID string) error {
+	return u.t.apply(u.next.DeleteWithCleanup(ctx, userID))
+}
+
+func (u *transformingUsersClient) ResetPassword(ctx context.Context, userID, password string, temporary bool) error {
+	return u.t.apply(u.next.ResetPassword(ctx, userID, password, temporary))
+}
+
+func (u *transformingUsersClient) AddToGroup(ctx context.Context, userID, groupID string) error {
+	return u.t.apply(u.next.AddToGroup(ctx, userID, groupID))
+}
+
+func (u *transformingUsersClient) RemoveFromGroup(ctx context.Context, userID, groupID string) error {
+	return u.t.apply(u.next.RemoveFromGroup(ctx, userID, groupID))
+}
+
+func (u *transformingUsersClient) Provision(ctx context.Context, req ProvisionUserRequest) (*User, error) {
+	user, err := u.next.Provision(ctx, req)
+	return user, u.t.apply(err)
+}
+
+func (u *transformingUsersClient) Impersonate(ctx context.Context, userID string) (*ImpersonationResult, error) {
+	result, err := u.next.Impersonate(ctx, userID)
+	return result, u.t.apply(err)
+}
+
+// transformingClientsClient applies the error transform to every ClientsClient method.
+type transformingClientsClient struct {
+	next ClientsClient
+	t    errorTransformer
+}
+
+func (c *transformingClientsClient) InternalID(ctx context.Context, clientID string) (string, error) {
+	id, err := c.next.InternalID(ctx, clientID)
+	return id, c.t.apply(err)
+}
+
+func (c *transformingClientsClient) ListRoles(ctx context.Context, clientInternalID string) ([]*Role, error) {
+	roles, err := c.next.ListRoles(ctx, clientInternalID)
+	return roles, c.t.apply(err)
+}
+
+func (c *transformingClientsClient) GetRole(ctx context.Context, clientInternalID, roleName string) (*Role, error) {
+	role, err := c.next.GetRole(ctx, clientInternalID, roleName)
+	return role, c.t.apply(err)
+}
+
+func (c *transformingClientsClient) ListProtocolMappers(ctx context.Context, clientInternalID string) ([]*ProtocolMapper, error) {
+	mappers, err := c.next.ListProtocolMappers(ctx, clientInternalID)
+	return mappers, c.t.apply(err)
+}
+
+func (c *transformingClientsClient) AddProtocolMapper(ctx context.Context, clientInternalID string, mapper ProtocolMapper) (string, error) {
+	id, err := c.next.AddProtocolMapper(ctx, clientInternalID, mapper)
+	return id, c.t.apply(err)
+}
+
+func (c *transformingClientsClient) DeleteProtocolMapper(ctx context.Context, clientInternalID, mapperID string) error {
+	return c.t.apply(c.next.DeleteProtocolMapper(ctx, clientInternalID, mapperID))
+}
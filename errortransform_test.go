@@ -0,0 +1,110 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDomainNotFound = errors.New("domain: not found")
+
+func TestWithErrorTransform(t *testing.T) {
+	t.Run("nil transform", func(t *testing.T) {
+		client := &Client{}
+		assert.Error(t, WithErrorTransform(nil)(client))
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin/realms/test-realm/groups/ok":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"ok"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var calls atomic.Int32
+	client := newTestClient(server.URL, WithErrorTransform(func(err error) error {
+		calls.Add(1)
+		if errors.Is(err, ErrGroupNotFound) {
+			return fmt.Errorf("%w: %w", errDomainNotFound, err)
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}))
+	ctx := context.Background()
+
+	t.Run("maps errors and preserves sentinels", func(t *testing.T) {
+		_, err := client.Groups.Get(ctx, "missing")
+		assert.ErrorIs(t, err, errDomainNotFound)
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+	})
+
+	t.Run("successful calls are not transformed", func(t *testing.T) {
+		calls.Store(0)
+		group, err := client.Groups.Get(ctx, "ok")
+		require.NoError(t, err)
+		assert.Equal(t, "ok", *group.ID)
+		assert.Equal(t, int32(0), calls.Load())
+	})
+
+	t.Run("nil result keeps the original error", func(t *testing.T) {
+		_, err := client.Users.Get(ctx, "missing")
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("iterator errors", func(t *testing.T) {
+		var iterErr error
+		for _, err := range client.Groups.IterateMembers(ctx, "missing", GroupMembersParams{}) {
+			iterErr = err
+		}
+		var apiErr *APIError
+		assert.ErrorAs(t, iterErr, &apiErr)
+	})
+
+	t.Run("applied once for composite methods", func(t *testing.T) {
+		members := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer members.Close()
+
+		calls.Store(0)
+		client := newTestClient(members.URL, WithErrorTransform(func(err error) error {
+			calls.Add(1)
+			return err
+		}))
+		_, _, err := client.Groups.ReconcileMembers(ctx, "g1", []string{"u1", "u2"})
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
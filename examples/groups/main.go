@@ -57,7 +57,7 @@ func main() {
 	fmt.Println("Fetching groups with subgroups...")
 
 	// Use a search term to filter groups (adjust based on your group names)
-	// For broader results, use a common prefix or empty string ""
+	// For all groups, use an empty string "" (sent as an empty search, which matches every group)
 	searchQuery := "test"
 
 	groups, err := client.Groups.ListWithSubGroups(ctx, searchQuery, false, 0, 100)
@@ -0,0 +1,16 @@
+module go.companyinfo.dev/keycloak/examples/logging
+
+go 1.24.0
+
+require go.companyinfo.dev/keycloak v0.0.0
+
+require (
+	github.com/coreos/go-oidc/v3 v3.16.0 // indirect
+	github.com/go-jose/go-jose/v4 v4.1.3 // indirect
+	github.com/go-resty/resty/v2 v2.16.5 // indirect
+	go.companyinfo.dev/ptr v0.1.0 // indirect
+	golang.org/x/net v0.46.0 // indirect
+	golang.org/x/oauth2 v0.32.0 // indirect
+)
+
+replace go.companyinfo.dev/keycloak => ../..
@@ -0,0 +1,77 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main demonstrates structured logging with the Keycloak client library.
+// This example wires the client to log/slog, so every request is logged with its
+// method, path, status and duration.
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+
+	keycloak "go.companyinfo.dev/keycloak"
+)
+
+func main() {
+	ctx := context.Background()
+
+	// Get configuration from environment variables
+	keycloakURL := os.Getenv("KEYCLOAK_URL")
+	if keycloakURL == "" {
+		keycloakURL = "https://keycloak.example.com"
+	}
+
+	realm := os.Getenv("KEYCLOAK_REALM")
+	if realm == "" {
+		realm = "master"
+	}
+
+	clientID := os.Getenv("KEYCLOAK_CLIENT_ID")
+	if clientID == "" {
+		clientID = "admin-cli"
+	}
+
+	clientSecret := os.Getenv("KEYCLOAK_CLIENT_SECRET")
+	if clientSecret == "" {
+		log.Fatal("KEYCLOAK_CLIENT_SECRET environment variable is required")
+	}
+
+	// Request events are logged at debug level, so enable it on the handler
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	client, err := keycloak.New(ctx, keycloak.Config{
+		URL:          keycloakURL,
+		Realm:        realm,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	}, keycloak.WithLogger(keycloak.SlogLogger(logger)))
+	if err != nil {
+		log.Fatalf("Failed to create Keycloak client: %v", err)
+	}
+
+	// Each call below produces an event such as:
+	// {"level":"DEBUG","msg":"keycloak request completed","method":"GET","path":"/admin/realms/master/groups","status":200,"duration":12345678}
+	count, err := client.Groups.Count(ctx, nil, nil)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to count groups", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	logger.InfoContext(ctx, "counted groups", slog.Int("count", count))
+}
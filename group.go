@@ -15,11 +15,23 @@
 package keycloak
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"path"
+	"io"
+	"iter"
+	"maps"
+	"net/http"
+	"net/url"
 	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-resty/resty/v2"
 	"go.companyinfo.dev/ptr"
@@ -28,19 +40,46 @@ import (
 var (
 	// ErrGroupNotFound is returned when a requested group cannot be found.
 	ErrGroupNotFound = errors.New("group not found")
+
+	// ErrAmbiguousAttribute is returned in strict attribute matching mode when the searched
+	// value was found only in a multi-value attribute. See WithStrictAttributeMatching.
+	ErrAmbiguousAttribute = errors.New("attribute has multiple values")
+
+	// ErrAttributeLimitExceeded is returned when group attributes exceed the limits
+	// configured with WithAttributeLimits. No request is sent in that case.
+	ErrAttributeLimitExceeded = errors.New("attribute limit exceeded")
+
+	// ErrSearchTooShort is returned when a non-empty group name search is shorter than the
+	// minimum configured with WithMinSearchLength. No request is sent in that case.
+	ErrSearchTooShort = errors.New("search term too short")
+
+	// ErrAmbiguousGroupName is returned by GetByName when several groups, e.g. subgroups of
+	// different parents, have the requested name.
+	ErrAmbiguousGroupName = errors.New("group name is ambiguous")
 )
 
 // GroupsClient provides methods for managing Keycloak groups.
 // It handles group CRUD operations, subgroup management, and group searches.
 type GroupsClient interface {
 	// Create creates a new group in Keycloak with the specified name and attributes.
-	// Returns the newly created group's ID.
+	// Returns the newly created group's ID. If a top-level group with the same name already
+	// exists, the returned APIError has status 409 and its ExistingID set.
 	Create(ctx context.Context, name string, attributes map[string][]string) (string, error)
 
+	// CreateWithID creates a top-level group keeping the ID of the given representation, e.g.
+	// when migrating groups between realms. Requires Keycloak 20 or later. Returns an error
+	// wrapping ErrUnsupportedServer if the server assigned a different ID.
+	CreateWithID(ctx context.Context, group Group) error
+
 	// Update updates an existing group with the provided group data.
 	// Note: This operation ignores the SubGroups field. Use CreateSubGroup to manage subgroups.
 	Update(ctx context.Context, updatedGroup Group) error
 
+	// RemoveAttributeValue removes a single value from an attribute of the group, deleting the
+	// attribute if no values remain. Other attributes and values are left untouched.
+	// Returns ErrGroupNotFound if the group does not exist.
+	RemoveAttributeValue(ctx context.Context, groupID, key, value string) error
+
 	// Delete deletes a group by its ID.
 	Delete(ctx context.Context, groupID string) error
 
@@ -53,12 +92,29 @@ type GroupsClient interface {
 	// hierarchy population, and subgroup counts.
 	ListWithParams(ctx context.Context, params SearchGroupParams) ([]*Group, error)
 
+	// ListNoCount retrieves groups like ListWithParams with subGroupsCount=false, so Keycloak
+	// skips counting the subgroups of every returned group. SubGroupCount is not set in results.
+	ListNoCount(ctx context.Context, params SearchGroupParams) ([]*Group, error)
+
 	// ListWithSubGroups retrieves groups including their subgroup hierarchies.
 	// This is a convenience method that automatically sets the Search parameter,
 	// which is required by Keycloak's API to include subgroups in the response.
 	// Use searchQuery to filter groups (use empty string "" or a broad term to match all groups).
+	// A group listed several times in the hierarchy is returned once.
 	ListWithSubGroups(ctx context.Context, searchQuery string, briefRepresentation bool, first, max int) ([]*Group, error)
 
+	// ListSorted retrieves groups like ListWithParams and sorts them client-side using less.
+	// If less is nil, groups are sorted by name. Sorting applies to the fetched page only.
+	ListSorted(ctx context.Context, params SearchGroupParams, less func(a, b *Group) bool) ([]*Group, error)
+
+	// ListTopLevel retrieves groups like ListWithParams and keeps only top-level groups
+	// (no parent). Filtering applies to the fetched page only.
+	ListTopLevel(ctx context.Context, params SearchGroupParams) ([]*Group, error)
+
+	// BuildAttributeIndex pages through all groups and returns a map from each value of the
+	// given attribute key to the group holding it, for repeated O(1) lookups.
+	BuildAttributeIndex(ctx context.Context, key string) (map[string]*Group, error)
+
 	// Count returns the total count of groups matching the search criteria.
 	Count(ctx context.Context, search *string, top *bool) (int, error)
 
@@ -69,25 +125,71 @@ type GroupsClient interface {
 	// Get retrieves a single group by its ID.
 	Get(ctx context.Context, groupID string) (*Group, error)
 
+	// GetRaw retrieves a single group by its ID, along with the raw JSON representation
+	// returned by Keycloak, for reading fields not modeled by Group.
+	GetRaw(ctx context.Context, groupID string) (*Group, json.RawMessage, error)
+
+	// GetByName retrieves the group with exactly the given name. Returns ErrGroupNotFound if
+	// there is none and ErrAmbiguousGroupName if several groups have the name.
+	GetByName(ctx context.Context, name string) (*Group, error)
+
+	// Exists reports whether a group with the given ID exists without decoding its representation.
+	Exists(ctx context.Context, groupID string) (bool, error)
+
+	// Ancestry returns the ancestors of a group, from its top-level group down to its parent.
+	// The ancestry of a top-level group is empty.
+	Ancestry(ctx context.Context, groupID string) ([]*Group, error)
+
+	// GetWithSubGroups retrieves a single group by its ID with its SubGroups field populated
+	// down to the given depth. A depth of 1 fetches direct children only, 0 fetches no children,
+	// and a negative depth fetches the entire subtree.
+	GetWithSubGroups(ctx context.Context, groupID string, depth int) (*Group, error)
+
 	// GetByAttribute searches for a group with the specified attribute key-value pair.
 	// Returns ErrGroupNotFound if no matching group is found.
+	// In strict matching mode, returns ErrAmbiguousAttribute if the value only appears in a multi-value attribute.
 	GetByAttribute(ctx context.Context, attribute *GroupAttribute) (*Group, error)
 
-	// ListSubGroups retrieves all direct child groups of the specified parent group.
+	// SearchByAnyAttribute returns the groups matching any of the attributes (OR semantics),
+	// deduplicated by ID. It sends one search per attribute.
+	SearchByAnyAttribute(ctx context.Context, attrs []GroupAttribute) ([]*Group, error)
+
+	// ListSubGroups retrieves all direct child groups of the specified parent group, paging
+	// through them with the client's page size; Keycloak returns only 10 children per request
+	// by default. Use ListSubGroupsPaginated for explicit control over paging.
+	// Returns an error wrapping ErrGroupNotFound if the parent group does not exist, or
+	// ErrPageLimitExceeded if the listing reaches the page limit.
 	ListSubGroups(ctx context.Context, groupID string) ([]*Group, error)
 
 	// ListSubGroupsPaginated retrieves a paginated list of subgroups with optional search filtering.
 	// Uses the /groups/{group-id}/children endpoint for server-side pagination and filtering.
+	// Returns an error wrapping ErrGroupNotFound if the parent group does not exist.
 	ListSubGroupsPaginated(ctx context.Context, groupID string, params SubGroupSearchParams) ([]*Group, error)
 
 	// CreateSubGroup creates a new subgroup under the specified parent group.
 	// If the group already exists, this will set/update its parent relationship.
 	// Returns the newly created subgroup's ID (or empty string if group already existed).
+	// If attributes are given for a relocated group, they are applied with a follow-up Update
+	// and the existing group's ID is returned.
+	// Returns an error wrapping ErrGroupNotFound if the parent group does not exist.
 	CreateSubGroup(ctx context.Context, groupID, name string, attributes map[string][]string) (string, error)
 
+	// Detach moves a subgroup to the top level of the realm, keeping its ID, attributes and children.
+	// Returns ErrGroupNotFound if the group does not exist.
+	Detach(ctx context.Context, groupID string) error
+
 	// GetSubGroupByAttribute searches for a subgroup with the specified attribute within a parent group.
 	GetSubGroupByAttribute(group Group, attribute GroupAttribute) (*Group, error)
 
+	// FindSubGroupsByAttribute searches the whole realm for subgroups (at any depth) with the
+	// specified attribute and returns them as a flat list with ParentID set.
+	// Top-level groups are never included. Returns an empty list if nothing matches.
+	FindSubGroupsByAttribute(ctx context.Context, attribute GroupAttribute) ([]*Group, error)
+
+	// ListWithAttribute returns all groups that have the attribute key, regardless of its
+	// values, including subgroups present in the listing. First and Max in params are ignored.
+	ListWithAttribute(ctx context.Context, key string, params SearchGroupParams) ([]*Group, error)
+
 	// GetSubGroupByID finds a subgroup by its ID within a parent group's children.
 	GetSubGroupByID(group Group, subGroupID string) (*Group, error)
 
@@ -95,6 +197,41 @@ type GroupsClient interface {
 	// Returns a filtered stream of users according to the query parameters.
 	ListMembers(ctx context.Context, groupID string, params GroupMembersParams) ([]*User, error)
 
+	// ListMembersWithRole lists members of the group like ListMembers and keeps those holding
+	// the realm role, directly or through groups and composite roles. It costs one request per
+	// listed member.
+	ListMembersWithRole(ctx context.Context, groupID, roleName string, params GroupMembersParams) ([]*User, error)
+
+	// ListMembersPage returns one page of members of the group together with the parameters
+	// for the next page.
+	ListMembersPage(ctx context.Context, groupID string, params GroupMembersParams) (*MemberPage, error)
+
+	// IterateMembers returns an iterator over all direct members of the group, fetching pages
+	// lazily until a short page is returned. Errors are yielded as the second value.
+	IterateMembers(ctx context.Context, groupID string, params GroupMembersParams) iter.Seq2[*User, error]
+
+	// SnapshotMembers writes the direct members of the group to w as JSON lines, page by page,
+	// starting at resume (from the beginning if nil). It returns the cursor to resume from after
+	// an error, and nil once all members have been written.
+	SnapshotMembers(ctx context.Context, groupID string, w io.Writer, resume *GroupMembersParams) (*GroupMembersParams, error)
+
+	// ReconcileMembers makes the desired user IDs the exact member set of the group, adding and
+	// removing members as needed. It returns the user IDs that were added and removed.
+	ReconcileMembers(ctx context.Context, groupID string, desired []string) (added, removed []string, err error)
+
+	// ReconcileTree makes the group named desired.Name under the parent group (top level if
+	// parentID is empty) and its subtree match desired, creating, updating and, with
+	// opts.Prune, deleting groups as needed. Groups are matched by name.
+	ReconcileTree(ctx context.Context, parentID string, desired *Group, opts ReconcileTreeOptions) error
+
+	// TagMatching adds the values to the attribute key of every group matching params,
+	// concurrently, and reports the outcome per group.
+	TagMatching(ctx context.Context, params SearchGroupParams, key string, values []string) ([]BatchResult, error)
+
+	// SubtreeSize returns the number of descendant groups of the group and the sum of the direct
+	// member counts of the group and all its descendants. This scans the whole subtree.
+	SubtreeSize(ctx context.Context, groupID string) (groups int, members int, err error)
+
 	// GetManagementPermissions returns whether client Authorization permissions have been initialized
 	// for this group and provides a reference.
 	GetManagementPermissions(ctx context.Context, groupID string) (*ManagementPermissionReference, error)
@@ -102,6 +239,26 @@ type GroupsClient interface {
 	// UpdateManagementPermissions enables or disables client Authorization permissions for this group
 	// and returns the updated permission reference.
 	UpdateManagementPermissions(ctx context.Context, groupID string, ref ManagementPermissionReference) (*ManagementPermissionReference, error)
+
+	// EnableManagementPermissionsAndWait enables client Authorization permissions for this group
+	// and polls until Keycloak reports the permission resource, or the timeout elapses.
+	EnableManagementPermissionsAndWait(ctx context.Context, groupID string, timeout time.Duration) (*ManagementPermissionReference, error)
+
+	// PermissionsEnabled reports whether client Authorization permissions are enabled for this group.
+	// An omitted Enabled field is reported as false.
+	PermissionsEnabled(ctx context.Context, groupID string) (bool, error)
+
+	// GetRoleMappings returns the realm and client roles mapped to the group in a single call.
+	// A group without mappings yields empty (non-nil) RoleMappings.
+	GetRoleMappings(ctx context.Context, groupID string) (*RoleMappings, error)
+
+	// AddDefaultGroup makes the group a default group of the realm, so new users join it.
+	// With opts.Idempotent set, a group that is already a default group is not an error.
+	AddDefaultGroup(ctx context.Context, groupID string, opts DefaultGroupOptions) error
+
+	// RemoveDefaultGroup removes the group from the default groups of the realm.
+	// With opts.Idempotent set, a group that is absent (404) is not an error.
+	RemoveDefaultGroup(ctx context.Context, groupID string, opts DefaultGroupOptions) error
 }
 
 // groupsClient implements the GroupsClient interface.
@@ -118,6 +275,10 @@ func newGroupsClient(client *Client) GroupsClient {
 
 // Create creates a new group in Keycloak with the specified name and attributes.
 func (g *groupsClient) Create(ctx context.Context, name string, attributes map[string][]string) (string, error) {
+	attributes = g.withDefaultAttributes(attributes)
+	if err := g.client.attrLimits.validate(attributes); err != nil {
+		return "", fmt.Errorf("unable to create group: %w", err)
+	}
 	group := Group{
 		Name:       &name,
 		Attributes: &attributes,
@@ -130,10 +291,145 @@ func (g *groupsClient) Create(ctx context.Context, name string, attributes map[s
 		return "", fmt.Errorf("unable to create group: %w", err)
 	}
 	if !resp.IsSuccess() {
-		return "", fmt.Errorf("unable to create group: %v", resp.Error())
+		apiErr := newAPIError(resp)
+		if apiErr.StatusCode == http.StatusConflict {
+			apiErr.ExistingID = g.findTopLevelID(ctx, name)
+		}
+		return "", fmt.Errorf("unable to create group: %w", apiErr)
+	}
+
+	id, err := createdID(resp, g.client.realm, "groups")
+	if err != nil {
+		return "", fmt.Errorf("unable to create group: %w", err)
+	}
+	return id, nil
+}
+
+// CreateWithID creates a top-level group with the ID, name, description and attributes of
+// group, so that references to the group survive a migration between realms. SubGroups are
+// ignored. Default attributes and attribute limits apply as for Create.
+//
+// The groups endpoint cannot be used for this: Keycloak treats a posted ID as a request to
+// move that existing group (see Detach). The group is therefore created through a partial
+// import (see PartialImport), which keeps imported IDs on Keycloak 20 and later; older servers
+// are rejected with ErrUnsupportedServer before anything is sent. If a group with the same name
+// exists, nothing is created and an APIError with status 409 is returned. Should the server
+// nevertheless assign its own ID, the group exists under that ID, and an error wrapping
+// ErrUnsupportedServer naming it is returned.
+func (g *groupsClient) CreateWithID(ctx context.Context, group Group) error {
+	if ptr.IsZero(group.ID) {
+		return fmt.Errorf("the ID of the group is required")
+	}
+	if ptr.IsZero(group.Name) {
+		return fmt.Errorf("the name of the group is required")
+	}
+
+	var attributes map[string][]string
+	if group.Attributes != nil {
+		attributes = *group.Attributes
+	}
+	attributes = g.withDefaultAttributes(attributes)
+	if err := g.client.attrLimits.validate(attributes); err != nil {
+		return fmt.Errorf("unable to create group: %w", err)
+	}
+	imported := &Group{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		Attributes:  &attributes,
+	}
+
+	result, err := g.client.PartialImport(ctx, PartialImportRequest{
+		IfResourceExists: PartialImportFail,
+		Groups:           []*Group{imported},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create group: %w", err)
+	}
+
+	for _, entry := range result.Results {
+		if entry == nil || entry.ResourceType != "GROUP" || entry.ResourceName != *group.Name {
+			continue
+		}
+		if entry.ID != "" && entry.ID != *group.ID {
+			return fmt.Errorf("unable to create group with ID %q: %w: the server assigned ID %q", *group.ID, ErrUnsupportedServer, entry.ID)
+		}
+		return nil
+	}
+	return fmt.Errorf("unable to create group with ID %q: no import result for group %q", *group.ID, *group.Name)
+}
+
+// findTopLevelID returns the ID of the top-level group with exactly the given name, so that a
+// conflicting Create can report it. The lookup is best-effort: it returns an empty string if
+// the group cannot be found or the lookup fails, leaving the original conflict error intact.
+func (g *groupsClient) findTopLevelID(ctx context.Context, name string) string {
+	groups, err := g.list(ctx, SearchGroupParams{
+		Search:              ptr.String(name),
+		Exact:               ptr.Bool(true),
+		BriefRepresentation: ptr.Bool(true),
+	})
+	if err != nil {
+		return ""
+	}
+
+	// Exact search also returns the parents of matching subgroups, so compare names
+	for _, group := range groups {
+		if group != nil && ptr.ToString(group.Name) == name && !ptr.IsZero(group.ID) {
+			return *group.ID
+		}
+	}
+	return ""
+}
+
+// withDefaultAttributes merges the client's default attributes into attributes, with the
+// caller's keys taking precedence. The caller's map is not modified.
+func (g *groupsClient) withDefaultAttributes(attributes map[string][]string) map[string][]string {
+	if len(g.client.defaultAttrs) == 0 {
+		return attributes
+	}
+
+	merged := make(map[string][]string, len(g.client.defaultAttrs)+len(attributes))
+	for key, values := range g.client.defaultAttrs {
+		merged[key] = append([]string(nil), values...)
+	}
+	for key, values := range attributes {
+		merged[key] = values
+	}
+	return merged
+}
+
+// attributeLimits holds the limits configured with WithAttributeLimits. Zero disables a limit.
+type attributeLimits struct {
+	maxKeys         int
+	maxValuesPerKey int
+	maxValueLen     int
+}
+
+// validate returns an error wrapping ErrAttributeLimitExceeded if attributes exceed any limit.
+// Keys are checked in sorted order so the reported violation is deterministic.
+func (l attributeLimits) validate(attributes map[string][]string) error {
+	if l.maxKeys > 0 && len(attributes) > l.maxKeys {
+		return fmt.Errorf("%w: %d attribute keys, at most %d allowed", ErrAttributeLimitExceeded, len(attributes), l.maxKeys)
+	}
+	if l.maxValuesPerKey == 0 && l.maxValueLen == 0 {
+		return nil
 	}
 
-	return getID(resp), nil
+	for _, key := range slices.Sorted(maps.Keys(attributes)) {
+		values := attributes[key]
+		if l.maxValuesPerKey > 0 && len(values) > l.maxValuesPerKey {
+			return fmt.Errorf("%w: attribute %q has %d values, at most %d allowed", ErrAttributeLimitExceeded, key, len(values), l.maxValuesPerKey)
+		}
+		if l.maxValueLen == 0 {
+			continue
+		}
+		for _, value := range values {
+			if n := utf8.RuneCountInString(value); n > l.maxValueLen {
+				return fmt.Errorf("%w: value of attribute %q has %d characters, at most %d allowed", ErrAttributeLimitExceeded, key, n, l.maxValueLen)
+			}
+		}
+	}
+	return nil
 }
 
 // Update updates an existing group with the provided group data.
@@ -142,6 +438,11 @@ func (g *groupsClient) Update(ctx context.Context, group Group) error {
 	if ptr.IsZero(group.ID) {
 		return fmt.Errorf("the ID of the group is required")
 	}
+	if group.Attributes != nil {
+		if err := g.client.attrLimits.validate(*group.Attributes); err != nil {
+			return fmt.Errorf("unable to update group: %w", err)
+		}
+	}
 
 	resp, err := g.getRequest(ctx).
 		SetBody(group).
@@ -150,12 +451,98 @@ func (g *groupsClient) Update(ctx context.Context, group Group) error {
 		return fmt.Errorf("unable to update group: %w", err)
 	}
 	if !resp.IsSuccess() {
-		return fmt.Errorf("unable to update group: %v", resp.Error())
+		return fmt.Errorf("unable to update group: %w", newAPIError(resp))
 	}
 
 	return nil
 }
 
+// attributeUpdateAttempts is the number of read-modify-write attempts RemoveAttributeValue
+// makes before giving up on concurrent modifications.
+const attributeUpdateAttempts = 3
+
+// RemoveAttributeValue removes every occurrence of value from the attribute key of the group,
+// deleting the key if it becomes empty. It is a read-modify-write: the group is fetched,
+// modified and written back with Update. If Keycloak answers 409 Conflict (a concurrent
+// modification), the whole cycle is retried a few times. Removing a value the group does not
+// have is a no-op and sends no update.
+func (g *groupsClient) RemoveAttributeValue(ctx context.Context, groupID, key, value string) error {
+	if groupID == "" {
+		return fmt.Errorf("groupID parameter cannot be empty")
+	}
+	if key == "" {
+		return fmt.Errorf("key parameter cannot be empty")
+	}
+
+	return g.updateAttributes(ctx, groupID, "remove attribute value", func(attributes map[string][]string) bool {
+		return removeAttributeValue(attributes, key, value)
+	})
+}
+
+// updateAttributes applies modify to the attributes of the group in a read-modify-write cycle:
+// the group is fetched, modified and written back with Update, retrying the whole cycle a few
+// times on 409 Conflict (a concurrent modification). If modify reports no change, no update is
+// sent. The action names the operation in the error returned after the last attempt.
+func (g *groupsClient) updateAttributes(ctx context.Context, groupID, action string, modify func(attributes map[string][]string) bool) error {
+	var err error
+	for attempt := 0; attempt < attributeUpdateAttempts; attempt++ {
+		var group *Group
+		group, err = g.Get(ctx, groupID)
+		if err != nil {
+			return err
+		}
+
+		if group.Attributes == nil {
+			group.Attributes = &map[string][]string{}
+		}
+		if !modify(*group.Attributes) {
+			return nil
+		}
+
+		err = g.Update(ctx, *group)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+			return err
+		}
+	}
+
+	return fmt.Errorf("unable to %s after %d attempts: %w", action, attributeUpdateAttempts, err)
+}
+
+// mergeAttributeValues appends the values missing from attributes[key], keeping the existing
+// values and their order. It reports whether attributes was modified.
+func mergeAttributeValues(attributes map[string][]string, key string, values []string) bool {
+	current := attributes[key]
+	merged := current
+	for _, value := range values {
+		if !slices.Contains(merged, value) {
+			merged = append(merged, value)
+		}
+	}
+	if len(merged) == len(current) {
+		return false
+	}
+	attributes[key] = merged
+	return true
+}
+
+// removeAttributeValue removes every occurrence of value from attributes[key], deleting the key
+// if no values remain. It reports whether attributes was modified.
+func removeAttributeValue(attributes map[string][]string, key, value string) bool {
+	values, ok := attributes[key]
+	if !ok || !slices.Contains(values, value) {
+		return false
+	}
+
+	values = slices.DeleteFunc(values, func(v string) bool { return v == value })
+	if len(values) == 0 {
+		delete(attributes, key)
+	} else {
+		attributes[key] = values
+	}
+	return true
+}
+
 // List retrieves all groups matching the optional search criteria.
 func (g *groupsClient) List(ctx context.Context, search *string, briefRepresentation bool) ([]*Group, error) {
 	return g.list(ctx, SearchGroupParams{
@@ -179,6 +566,18 @@ func (g *groupsClient) ListWithParams(ctx context.Context, params SearchGroupPar
 	return g.list(ctx, params)
 }
 
+// ListNoCount retrieves groups like ListWithParams, with SubGroupsCount forced to false.
+//
+// Keycloak counts the direct subgroups of every returned group by default, one extra query
+// per group, which dominates listing time in large realms; the counts have also been
+// inaccurate on some versions. Use this method when SubGroupCount is not needed: it is not
+// set (nil) in the results, so callers relying on it, such as code skipping groups without
+// children, must list children instead.
+func (g *groupsClient) ListNoCount(ctx context.Context, params SearchGroupParams) ([]*Group, error) {
+	params.SubGroupsCount = ptr.Bool(false)
+	return g.list(ctx, params)
+}
+
 // ListWithSubGroups retrieves groups including their subgroup hierarchies.
 // This is a convenience method that automatically sets the Search parameter,
 // which is required by Keycloak's API to include subgroups in the response.
@@ -187,48 +586,344 @@ func (g *groupsClient) ListWithParams(ctx context.Context, params SearchGroupPar
 // parameter is provided. This method uses the provided searchQuery to enable
 // subgroup population.
 //
+// An empty (or whitespace-only) searchQuery is sent as an empty search parameter, which
+// Keycloak treats as matching every group while still populating SubGroups. Non-empty
+// terms shorter than WithMinSearchLength are rejected with ErrSearchTooShort.
+//
 // Parameters:
-//   - searchQuery: Search term to filter groups (use empty string "" or a broad term to match all groups)
+//   - searchQuery: Search term to filter groups (use empty string "" to match all groups)
 //   - briefRepresentation: If true, return groups without detailed attributes
 //   - first: Pagination offset
 //   - max: Maximum number of results
 //
 // Returns groups matching the search with their SubGroups field populated.
+//
+// Depending on how the search matches the hierarchy, Keycloak may list a group both as a
+// result and nested under a parent. Such duplicates are removed by ID, so every group appears
+// once in the flattened result (see dedupeGroupTree).
 func (g *groupsClient) ListWithSubGroups(ctx context.Context, searchQuery string, briefRepresentation bool, first, max int) ([]*Group, error) {
+	// Keycloak trims the term; doing it here makes " " match all groups like "" does
+	searchQuery = strings.TrimSpace(searchQuery)
 	populateHierarchy := true
-	return g.list(ctx, SearchGroupParams{
+	groups, err := g.list(ctx, SearchGroupParams{
 		Search:              &searchQuery,
 		BriefRepresentation: &briefRepresentation,
 		PopulateHierarchy:   &populateHierarchy,
 		First:               &first,
 		Max:                 &max,
 	})
+	if err != nil {
+		return nil, err
+	}
+	return dedupeGroupTree(groups), nil
+}
+
+// dedupeGroupTree removes repeated occurrences of the same group ID from a group tree. Each
+// duplicated group is kept once: at its first nested position, so that the hierarchy stays
+// intact, or at its first position if it only appears at the top level. The occurrence kept
+// there is the most complete one, the one with the most descendants and then the most
+// attributes. Groups without ID are left alone.
+func dedupeGroupTree(groups []*Group) []*Group {
+	type occurrence struct {
+		slot   **Group
+		nested bool
+	}
+	occurrences := map[string][]occurrence{}
+	var ids []string
+
+	var collect func(groups []*Group, nested bool)
+	collect = func(groups []*Group, nested bool) {
+		for i, group := range groups {
+			if group == nil {
+				continue
+			}
+			if id := ptr.ToString(group.ID); id != "" {
+				if _, seen := occurrences[id]; !seen {
+					ids = append(ids, id)
+				}
+				occurrences[id] = append(occurrences[id], occurrence{slot: &groups[i], nested: nested})
+			}
+			if group.SubGroups != nil {
+				collect(*group.SubGroups, true)
+			}
+		}
+	}
+	collect(groups, false)
+
+	dropped := map[**Group]bool{}
+	for _, id := range ids {
+		found := occurrences[id]
+		if len(found) < 2 {
+			continue
+		}
+		keep, best := found[0].slot, *found[0].slot
+		nestedKept := found[0].nested
+		for _, o := range found[1:] {
+			if o.nested && !nestedKept {
+				keep, nestedKept = o.slot, true
+			}
+			if groupCompleteness(*o.slot).greater(groupCompleteness(best)) {
+				best = *o.slot
+			}
+		}
+		for _, o := range found {
+			if o.slot != keep {
+				dropped[o.slot] = true
+			}
+		}
+		*keep = best
+	}
+	if len(dropped) == 0 {
+		return groups
+	}
+
+	var filter func(groups []*Group) []*Group
+	filter = func(groups []*Group) []*Group {
+		kept := make([]*Group, 0, len(groups))
+		for i, group := range groups {
+			if dropped[&groups[i]] {
+				continue
+			}
+			if group != nil && group.SubGroups != nil {
+				subGroups := filter(*group.SubGroups)
+				group.SubGroups = &subGroups
+			}
+			kept = append(kept, group)
+		}
+		return kept
+	}
+	return filter(groups)
+}
+
+// completeness ranks group representations by how much of the group they describe.
+type completeness struct {
+	descendants int
+	attributes  int
+}
+
+// greater reports whether c describes more than other.
+func (c completeness) greater(other completeness) bool {
+	if c.descendants != other.descendants {
+		return c.descendants > other.descendants
+	}
+	return c.attributes > other.attributes
+}
+
+// groupCompleteness returns the completeness of a group representation.
+func groupCompleteness(group *Group) completeness {
+	var c completeness
+	if group.Attributes != nil {
+		c.attributes = len(*group.Attributes)
+	}
+	var count func(group *Group)
+	count = func(group *Group) {
+		if group.SubGroups == nil {
+			return
+		}
+		for _, subGroup := range *group.SubGroups {
+			if subGroup != nil {
+				c.descendants++
+				count(subGroup)
+			}
+		}
+	}
+	count(group)
+	return c
+}
+
+// ListTopLevel retrieves groups like ListWithParams and keeps only the top-level groups.
+// Keycloak has no parameter restricting the list to top-level groups (Top only exists for
+// Count), so groups are filtered client-side: a group is top-level if it has no ParentID and
+// its Path, when present, has a single segment. Filtering applies to the fetched page only,
+// so a page may hold fewer groups than params.Max.
+func (g *groupsClient) ListTopLevel(ctx context.Context, params SearchGroupParams) ([]*Group, error) {
+	groups, err := g.list(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	topLevel := []*Group{}
+	for _, group := range groups {
+		if isTopLevel(group) {
+			topLevel = append(topLevel, group)
+		}
+	}
+	return topLevel, nil
+}
+
+// isTopLevel reports whether group has no parent, judging by its ParentID and Path.
+// Slashes escaped as "~/" (Keycloak's escaping of slashes in group names) are not separators.
+func isTopLevel(group *Group) bool {
+	if group == nil || !ptr.IsZero(group.ParentID) {
+		return false
+	}
+	if group.Path == nil {
+		return true
+	}
+	path := strings.ReplaceAll(strings.Trim(*group.Path, "/"), "~/", "")
+	return !strings.Contains(path, "/")
+}
+
+// ListSorted retrieves groups like ListWithParams and sorts them client-side.
+// Keycloak has no server-side sorting for groups, so the order is determined after fetching.
+// The sort is stable, so groups that compare equal keep Keycloak's order.
+//
+// Note: sorting applies to the fetched page only. When params.First/Max paginate the result,
+// each page is sorted independently; fetch all pages first to sort an entire realm.
+//
+// Use GroupsByName or GroupsByPath as less, or provide a custom comparator.
+// If less is nil, GroupsByName is used.
+func (g *groupsClient) ListSorted(ctx context.Context, params SearchGroupParams, less func(a, b *Group) bool) ([]*Group, error) {
+	groups, err := g.list(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if less == nil {
+		less = GroupsByName
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return less(groups[i], groups[j])
+	})
+
+	return groups, nil
+}
+
+// GroupsByName reports whether group a sorts before group b by name.
+// Nil groups and groups without a name sort first.
+func GroupsByName(a, b *Group) bool {
+	return groupField(a, func(g *Group) *string { return g.Name }) < groupField(b, func(g *Group) *string { return g.Name })
+}
+
+// GroupsByPath reports whether group a sorts before group b by hierarchy path.
+// Nil groups and groups without a path sort first.
+func GroupsByPath(a, b *Group) bool {
+	return groupField(a, func(g *Group) *string { return g.Path }) < groupField(b, func(g *Group) *string { return g.Path })
+}
+
+// groupField returns the value of a string field of a possibly nil group.
+func groupField(group *Group, field func(*Group) *string) string {
+	if group == nil {
+		return ""
+	}
+	return ptr.ToString(field(group))
+}
+
+// BuildAttributeIndex pages through all groups of the realm and returns a map from each
+// value of the attribute key to the group holding it. Services that look up many groups
+// by the same attribute can build the index once instead of calling GetByAttribute per value.
+//
+// The index is a point-in-time snapshot: groups created, updated or deleted afterwards are
+// not reflected, so rebuild it when freshness matters. Every value of a multi-value attribute
+// is indexed. If several groups share a value, the first group encountered is kept.
+// Subgroups are included when Keycloak returns them nested in the listing.
+func (g *groupsClient) BuildAttributeIndex(ctx context.Context, key string) (map[string]*Group, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key parameter cannot be empty")
+	}
+
+	groups, err := g.listAll(ctx, SearchGroupParams{BriefRepresentation: ptr.Bool(false)})
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*Group)
+	var add func(groups []*Group)
+	add = func(groups []*Group) {
+		for _, group := range groups {
+			if group == nil {
+				continue
+			}
+			if group.Attributes != nil {
+				for _, value := range (*group.Attributes)[key] {
+					if _, exists := index[value]; !exists {
+						index[value] = group
+					}
+				}
+			}
+			if group.SubGroups != nil {
+				add(*group.SubGroups)
+			}
+		}
+	}
+	add(groups)
+
+	return index, nil
+}
+
+// listAll pages through all groups matching params using the client's page size.
+// Any First/Max values in params are ignored. Returns ErrPageLimitExceeded if the
+// client's page limit is reached before a short page is seen.
+func (g *groupsClient) listAll(ctx context.Context, params SearchGroupParams) ([]*Group, error) {
+	return listGroupPages(g.client, "unable to list all groups", func(first, max int) ([]*Group, error) {
+		params.First = ptr.Int(first)
+		params.Max = ptr.Int(max)
+		return g.list(ctx, params)
+	})
+}
+
+// listGroupPages calls list with successive pages of the client's page size until a short
+// page is returned, and returns all groups listed. Returns ErrPageLimitExceeded, prefixed
+// with errPrefix, if the client's page limit is reached first.
+func listGroupPages(c *Client, errPrefix string, list func(first, max int) ([]*Group, error)) ([]*Group, error) {
+	var result []*Group
+	for page := 0; ; page++ {
+		if page >= c.maxPages {
+			return nil, fmt.Errorf("%s: %w (%d pages of %d)", errPrefix, ErrPageLimitExceeded, c.maxPages, c.pageSize)
+		}
+		groups, err := list(page*c.pageSize, c.pageSize)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, groups...)
+		if len(groups) < c.pageSize {
+			return result, nil
+		}
+	}
 }
 
 // list is an internal method that handles group listing with all optional parameters.
 func (g *groupsClient) list(ctx context.Context, params SearchGroupParams) ([]*Group, error) {
 	var result []*Group
 
+	if err := g.checkSearchLength(params.Search); err != nil {
+		return nil, err
+	}
+	if params.BriefRepresentation == nil {
+		params.BriefRepresentation = g.client.defaults.GroupsBrief
+	}
+
 	queryParams, err := mapper(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initiate search parameters of groups: %w", err)
 	}
 
-	resp, err := g.getRequest(ctx).
-		SetResult(&result).
-		SetQueryParams(queryParams).
-		Execute(endpointGroupsList.Method, g.client.buildURL(endpointGroupsList, nil))
+	resp, err := g.client.executeList(g.getRequest(ctx).SetQueryParams(queryParams), &result,
+		endpointGroupsList.Method, g.client.buildURL(endpointGroupsList, nil))
 	if err != nil {
 		return nil, fmt.Errorf("unable to list groups: %w", err)
 	}
 
 	if !resp.IsSuccess() {
-		return nil, fmt.Errorf("unable to list groups: %v", resp.Error())
+		return nil, fmt.Errorf("unable to list groups: %w", newAPIError(resp))
 	}
 
 	return result, nil
 }
 
+// checkSearchLength returns ErrSearchTooShort if search is a non-empty term shorter than the
+// configured minimum. Empty searches match all groups and are always allowed.
+func (g *groupsClient) checkSearchLength(search *string) error {
+	if search == nil || g.client.minSearchLen == 0 {
+		return nil
+	}
+	term := strings.TrimSpace(*search)
+	if n := utf8.RuneCountInString(term); n > 0 && n < g.client.minSearchLen {
+		return fmt.Errorf("%w: %q has %d characters, at least %d required", ErrSearchTooShort, term, n, g.client.minSearchLen)
+	}
+	return nil
+}
+
 // Count returns the total count of groups matching the search criteria.
 func (g *groupsClient) Count(ctx context.Context, search *string, top *bool) (int, error) {
 	var result CountGroupResponse
@@ -250,7 +945,7 @@ func (g *groupsClient) Count(ctx context.Context, search *string, top *bool) (in
 	}
 
 	if !resp.IsSuccess() {
-		return 0, fmt.Errorf("unable to count groups: %v", resp.Error())
+		return 0, fmt.Errorf("unable to count groups: %w", newAPIError(resp))
 	}
 
 	return result.Count, nil
@@ -276,34 +971,278 @@ func (g *groupsClient) Get(ctx context.Context, groupID string) (*Group, error)
 		if resp.StatusCode() == 404 {
 			return nil, ErrGroupNotFound
 		}
-		return nil, fmt.Errorf("unable to get group: %v", resp.Error())
+		return nil, fmt.Errorf("unable to get group: %w", newAPIError(resp))
 	}
 
 	return &result, nil
 }
 
-// GetByAttribute searches for a group with the specified attribute key-value pair.
-// This method uses Keycloak's server-side attribute search (q parameter) for efficient filtering.
-// Only groups matching the exact attribute key-value pair are returned from the server.
+// GetRaw retrieves a single group by its ID, like Get, and also returns the raw JSON
+// representation, so callers can read fields not (yet) modeled by Group without resorting
+// to a hand-built request. The response is decoded once; the raw bytes are the body as
+// received.
 //
-// Performance: This method uses server-side filtering, making it efficient even for realms
-// with thousands of groups. The search is performed by Keycloak using the 'q' query parameter.
+// Example:
 //
-// Returns ErrGroupNotFound if no matching group is found.
-func (g *groupsClient) GetByAttribute(ctx context.Context, attribute *GroupAttribute) (*Group, error) {
-	if attribute == nil {
-		return nil, errors.New("attribute parameter cannot be nil")
+//	group, raw, err := client.Groups.GetRaw(ctx, groupID)
+//	var extra struct {
+//	    Description string `json:"description"`
+//	}
+//	err = json.Unmarshal(raw, &extra)
+func (g *groupsClient) GetRaw(ctx context.Context, groupID string) (*Group, json.RawMessage, error) {
+	if groupID == "" {
+		return nil, nil, fmt.Errorf("groupID parameter cannot be empty")
 	}
 
-	// Use server-side filtering with the q parameter
-	// Format: "key:value"
-	query := fmt.Sprintf("%s:%s", attribute.Key, attribute.Value)
-	params := SearchGroupParams{
-		Q:                   ptr.String(query),
-		BriefRepresentation: ptr.Bool(false),
-	}
+	var result Group
 
-	groups, err := g.list(ctx, params)
+	resp, err := g.getRequest(ctx).
+		SetResult(&result).
+		Execute(endpointGroupGet.Method, g.client.buildURL(endpointGroupGet, map[string]string{"groupID": groupID}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get group: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, nil, ErrGroupNotFound
+		}
+		return nil, nil, fmt.Errorf("unable to get group: %w", newAPIError(resp))
+	}
+
+	return &result, json.RawMessage(slices.Clone(resp.Body())), nil
+}
+
+// Exists reports whether a group with the given ID exists.
+// It issues the same GET as Get (Keycloak does not support HEAD on groups) but does not
+// decode the representation. Returns false on 404, true on any 2xx, and an error otherwise.
+func (g *groupsClient) Exists(ctx context.Context, groupID string) (bool, error) {
+	if groupID == "" {
+		return false, fmt.Errorf("groupID parameter cannot be empty")
+	}
+
+	resp, err := g.getRequest(ctx).
+		Execute(endpointGroupGet.Method, g.client.buildURL(endpointGroupGet, map[string]string{"groupID": groupID}))
+	if err != nil {
+		return false, fmt.Errorf("unable to check group existence: %w", err)
+	}
+
+	if resp.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	if !resp.IsSuccess() {
+		return false, fmt.Errorf("unable to check group existence: %w", newAPIError(resp))
+	}
+
+	return true, nil
+}
+
+// GetByName retrieves the group with exactly the given (case-sensitive) name, replacing the
+// exact ListWithParams search callers would otherwise index themselves. Subgroups are
+// considered too: Keycloak answers an exact search with the hierarchies containing the
+// matches, which are searched as a whole. Returns ErrGroupNotFound if no group has the name,
+// and an error wrapping ErrAmbiguousGroupName, listing the paths of the matches, if several
+// groups do (names are only unique among siblings).
+func (g *groupsClient) GetByName(ctx context.Context, name string) (*Group, error) {
+	if name == "" {
+		return nil, fmt.Errorf("name parameter cannot be empty")
+	}
+
+	params := SearchGroupParams{
+		Search:              ptr.String(name),
+		Exact:               ptr.Bool(true),
+		BriefRepresentation: ptr.Bool(false),
+	}
+	groups, err := g.listAll(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := matchingGroups(groups, params)
+	switch len(matches) {
+	case 0:
+		return nil, ErrGroupNotFound
+	case 1:
+		return matches[0], nil
+	}
+	paths := make([]string, len(matches))
+	for i, group := range matches {
+		paths[i] = cmp.Or(ptr.ToString(group.Path), *group.ID)
+	}
+	return nil, fmt.Errorf("%w: %d groups named %q (%s)", ErrAmbiguousGroupName, len(matches), name, strings.Join(paths, ", "))
+}
+
+// Ancestry returns the ancestors of a group, from its top-level group down to its parent, e.g.
+// for breadcrumbs. The ancestry of a top-level group is empty.
+//
+// Ancestors are fetched one by one following ParentID, costing one request per level. Keycloak
+// versions that don't report ParentID (before 23) are handled by resolving the segments of the
+// group's Path by name instead, starting from the top-level group.
+func (g *groupsClient) Ancestry(ctx context.Context, groupID string) ([]*Group, error) {
+	group, err := g.Get(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if ptr.IsZero(group.ParentID) {
+		if isTopLevel(group) {
+			return []*Group{}, nil
+		}
+		return g.ancestryByPath(ctx, *group.Path)
+	}
+
+	ancestors := []*Group{}
+	seen := map[string]bool{groupID: true}
+	for parentID := ptr.ToString(group.ParentID); parentID != ""; parentID = ptr.ToString(group.ParentID) {
+		if seen[parentID] {
+			return nil, fmt.Errorf("unable to resolve ancestry of group %s: cycle at group %s", groupID, parentID)
+		}
+		seen[parentID] = true
+
+		group, err = g.Get(ctx, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve ancestry of group %s: %w", groupID, err)
+		}
+		ancestors = append(ancestors, group)
+	}
+	slices.Reverse(ancestors)
+	return ancestors, nil
+}
+
+// ancestryByPath resolves the ancestors named by a group path, from the top-level group down.
+func (g *groupsClient) ancestryByPath(ctx context.Context, path string) ([]*Group, error) {
+	names := splitGroupPath(path)
+	ancestors := make([]*Group, 0, len(names)-1)
+	for _, name := range names[:len(names)-1] {
+		var candidates []*Group
+		var err error
+		if len(ancestors) == 0 {
+			candidates, err = g.list(ctx, SearchGroupParams{
+				Search:              &name,
+				Exact:               ptr.Bool(true),
+				BriefRepresentation: ptr.Bool(false),
+			})
+		} else {
+			candidates, err = g.ListSubGroupsPaginated(ctx, *ancestors[len(ancestors)-1].ID, SubGroupSearchParams{
+				Search:              &name,
+				Exact:               ptr.Bool(true),
+				BriefRepresentation: ptr.Bool(false),
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve ancestor %q of %s: %w", name, path, err)
+		}
+
+		ancestor := groupNamed(candidates, name)
+		if ancestor == nil {
+			return nil, fmt.Errorf("unable to resolve ancestor %q of %s: %w", name, path, ErrGroupNotFound)
+		}
+		// Search results carry the matching descendants, which are not part of the ancestry
+		ancestor.SubGroups = nil
+		ancestors = append(ancestors, ancestor)
+	}
+	return ancestors, nil
+}
+
+// splitGroupPath splits a group path into group names. Slashes escaped as "~/" are part of
+// a name rather than separators.
+func splitGroupPath(path string) []string {
+	var names []string
+	var name strings.Builder
+	path = strings.Trim(path, "/")
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '~' && i+1 < len(path) && path[i+1] == '/':
+			name.WriteByte('/')
+			i++
+		case path[i] == '/':
+			names = append(names, name.String())
+			name.Reset()
+		default:
+			name.WriteByte(path[i])
+		}
+	}
+	return append(names, name.String())
+}
+
+// GetWithSubGroups retrieves a single group by its ID together with its subtree.
+// Keycloak only populates SubGroups in list responses when a search or q parameter is set,
+// so this method combines Get with recursive calls to the children endpoint instead.
+//
+// The depth parameter limits how many levels of descendants are fetched:
+//   - depth < 0: fetch the entire subtree
+//   - depth == 0: fetch the group only (SubGroups is left empty)
+//   - depth == 1: fetch direct children only, and so on
+//
+// Each level costs one request per group, so prefer a small depth for large hierarchies.
+func (g *groupsClient) GetWithSubGroups(ctx context.Context, groupID string, depth int) (*Group, error) {
+	group, err := g.Get(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.populateSubGroups(ctx, group, depth); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// populateSubGroups recursively fills the SubGroups field of the given group
+// using the children endpoint, stopping when depth reaches zero.
+func (g *groupsClient) populateSubGroups(ctx context.Context, group *Group, depth int) error {
+	subGroups := []*Group{}
+	group.SubGroups = &subGroups
+	if depth == 0 {
+		return nil
+	}
+
+	children, err := g.ListSubGroups(ctx, *group.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if child == nil || child.ID == nil {
+			continue
+		}
+		// Skip the extra round trip when Keycloak already told us there is nothing below
+		if child.SubGroupCount != nil && *child.SubGroupCount == 0 {
+			empty := []*Group{}
+			child.SubGroups = &empty
+		} else if err := g.populateSubGroups(ctx, child, depth-1); err != nil {
+			return err
+		}
+		subGroups = append(subGroups, child)
+	}
+	group.SubGroups = &subGroups
+
+	return nil
+}
+
+// GetByAttribute searches for a group with the specified attribute key-value pair.
+// This method uses Keycloak's server-side attribute search (q parameter) for efficient filtering.
+// Only groups matching the exact attribute key-value pair are returned from the server.
+//
+// Performance: This method uses server-side filtering, making it efficient even for realms
+// with thousands of groups. The search is performed by Keycloak using the 'q' query parameter.
+//
+// Returns ErrGroupNotFound if no matching group is found. When the client is created with
+// WithStrictAttributeMatching(true), only single-value attributes match and ErrAmbiguousAttribute
+// is returned if the value was only found among multiple values.
+func (g *groupsClient) GetByAttribute(ctx context.Context, attribute *GroupAttribute) (*Group, error) {
+	if attribute == nil {
+		return nil, errors.New("attribute parameter cannot be nil")
+	}
+
+	// Use server-side filtering with the q parameter
+	// Format: "key:value"
+	query := fmt.Sprintf("%s:%s", attribute.Key, attribute.Value)
+	params := SearchGroupParams{
+		Q:                   ptr.String(query),
+		BriefRepresentation: ptr.Bool(false),
+	}
+
+	groups, err := g.list(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -315,22 +1254,204 @@ func (g *groupsClient) GetByAttribute(ctx context.Context, attribute *GroupAttri
 
 	// Keycloak's q parameter should return exact matches, but let's verify
 	// to ensure we return the correct group if multiple groups are returned
+	return matchGroupByAttribute(groups, *attribute, g.client.strictAttrs)
+}
+
+// SearchByAnyAttribute returns the groups matching any of the attributes. Keycloak's q
+// parameter combines several attributes with AND and has no OR operator, so this method sends
+// one q search per attribute concurrently, paging through each, and returns the union of the
+// results deduplicated by group ID. Groups are ordered by the first attribute that matched
+// them, then as Keycloak returned them.
+//
+// Each attribute costs at least one request, all in flight at once; use
+// WithMaxConcurrentRequests to bound them. As with ListWithParams, the results are what the q
+// search returns, i.e. top-level groups. If any search fails, the first error (in attribute
+// order) is returned.
+func (g *groupsClient) SearchByAnyAttribute(ctx context.Context, attrs []GroupAttribute) ([]*Group, error) {
+	if len(attrs) == 0 {
+		return nil, errors.New("attrs parameter cannot be empty")
+	}
+	for _, attribute := range attrs {
+		if attribute.Key == "" {
+			return nil, errors.New("attribute key cannot be empty")
+		}
+	}
+
+	results := make([][]*Group, len(attrs))
+	errs := make([]error, len(attrs))
+	var wg sync.WaitGroup
+	for i, attribute := range attrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = g.listAll(ctx, SearchGroupParams{
+				Q:                   ptr.String(fmt.Sprintf("%s:%s", attribute.Key, attribute.Value)),
+				BriefRepresentation: ptr.Bool(false),
+			})
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	union := []*Group{}
+	seen := make(map[string]bool)
+	for _, groups := range results {
+		for _, group := range groups {
+			if group == nil {
+				continue
+			}
+			if !ptr.IsZero(group.ID) {
+				if seen[*group.ID] {
+					continue
+				}
+				seen[*group.ID] = true
+			}
+			union = append(union, group)
+		}
+	}
+	return union, nil
+}
+
+// FindSubGroupsByAttribute searches the whole realm for subgroups with the specified attribute.
+//
+// Keycloak's q parameter returns the top-level groups whose hierarchy contains a match rather
+// than the matching subgroups themselves. This method pages through those results with
+// populateHierarchy=true, walks the returned trees and extracts the subgroups that actually
+// hold the attribute, in depth-first order. ParentID is filled in from the tree when Keycloak
+// omits it. Matching follows GetByAttribute: with WithStrictAttributeMatching(true) only
+// single-value attributes match.
+func (g *groupsClient) FindSubGroupsByAttribute(ctx context.Context, attribute GroupAttribute) ([]*Group, error) {
+	if attribute.Key == "" {
+		return nil, errors.New("attribute key cannot be empty")
+	}
+
+	groups, err := g.listAll(ctx, SearchGroupParams{
+		Q:                   ptr.String(fmt.Sprintf("%s:%s", attribute.Key, attribute.Value)),
+		BriefRepresentation: ptr.Bool(false),
+		PopulateHierarchy:   ptr.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return collectSubGroupsByAttribute(groups, attribute, g.client.strictAttrs), nil
+}
+
+// ListWithAttribute returns all groups that have the attribute key, whatever its values, in
+// depth-first order with ParentID filled in from the tree when Keycloak omits it. All pages
+// are fetched; First and Max in params are ignored.
+//
+// Keycloak matches q values literally and has no presence search, so by default every group
+// matching params is listed and filtered client-side; subgroups are only seen when Keycloak
+// includes them in the listing (e.g. when params.Search is set). With
+// WithAttributeWildcardSearch(true) the server is asked with q=key:* instead and only the
+// returned hierarchies are filtered.
+func (g *groupsClient) ListWithAttribute(ctx context.Context, key string, params SearchGroupParams) ([]*Group, error) {
+	if key == "" {
+		return nil, errors.New("key parameter cannot be empty")
+	}
+
+	params.BriefRepresentation = ptr.Bool(false)
+	if g.client.attrWildcard {
+		q := key + ":*"
+		if !ptr.IsZero(params.Q) {
+			q = *params.Q + " " + q
+		}
+		params.Q = ptr.String(q)
+	}
+
+	groups, err := g.listAll(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectGroupsWithKey(groups, key), nil
+}
+
+// collectGroupsWithKey walks groups and their subgroup trees depth-first and returns every
+// group holding the attribute key, setting ParentID from the tree if missing. The result is
+// never nil.
+func collectGroupsWithKey(groups []*Group, key string) []*Group {
+	result := []*Group{}
+
+	var walk func(group *Group)
+	walk = func(group *Group) {
+		if group.Attributes != nil {
+			if _, ok := (*group.Attributes)[key]; ok {
+				result = append(result, group)
+			}
+		}
+		if group.SubGroups == nil {
+			return
+		}
+		for _, subGroup := range *group.SubGroups {
+			if subGroup == nil {
+				continue
+			}
+			if ptr.IsZero(subGroup.ParentID) && group.ID != nil {
+				subGroup.ParentID = ptr.String(*group.ID)
+			}
+			walk(subGroup)
+		}
+	}
 	for _, group := range groups {
-		if group == nil || group.Attributes == nil {
-			continue
+		if group != nil {
+			walk(group)
 		}
+	}
 
-		groupAttributes := *group.Attributes
-		if values, ok := groupAttributes[attribute.Key]; ok {
-			// Check if any value matches (supports both single and multi-value attributes)
-			if slices.Contains(values, attribute.Value) {
-				return group, nil
+	return result
+}
+
+// collectSubGroupsByAttribute walks the subgroup trees of groups depth-first and returns every
+// subgroup holding the attribute, setting its ParentID from the tree if missing. The groups
+// themselves are not matched. The result is never nil.
+func collectSubGroupsByAttribute(groups []*Group, attribute GroupAttribute, strict bool) []*Group {
+	result := []*Group{}
+
+	var walk func(parent *Group)
+	walk = func(parent *Group) {
+		if parent.SubGroups == nil {
+			return
+		}
+		for _, subGroup := range *parent.SubGroups {
+			if subGroup == nil {
+				continue
 			}
+			if ptr.IsZero(subGroup.ParentID) && parent.ID != nil {
+				subGroup.ParentID = ptr.String(*parent.ID)
+			}
+			if hasAttribute(subGroup, attribute, strict) {
+				result = append(result, subGroup)
+			}
+			walk(subGroup)
+		}
+	}
+	for _, group := range groups {
+		if group != nil {
+			walk(group)
 		}
 	}
 
-	// No exact match found (shouldn't happen with q parameter, but be defensive)
-	return nil, ErrGroupNotFound
+	return result
+}
+
+// hasAttribute reports whether the group holds the attribute value. In strict mode only
+// single-value attributes match, as in matchGroupByAttribute.
+func hasAttribute(group *Group, attribute GroupAttribute, strict bool) bool {
+	if group.Attributes == nil {
+		return false
+	}
+	values := (*group.Attributes)[attribute.Key]
+	if strict {
+		return len(values) == 1 && values[0] == attribute.Value
+	}
+	return slices.Contains(values, attribute.Value)
 }
 
 // GetSubGroupByID finds a subgroup by its ID within a parent group's children.
@@ -351,11 +1472,24 @@ func (g *groupsClient) GetSubGroupByID(group Group, subGroupID string) (*Group,
 // CreateSubGroup creates a new subgroup under the specified parent group.
 // If the group already exists, this will set/update its parent relationship.
 // Returns the subgroup ID. May return empty string if the group already existed (204 response).
+//
+// Keycloak may ignore the attributes when it relocates an existing group instead of creating
+// one, so in that case the relocate is followed by a second operation: the child is looked up
+// by name under the parent and its attributes are set with Update, and the resolved child ID is
+// returned. Without attributes the relocate is a single operation and no ID is resolved.
+//
+// A missing parent is reported as an error wrapping ErrGroupNotFound, whether Keycloak answers
+// 404 or, as some versions do, 400 "could not find parent". A sibling with the same name is
+// still reported as an APIError with status 409.
 func (g *groupsClient) CreateSubGroup(ctx context.Context, groupID, name string, attributes map[string][]string) (string, error) {
 	if groupID == "" {
 		return "", errors.New("groupID parameter cannot be empty")
 	}
 
+	attributes = g.withDefaultAttributes(attributes)
+	if err := g.client.attrLimits.validate(attributes); err != nil {
+		return "", fmt.Errorf("unable to create sub-group: %w", err)
+	}
 	group := Group{
 		Name:       &name,
 		Attributes: &attributes,
@@ -368,31 +1502,127 @@ func (g *groupsClient) CreateSubGroup(ctx context.Context, groupID, name string,
 		return "", fmt.Errorf("unable to create sub-group: %w", err)
 	}
 	if !resp.IsSuccess() {
-		return "", fmt.Errorf("unable to create sub-group: %v", resp.Error())
+		apiErr := newAPIError(resp)
+		if isMissingParent(apiErr) {
+			return "", fmt.Errorf("unable to create sub-group: parent group %q: %w", groupID, ErrGroupNotFound)
+		}
+		return "", fmt.Errorf("unable to create sub-group: %w", apiErr)
 	}
 
-	return getID(resp), nil
+	id, err := createdID(resp, g.client.realm, "groups")
+	if err != nil {
+		return "", fmt.Errorf("unable to create sub-group: %w", err)
+	}
+	if id == "" && len(attributes) > 0 {
+		return g.applyRelocatedAttributes(ctx, groupID, name, attributes)
+	}
+	return id, nil
 }
 
-// ListSubGroups retrieves all direct child groups of the specified parent group.
-func (g *groupsClient) ListSubGroups(ctx context.Context, groupID string) ([]*Group, error) {
+// applyRelocatedAttributes sets the attributes of the child a CreateSubGroup call relocated
+// under the parent, since Keycloak may not apply them when moving a group, and returns the
+// child's ID.
+func (g *groupsClient) applyRelocatedAttributes(ctx context.Context, groupID, name string, attributes map[string][]string) (string, error) {
+	children, err := g.ListSubGroupsPaginated(ctx, groupID, SubGroupSearchParams{
+		Search:              ptr.String(name),
+		Exact:               ptr.Bool(true),
+		BriefRepresentation: ptr.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve relocated sub-group %q: %w", name, err)
+	}
+	child := groupNamed(children, name)
+	if child == nil {
+		return "", fmt.Errorf("unable to resolve relocated sub-group %q: %w", name, ErrGroupNotFound)
+	}
+
+	if err := g.Update(ctx, Group{ID: child.ID, Name: &name, Attributes: &attributes}); err != nil {
+		return "", fmt.Errorf("unable to set attributes of relocated sub-group: %w", err)
+	}
+	return *child.ID, nil
+}
+
+// isMissingParent reports whether a failed subgroup creation was rejected because the parent
+// group does not exist. Keycloak answers 404 for an unknown group ID in the path, while some
+// versions answer 400 with a "could not find parent" message instead.
+func isMissingParent(apiErr *APIError) bool {
+	switch apiErr.StatusCode {
+	case http.StatusNotFound:
+		return true
+	case http.StatusBadRequest:
+		details := strings.ToLower(apiErr.Response.String())
+		return strings.Contains(details, "could not find parent") || strings.Contains(details, "parent not found")
+	}
+	return false
+}
+
+// Detach moves a subgroup to the top level of the realm. The group keeps its ID,
+// attributes and children; its ParentID is cleared and its Path loses the parent prefix.
+//
+// Keycloak has no dedicated endpoint for this. Posting an existing group representation
+// (including its ID) to the top-level groups endpoint moves it instead of creating a new
+// group; this works on all supported Keycloak versions, whereas clearing parentId through
+// PUT is ignored. The current representation is fetched first, because Keycloak also
+// applies the posted name and attributes. Detaching a top-level group is a no-op, and
+// Keycloak answers 409 if a top-level group with the same name already exists.
+func (g *groupsClient) Detach(ctx context.Context, groupID string) error {
 	if groupID == "" {
-		return nil, fmt.Errorf("groupID parameter cannot be empty")
+		return errors.New("groupID parameter cannot be empty")
 	}
 
-	var result []*Group
+	group, err := g.Get(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	group.ParentID = nil
+	group.Path = nil
+	group.SubGroups = nil
+	group.SubGroupCount = nil
 
 	resp, err := g.getRequest(ctx).
-		SetResult(&result).
-		Execute(endpointGroupChildren.Method, g.client.buildURL(endpointGroupChildren, map[string]string{"groupID": groupID}))
+		SetBody(group).
+		Execute(endpointGroupsCreate.Method, g.client.buildURL(endpointGroupsCreate, nil))
 	if err != nil {
-		return nil, fmt.Errorf("unable to list groups: %w", err)
+		return fmt.Errorf("unable to detach group: %w", err)
 	}
 	if !resp.IsSuccess() {
-		return nil, fmt.Errorf("unable to list groups: %v", resp.Error())
+		if resp.StatusCode() == http.StatusNotFound {
+			return ErrGroupNotFound
+		}
+		return fmt.Errorf("unable to detach group: %w", newAPIError(resp))
 	}
 
-	return result, nil
+	return nil
+}
+
+// ListSubGroups retrieves all direct child groups of the specified parent group. The children
+// endpoint returns only 10 children unless asked for more, so they are fetched page by page.
+func (g *groupsClient) ListSubGroups(ctx context.Context, groupID string) ([]*Group, error) {
+	if groupID == "" {
+		return nil, fmt.Errorf("groupID parameter cannot be empty")
+	}
+
+	return listGroupPages(g.client, "unable to list all sub-groups", func(first, max int) ([]*Group, error) {
+		var result []*Group
+
+		req := g.getRequest(ctx).
+			SetQueryParam("first", strconv.Itoa(first)).
+			SetQueryParam("max", strconv.Itoa(max))
+		resp, err := g.client.executeList(req, &result,
+			endpointGroupChildren.Method, g.client.buildURL(endpointGroupChildren, map[string]string{"groupID": groupID}))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list groups: %w", err)
+		}
+		if !resp.IsSuccess() {
+			// Report a missing parent the same way Get reports a missing group
+			if resp.StatusCode() == http.StatusNotFound {
+				return nil, fmt.Errorf("unable to list groups: %w", ErrGroupNotFound)
+			}
+			return nil, fmt.Errorf("unable to list groups: %w", newAPIError(resp))
+		}
+
+		return result, nil
+	})
 }
 
 // ListSubGroupsPaginated retrieves a paginated list of subgroups.
@@ -405,21 +1635,26 @@ func (g *groupsClient) ListSubGroupsPaginated(ctx context.Context, groupID strin
 
 	var result []*Group
 
+	if params.BriefRepresentation == nil {
+		params.BriefRepresentation = g.client.defaults.SubGroupsBrief
+	}
 	queryParams, err := mapper(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initiate search parameters for sub-groups: %w", err)
 	}
 
-	resp, err := g.getRequest(ctx).
-		SetResult(&result).
-		SetQueryParams(queryParams).
-		Execute(endpointGroupChildren.Method, g.client.buildURL(endpointGroupChildren, map[string]string{"groupID": groupID}))
+	resp, err := g.client.executeList(g.getRequest(ctx).SetQueryParams(queryParams), &result,
+		endpointGroupChildren.Method, g.client.buildURL(endpointGroupChildren, map[string]string{"groupID": groupID}))
 	if err != nil {
 		return nil, fmt.Errorf("unable to list sub-groups: %w", err)
 	}
 
 	if !resp.IsSuccess() {
-		return nil, fmt.Errorf("unable to list sub-groups: %v", resp.Error())
+		// Report a missing parent the same way Get reports a missing group
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, fmt.Errorf("unable to list sub-groups: %w", ErrGroupNotFound)
+		}
+		return nil, fmt.Errorf("unable to list sub-groups: %w", newAPIError(resp))
 	}
 
 	return result, nil
@@ -431,12 +1666,7 @@ func (g *groupsClient) GetSubGroupByAttribute(group Group, attribute GroupAttrib
 		return nil, ErrGroupNotFound
 	}
 
-	subGroup, found := findGroupByAttribute(*group.SubGroups, attribute)
-	if !found {
-		return nil, ErrGroupNotFound
-	}
-
-	return subGroup, nil
+	return matchGroupByAttribute(*group.SubGroups, attribute, g.client.strictAttrs)
 }
 
 // Delete deletes a group by its ID.
@@ -452,7 +1682,7 @@ func (g *groupsClient) Delete(ctx context.Context, groupID string) error {
 	}
 
 	if !resp.IsSuccess() {
-		return fmt.Errorf("unable to delete group: %v", resp.Error())
+		return fmt.Errorf("unable to delete group: %w", newAPIError(resp))
 	}
 
 	return nil
@@ -487,14 +1717,75 @@ func findGroupByAttribute(groups []*Group, attribute GroupAttribute) (*Group, bo
 	return nil, false
 }
 
-// getID extracts the resource ID from the Location header in the HTTP response.
-// Returns an empty string if the Location header is not present.
-func getID(resp *resty.Response) string {
+// matchGroupByAttribute returns the first group in groups whose attribute matches.
+// In the default mode any value of a multi-value attribute may match, mirroring findGroupByAttribute.
+// In strict mode only single-value attributes match; if the value was found only within
+// multi-value attributes, ErrAmbiguousAttribute is returned instead of ErrGroupNotFound
+// so callers can tell "ambiguous" apart from "absent".
+func matchGroupByAttribute(groups []*Group, attribute GroupAttribute, strict bool) (*Group, error) {
+	if !strict {
+		group, found := findGroupByAttribute(groups, attribute)
+		if !found {
+			return nil, ErrGroupNotFound
+		}
+		return group, nil
+	}
+
+	ambiguous := false
+	for _, group := range groups {
+		if group == nil || group.Attributes == nil {
+			continue
+		}
+
+		values := (*group.Attributes)[attribute.Key]
+		if !slices.Contains(values, attribute.Value) {
+			continue
+		}
+		if len(values) == 1 {
+			return group, nil
+		}
+		ambiguous = true
+	}
+
+	if ambiguous {
+		return nil, fmt.Errorf("%w: %s", ErrAmbiguousAttribute, attribute.Key)
+	}
+	return nil, ErrGroupNotFound
+}
+
+// createdID extracts the ID of a created resource from the Location header in the HTTP
+// response. The header must address a resource of the given collection (the path segment
+// before the ID, e.g. "groups") in the given realm; otherwise, e.g. when a proxy rewrote it,
+// an error wrapping ErrUnexpectedLocation is returned rather than a wrong ID. Returns an empty
+// string if the Location header is not present.
+func createdID(resp *resty.Response, realm, collection string) (string, error) {
 	header := resp.Header().Get("Location")
 	if header == "" {
-		return ""
+		return "", nil
+	}
+	u, err := url.Parse(header)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %w", ErrUnexpectedLocation, header, err)
+	}
+
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	n := len(segments)
+	if n < 4 || segments[n-2] != collection || segments[n-1] == "" {
+		return "", fmt.Errorf("%w %q: not a %s URL", ErrUnexpectedLocation, header, collection)
+	}
+	realmIndex := slices.Index(segments, "realms") + 1
+	if realmIndex == 0 || realmIndex >= n-2 {
+		return "", fmt.Errorf("%w %q: no realm", ErrUnexpectedLocation, header)
+	}
+	if locationRealm, err := url.PathUnescape(segments[realmIndex]); err != nil || locationRealm != realm {
+		return "", fmt.Errorf("%w %q: not in realm %q", ErrUnexpectedLocation, header, realm)
+	}
+
+	id, err := url.PathUnescape(segments[n-1])
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %w", ErrUnexpectedLocation, header, err)
 	}
-	return path.Base(header)
+	return id, nil
 }
 
 // ListMembers retrieves the users that are members of the specified group.
@@ -505,26 +1796,814 @@ func (g *groupsClient) ListMembers(ctx context.Context, groupID string, params G
 
 	var result []*User
 
+	if params.BriefRepresentation == nil {
+		params.BriefRepresentation = g.client.defaults.MembersBrief
+	}
 	queryParams, err := mapper(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initiate search parameters for group members: %w", err)
 	}
 
-	resp, err := g.getRequest(ctx).
-		SetResult(&result).
-		SetQueryParams(queryParams).
-		Execute(endpointGroupMembers.Method, g.client.buildURL(endpointGroupMembers, map[string]string{"groupID": groupID}))
+	resp, err := g.client.executeList(g.getRequest(ctx).SetQueryParams(queryParams), &result,
+		endpointGroupMembers.Method, g.client.buildURL(endpointGroupMembers, map[string]string{"groupID": groupID}))
 	if err != nil {
 		return nil, fmt.Errorf("unable to list group members: %w", err)
 	}
 
 	if !resp.IsSuccess() {
-		return nil, fmt.Errorf("unable to list group members: %v", resp.Error())
+		return nil, fmt.Errorf("unable to list group members: %w", newAPIError(resp))
+	}
+
+	return result, nil
+}
+
+// memberRoleConcurrency bounds the number of role mapping requests ListMembersWithRole runs
+// concurrently.
+const memberRoleConcurrency = 4
+
+// ListMembersWithRole lists the members of the group with params, like ListMembers, and keeps
+// those holding the realm role roleName, e.g. for RBAC reports. The role counts whether it is
+// mapped to the user directly, through one of the user's groups (including this one) or
+// through a composite role. Client roles are not considered.
+//
+// Keycloak has no endpoint combining both filters: its role-users endpoint only reports
+// direct mappings. So the effective realm roles of every listed member are fetched, costing
+// one request per member, up to 4 at a time. Filtering applies to the page of members
+// fetched with params, so a page may hold fewer users than params.Max. For
+// WithEndpointTimeouts, the requests are identified as "Groups.ListMembers" and
+// "Users.ListEffectiveRealmRoles".
+func (g *groupsClient) ListMembersWithRole(ctx context.Context, groupID, roleName string, params GroupMembersParams) ([]*User, error) {
+	if roleName == "" {
+		return nil, fmt.Errorf("roleName parameter cannot be empty")
+	}
+	members, err := g.ListMembers(ctx, groupID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		holds    = make([]bool, len(members))
+		sem      = make(chan struct{}, memberRoleConcurrency)
+	)
+	for i, member := range members {
+		if member == nil || ptr.IsZero(member.ID) {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ok, err := g.hasEffectiveRealmRole(ctx, *member.ID, roleName)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			holds[i] = ok
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list group members with role: %w", err)
+	}
+	result := []*User{}
+	for i, member := range members {
+		if holds[i] {
+			result = append(result, member)
+		}
+	}
+	return result, nil
+}
+
+// hasEffectiveRealmRole reports whether the user holds the realm role, directly or through
+// groups and composite roles.
+func (g *groupsClient) hasEffectiveRealmRole(ctx context.Context, userID, roleName string) (bool, error) {
+	var roles []*Role
+	resp, err := g.getRequest(ctx).
+		SetQueryParam("briefRepresentation", "true").
+		SetResult(&roles).
+		Execute(endpointUserRealmRolesEffective.Method, g.client.buildURL(endpointUserRealmRolesEffective, map[string]string{"userID": userID}))
+	if err != nil {
+		return false, fmt.Errorf("unable to get roles of user %s: %w", userID, err)
+	}
+	if !resp.IsSuccess() {
+		return false, fmt.Errorf("unable to get roles of user %s: %w", userID, newAPIError(resp))
+	}
+
+	for _, role := range roles {
+		if role != nil && ptr.ToString(role.Name) == roleName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListMembersPage returns one page of members of the group. The page size is params.Max
+// (default: the client's page size) and the offset params.First (default 0). HasMore is
+// inferred from whether a full page was returned, so a list whose size is a multiple of the
+// page size ends with an empty page. Pass Next to get the following page.
+//
+// Example:
+//
+//	params := keycloak.GroupMembersParams{}
+//	for {
+//	    page, err := client.Groups.ListMembersPage(ctx, groupID, params)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    process(page.Members)
+//	    if !page.HasMore {
+//	        break
+//	    }
+//	    params = *page.Next
+//	}
+func (g *groupsClient) ListMembersPage(ctx context.Context, groupID string, params GroupMembersParams) (*MemberPage, error) {
+	pageSize := g.client.pageSize
+	if params.Max != nil && *params.Max > 0 {
+		pageSize = *params.Max
+	}
+	first := 0
+	if params.First != nil {
+		first = *params.First
+	}
+	params.First = ptr.Int(first)
+	params.Max = ptr.Int(pageSize)
+
+	members, err := g.ListMembers(ctx, groupID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &MemberPage{
+		Members: members,
+		HasMore: len(members) >= pageSize,
+	}
+	if page.HasMore {
+		next := params
+		next.First = ptr.Int(first + len(members))
+		page.Next = &next
+	}
+	return page, nil
+}
+
+// ReconcileMembers makes the desired user IDs the exact (direct) member set of the group,
+// for declarative membership sync. It lists the current members, then adds missing users
+// and removes extra ones concurrently, bounded by WithReconcileConcurrency. Running it
+// again with the same input changes nothing.
+//
+// The returned slices contain the user IDs that were actually added and removed, sorted.
+// If some changes fail, the successful ones are still returned together with an error
+// joining all failures. Changes not yet started when ctx is cancelled are skipped.
+func (g *groupsClient) ReconcileMembers(ctx context.Context, groupID string, desired []string) ([]string, []string, error) {
+	if groupID == "" {
+		return nil, nil, fmt.Errorf("groupID parameter cannot be empty")
+	}
+	want := make(map[string]bool, len(desired))
+	for _, userID := range desired {
+		if userID == "" {
+			return nil, nil, fmt.Errorf("desired user IDs cannot be empty")
+		}
+		want[userID] = true
+	}
+
+	members, err := g.listAllMembers(ctx, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := make(map[string]bool, len(members))
+	for _, member := range members {
+		if member != nil && !ptr.IsZero(member.ID) {
+			current[*member.ID] = true
+		}
+	}
+
+	var toAdd, toRemove []string
+	for userID := range want {
+		if !current[userID] {
+			toAdd = append(toAdd, userID)
+		}
+	}
+	for userID := range current {
+		if !want[userID] {
+			toRemove = append(toRemove, userID)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		added   []string
+		removed []string
+		errs    []error
+		sem     = make(chan struct{}, g.client.reconcileConcurrency)
+	)
+	apply := func(userID string, change func(context.Context, string, string) error, done *[]string) {
+		defer wg.Done()
+		defer func() { <-sem }()
+
+		err := change(ctx, userID, groupID)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		*done = append(*done, userID)
+	}
+
+	schedule := func(userIDs []string, change func(context.Context, string, string) error, done *[]string) {
+		for _, userID := range userIDs {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go apply(userID, change, done)
+		}
+	}
+	// The unwrapped users client is used so the error transform applies once, to the result
+	users := &usersClient{client: g.client}
+	schedule(toAdd, users.AddToGroup, &added)
+	schedule(toRemove, users.RemoveFromGroup, &removed)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		errs = append(errs, fmt.Errorf("unable to reconcile group members: %w", ctx.Err()))
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, errors.Join(errs...)
+}
+
+// ReconcileTree makes the group named desired.Name under the parent group, and its whole
+// subtree, match desired, for declarative (GitOps-style) group management. An empty parentID
+// addresses the top level of the realm.
+//
+// Groups are matched by name among the children of their parent; IDs in desired are ignored,
+// so renaming a group in desired creates a new group (and deletes the old one with Prune).
+// Missing groups are created with their attributes. Existing groups whose attributes differ
+// are updated to exactly the desired attributes, while a nil Attributes leaves them as they
+// are. The desired SubGroups are reconciled recursively. Existing subgroups not in desired are
+// deleted, with their own subgroups, only if opts.Prune is set; other children of the parent
+// are never touched. Running it again with the same input changes nothing.
+//
+// Changes are applied one at a time, top-down. The first failure stops the reconciliation
+// and is returned with the path of the group concerned, relative to the parent; changes
+// already applied are kept.
+//
+// Example:
+//
+//	desired := &keycloak.Group{
+//	    Name:       ptr.String("engineering"),
+//	    Attributes: &map[string][]string{"cost-center": {"42"}},
+//	    SubGroups: &[]*keycloak.Group{
+//	        {Name: ptr.String("backend")},
+//	        {Name: ptr.String("frontend")},
+//	    },
+//	}
+//	err := client.Groups.ReconcileTree(ctx, "", desired, keycloak.ReconcileTreeOptions{Prune: true})
+func (g *groupsClient) ReconcileTree(ctx context.Context, parentID string, desired *Group, opts ReconcileTreeOptions) error {
+	if desired == nil {
+		return fmt.Errorf("desired group cannot be nil")
+	}
+	if err := validateDesiredTree(desired, ""); err != nil {
+		return err
+	}
+
+	name := *desired.Name
+	var current *Group
+	if parentID == "" {
+		// Exact search also returns the parents of matching subgroups, so compare names
+		groups, err := g.list(ctx, SearchGroupParams{
+			Search:              ptr.String(name),
+			Exact:               ptr.Bool(true),
+			BriefRepresentation: ptr.Bool(false),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to reconcile group %q: %w", name, err)
+		}
+		current = groupNamed(groups, name)
+	} else {
+		children, err := g.ListSubGroups(ctx, parentID)
+		if err != nil {
+			return fmt.Errorf("unable to reconcile group %q: %w", name, err)
+		}
+		current = groupNamed(children, name)
+	}
+
+	return g.reconcileTreeNode(ctx, parentID, name, desired, current, opts)
+}
+
+// reconcileTreeNode reconciles the existing group current (nil if missing) under parentID with
+// desired, then its subgroups. path identifies the group in errors.
+func (g *groupsClient) reconcileTreeNode(ctx context.Context, parentID, path string, desired, current *Group, opts ReconcileTreeOptions) error {
+	fail := func(err error) error {
+		return fmt.Errorf("unable to reconcile group %q: %w", path, err)
+	}
+
+	var attributes map[string][]string
+	if desired.Attributes != nil {
+		attributes = *desired.Attributes
+	}
+
+	var children []*Group
+	if current == nil {
+		var id string
+		var err error
+		if parentID == "" {
+			id, err = g.Create(ctx, *desired.Name, attributes)
+		} else {
+			id, err = g.CreateSubGroup(ctx, parentID, *desired.Name, attributes)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if id == "" {
+			return fail(errors.New("keycloak returned no ID for the created group"))
+		}
+		current = &Group{ID: &id}
+	} else {
+		var currentAttributes map[string][]string
+		if current.Attributes != nil {
+			currentAttributes = *current.Attributes
+		}
+		if desired.Attributes != nil && !attributesEqual(currentAttributes, attributes) {
+			update := *current
+			update.Attributes = desired.Attributes
+			update.SubGroups = nil
+			update.SubGroupCount = nil
+			if err := g.Update(ctx, update); err != nil {
+				return fail(err)
+			}
+		}
+		// Skip the children request when Keycloak already told us there is nothing below
+		if current.SubGroupCount == nil || *current.SubGroupCount > 0 {
+			var err error
+			if children, err = g.ListSubGroups(ctx, *current.ID); err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	var desiredChildren []*Group
+	if desired.SubGroups != nil {
+		desiredChildren = *desired.SubGroups
+	}
+	wanted := make(map[string]bool, len(desiredChildren))
+	for _, child := range desiredChildren {
+		wanted[*child.Name] = true
+		existing := groupNamed(children, *child.Name)
+		if err := g.reconcileTreeNode(ctx, *current.ID, path+"/"+*child.Name, child, existing, opts); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Prune {
+		return nil
+	}
+	for _, child := range children {
+		if child == nil || ptr.IsZero(child.ID) || wanted[ptr.ToString(child.Name)] {
+			continue
+		}
+		if err := g.Delete(ctx, *child.ID); err != nil {
+			return fmt.Errorf("unable to reconcile group %q: %w", path+"/"+ptr.ToString(child.Name), err)
+		}
+	}
+	return nil
+}
+
+// validateDesiredTree checks that every group of a desired tree has a name, unique among its
+// siblings. parent is the path of the group's parent, used in errors.
+func validateDesiredTree(desired *Group, parent string) error {
+	if ptr.IsZero(desired.Name) {
+		return fmt.Errorf("desired group under %q has no name", "/"+parent)
+	}
+	path := strings.TrimPrefix(parent+"/"+*desired.Name, "/")
+	if desired.SubGroups == nil {
+		return nil
+	}
+	names := make(map[string]bool, len(*desired.SubGroups))
+	for _, child := range *desired.SubGroups {
+		if child == nil {
+			return fmt.Errorf("desired group %q has a nil subgroup", path)
+		}
+		if err := validateDesiredTree(child, path); err != nil {
+			return err
+		}
+		if names[*child.Name] {
+			return fmt.Errorf("desired group %q has several subgroups named %q", path, *child.Name)
+		}
+		names[*child.Name] = true
+	}
+	return nil
+}
+
+// groupNamed returns the group with exactly the given name and an ID, or nil.
+func groupNamed(groups []*Group, name string) *Group {
+	for _, group := range groups {
+		if group != nil && ptr.ToString(group.Name) == name && !ptr.IsZero(group.ID) {
+			return group
+		}
+	}
+	return nil
+}
+
+// attributesEqual reports whether two attribute maps hold the same keys and values, in the
+// same order. Nil and empty maps are equal.
+func attributesEqual(a, b map[string][]string) bool {
+	return maps.EqualFunc(a, b, slices.Equal)
+}
+
+// TagMatching adds the values to the attribute key of every group matching params, keeping
+// the values the groups already have. Groups that already hold all values are left untouched.
+// Matches are listed with all pages (First and Max in params are ignored), then updated
+// concurrently, bounded by WithReconcileConcurrency, each with the read-modify-write cycle of
+// RemoveAttributeValue.
+//
+// Keycloak answers a search with the hierarchies containing the matches, so the listed trees
+// are flattened and only groups that match params themselves are tagged: by name for Search
+// (case-insensitive substring, or exact with Exact) and by every key:value pair for Q.
+//
+// The results hold one entry per matching group, in listing order. If some updates fail, the
+// error joins all failures; groups not yet started when ctx is cancelled report ctx.Err().
+func (g *groupsClient) TagMatching(ctx context.Context, params SearchGroupParams, key string, values []string) ([]BatchResult, error) {
+	if key == "" {
+		return nil, errors.New("key parameter cannot be empty")
+	}
+	if len(values) == 0 {
+		return nil, errors.New("values parameter cannot be empty")
+	}
+
+	params.BriefRepresentation = ptr.Bool(false)
+	groups, err := g.listAll(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	matches := matchingGroups(groups, params)
+
+	results := make([]BatchResult, len(matches))
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, g.client.reconcileConcurrency)
+	)
+	for i, group := range matches {
+		results[i].ID = *group.ID
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Err = g.updateAttributes(ctx, *group.ID, "tag group", func(attributes map[string][]string) bool {
+				return mergeAttributeValues(attributes, key, values)
+			})
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("unable to tag group %s: %w", result.ID, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// matchingGroups flattens the group trees depth-first and returns the groups with an ID that
+// match the Search and Q of params themselves, each once.
+func matchingGroups(groups []*Group, params SearchGroupParams) []*Group {
+	var terms map[string]string
+	if !ptr.IsZero(params.Q) {
+		terms = parseSearchQuery(*params.Q)
+	}
+	search := strings.TrimSpace(ptr.ToString(params.Search))
+	exact := params.Exact != nil && *params.Exact
+
+	matches := func(group *Group) bool {
+		name := ptr.ToString(group.Name)
+		switch {
+		case search == "":
+		case exact && name != search:
+			return false
+		case !exact && !strings.Contains(strings.ToLower(name), strings.ToLower(search)):
+			return false
+		}
+		for key, value := range terms {
+			if !hasAttribute(group, GroupAttribute{Key: key, Value: value}, false) {
+				return false
+			}
+		}
+		return true
+	}
+
+	result := []*Group{}
+	seen := make(map[string]bool)
+	var walk func(groups []*Group)
+	walk = func(groups []*Group) {
+		for _, group := range groups {
+			if group == nil {
+				continue
+			}
+			if !ptr.IsZero(group.ID) && !seen[*group.ID] && matches(group) {
+				seen[*group.ID] = true
+				result = append(result, group)
+			}
+			if group.SubGroups != nil {
+				walk(*group.SubGroups)
+			}
+		}
 	}
+	walk(groups)
 
+	return result
+}
+
+// parseSearchQuery parses a q parameter of space-separated key:value pairs, as Keycloak does.
+// Terms without a colon are ignored.
+func parseSearchQuery(q string) map[string]string {
+	terms := make(map[string]string)
+	for _, term := range strings.Fields(q) {
+		if key, value, ok := strings.Cut(term, ":"); ok {
+			terms[key] = value
+		}
+	}
+	return terms
+}
+
+// listAllMembers collects all direct members of the group using IterateMembers.
+func (g *groupsClient) listAllMembers(ctx context.Context, groupID string) ([]*User, error) {
+	var result []*User
+	for member, err := range g.IterateMembers(ctx, groupID, GroupMembersParams{BriefRepresentation: ptr.Bool(true)}) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, member)
+	}
 	return result, nil
 }
 
+// IterateMembers returns an iterator over the direct members of the group. Pages are fetched
+// lazily with ListMembers, starting at params.First (default 0) with params.Max as the page size
+// (default: the client's page size), until a short page is returned. Stopping the iteration
+// early fetches no further pages.
+//
+// Errors are yielded as the second value, after which the iteration ends: HTTP errors from
+// ListMembers, the context's error once it is cancelled, and ErrPageLimitExceeded if the
+// client's page limit (WithMaxPages) is reached.
+//
+// Example:
+//
+//	for user, err := range client.Groups.IterateMembers(ctx, groupID, keycloak.GroupMembersParams{}) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(*user.Username)
+//	}
+func (g *groupsClient) IterateMembers(ctx context.Context, groupID string, params GroupMembersParams) iter.Seq2[*User, error] {
+	return func(yield func(*User, error) bool) {
+		pageSize := g.client.pageSize
+		if params.Max != nil && *params.Max > 0 {
+			pageSize = *params.Max
+		}
+		first := 0
+		if params.First != nil {
+			first = *params.First
+		}
+
+		for page := 0; ; page++ {
+			if page >= g.client.maxPages {
+				yield(nil, fmt.Errorf("unable to iterate group members: %w (%d pages of %d)", ErrPageLimitExceeded, g.client.maxPages, pageSize))
+				return
+			}
+			if err := ctx.Err(); err != nil {
+				yield(nil, fmt.Errorf("unable to iterate group members: %w", err))
+				return
+			}
+
+			pageParams := params
+			pageParams.First = ptr.Int(first + page*pageSize)
+			pageParams.Max = ptr.Int(pageSize)
+			members, err := g.ListMembers(ctx, groupID, pageParams)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, member := range members {
+				if !yield(member, nil) {
+					return
+				}
+			}
+			if len(members) < pageSize {
+				return
+			}
+		}
+	}
+}
+
+// SnapshotMembers exports the direct members of the group to w, one JSON-encoded user per
+// line, for groups too large to hold in memory. Pages are fetched with ListMembers like
+// IterateMembers does, starting at resume.First (default 0) with resume.Max as the page size
+// (default: the client's page size); only one page is held at a time, and with
+// WithStreamingDecode not even its raw JSON.
+//
+// The returned cursor holds the parameters to pass as resume to continue an interrupted
+// export: after an error, its First is the offset of the first member not written, so
+// appending the resumed output to what was written yields every member once (a write error
+// may leave a partial last line, which the caller should discard). Persist the cursor
+// alongside the output to resume after a crash. The cursor is nil once all members have been
+// written. ErrPageLimitExceeded is returned with a cursor when the client's page limit
+// (WithMaxPages) is reached, so a huge group can also be exported in chunks. Members added or
+// removed while the export runs may shift the offsets, as with any offset-based paging.
+//
+// Example:
+//
+//	cursor, err := client.Groups.SnapshotMembers(ctx, groupID, file, nil)
+//	for errors.Is(err, keycloak.ErrPageLimitExceeded) {
+//	    cursor, err = client.Groups.SnapshotMembers(ctx, groupID, file, cursor)
+//	}
+func (g *groupsClient) SnapshotMembers(ctx context.Context, groupID string, w io.Writer, resume *GroupMembersParams) (*GroupMembersParams, error) {
+	if groupID == "" {
+		return nil, fmt.Errorf("groupID parameter cannot be empty")
+	}
+	if w == nil {
+		return nil, fmt.Errorf("writer cannot be nil")
+	}
+
+	var cursor GroupMembersParams
+	if resume != nil {
+		cursor = *resume
+	}
+	pageSize := g.client.pageSize
+	if cursor.Max != nil && *cursor.Max > 0 {
+		pageSize = *cursor.Max
+	}
+	first := 0
+	if cursor.First != nil {
+		first = *cursor.First
+	}
+	// next returns the cursor resuming at the current offset
+	next := func() *GroupMembersParams {
+		next := cursor
+		next.First = ptr.Int(first)
+		next.Max = ptr.Int(pageSize)
+		return &next
+	}
+
+	for page := 0; ; page++ {
+		if page >= g.client.maxPages {
+			return next(), fmt.Errorf("unable to snapshot group members: %w (%d pages of %d)", ErrPageLimitExceeded, g.client.maxPages, pageSize)
+		}
+		if err := ctx.Err(); err != nil {
+			return next(), fmt.Errorf("unable to snapshot group members: %w", err)
+		}
+
+		members, err := g.ListMembers(ctx, groupID, *next())
+		if err != nil {
+			return next(), err
+		}
+		for _, member := range members {
+			line, err := json.Marshal(member)
+			if err != nil {
+				return next(), fmt.Errorf("unable to snapshot group members: %w", err)
+			}
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return next(), fmt.Errorf("unable to snapshot group members: %w", err)
+			}
+			first++
+		}
+		if len(members) < pageSize {
+			return nil, nil
+		}
+	}
+}
+
+// subtreeSizeConcurrency bounds the number of groups SubtreeSize scans concurrently.
+const subtreeSizeConcurrency = 4
+
+// SubtreeSize returns the number of descendant groups of the group (excluding the group
+// itself) and the sum of the direct member counts of the group and all its descendants.
+// Users that are members of several groups in the subtree are counted once per group.
+//
+// This is an expensive scan: every group in the subtree costs one request for its children
+// and at least one per page of members. Up to four groups are scanned concurrently. The
+// scan stops at the first error or when ctx is done, so bound it with a deadline for large
+// hierarchies. Returns an error wrapping ErrGroupNotFound if the group does not exist.
+func (g *groupsClient) SubtreeSize(ctx context.Context, groupID string) (int, int, error) {
+	if groupID == "" {
+		return 0, 0, fmt.Errorf("groupID parameter cannot be empty")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		groups   int
+		members  int
+		firstErr error
+		sem      = make(chan struct{}, subtreeSizeConcurrency)
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	var scan func(id string, hasChildren bool)
+	scan = func(id string, hasChildren bool) {
+		defer wg.Done()
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(fmt.Errorf("unable to compute subtree size: %w", ctx.Err()))
+			return
+		}
+		children, count, err := g.scanSubtreeNode(ctx, id, hasChildren)
+		<-sem
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		mu.Lock()
+		groups += len(children)
+		members += count
+		mu.Unlock()
+
+		for _, child := range children {
+			wg.Add(1)
+			// Skip the children request when Keycloak already told us there is nothing below
+			go scan(*child.ID, child.SubGroupCount == nil || *child.SubGroupCount > 0)
+		}
+	}
+
+	wg.Add(1)
+	scan(groupID, true)
+	wg.Wait()
+
+	if firstErr != nil {
+		return 0, 0, firstErr
+	}
+	return groups, members, nil
+}
+
+// scanSubtreeNode returns the direct subgroups (with IDs) and the direct member count of a group.
+// Children are only listed if hasChildren is set.
+func (g *groupsClient) scanSubtreeNode(ctx context.Context, groupID string, hasChildren bool) ([]*Group, int, error) {
+	var children []*Group
+	if hasChildren {
+		subGroups, err := g.ListSubGroups(ctx, groupID)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, child := range subGroups {
+			if child != nil && !ptr.IsZero(child.ID) {
+				children = append(children, child)
+			}
+		}
+	}
+
+	members, err := g.listAllMembers(ctx, groupID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return children, len(members), nil
+}
+
 // GetManagementPermissions returns whether client Authorization permissions have been initialized.
 func (g *groupsClient) GetManagementPermissions(ctx context.Context, groupID string) (*ManagementPermissionReference, error) {
 	if groupID == "" {
@@ -541,7 +2620,7 @@ func (g *groupsClient) GetManagementPermissions(ctx context.Context, groupID str
 	}
 
 	if !resp.IsSuccess() {
-		return nil, fmt.Errorf("unable to get management permissions: %v", resp.Error())
+		return nil, fmt.Errorf("unable to get management permissions: %w", newAPIError(resp))
 	}
 
 	return &result, nil
@@ -564,8 +2643,137 @@ func (g *groupsClient) UpdateManagementPermissions(ctx context.Context, groupID
 	}
 
 	if !resp.IsSuccess() {
-		return nil, fmt.Errorf("unable to update management permissions: %v", resp.Error())
+		return nil, fmt.Errorf("unable to update management permissions: %w", newAPIError(resp))
 	}
 
 	return &result, nil
 }
+
+// permissionsPollInterval is the delay between the polls of EnableManagementPermissionsAndWait.
+const permissionsPollInterval = 100 * time.Millisecond
+
+// EnableManagementPermissionsAndWait enables client Authorization permissions for the group,
+// like UpdateManagementPermissions, and then polls GetManagementPermissions until the
+// reference carries the ID of the authorization resource. Keycloak may answer the update
+// before the resource is initialized, so automation reading the resource ID right after
+// enabling permissions can otherwise race it.
+//
+// Returns an error wrapping context.DeadlineExceeded if the resource is still missing after
+// timeout. The timeout bounds the update as well as the polls.
+func (g *groupsClient) EnableManagementPermissionsAndWait(ctx context.Context, groupID string, timeout time.Duration) (*ManagementPermissionReference, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be positive, got %s", timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ref, err := g.UpdateManagementPermissions(ctx, groupID, ManagementPermissionReference{Enabled: ptr.Bool(true)})
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(permissionsPollInterval)
+	defer ticker.Stop()
+	for ptr.IsZero(ref.Resource) {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("unable to enable management permissions: resource not initialized after %s: %w", timeout, ctx.Err())
+		case <-ticker.C:
+		}
+		if ref, err = g.GetManagementPermissions(ctx, groupID); err != nil {
+			return nil, err
+		}
+	}
+	return ref, nil
+}
+
+// PermissionsEnabled reports whether client Authorization permissions are enabled for the group.
+// It is a convenience over GetManagementPermissions that treats an omitted Enabled field as false.
+func (g *groupsClient) PermissionsEnabled(ctx context.Context, groupID string) (bool, error) {
+	ref, err := g.GetManagementPermissions(ctx, groupID)
+	if err != nil {
+		return false, err
+	}
+	return ref.Enabled != nil && *ref.Enabled, nil
+}
+
+// GetRoleMappings returns the realm and client roles mapped to the group in a single call,
+// which is cheaper than querying realm and client mappings separately (e.g. for audits).
+// A group without mappings yields empty (non-nil) RoleMappings rather than an error.
+func (g *groupsClient) GetRoleMappings(ctx context.Context, groupID string) (*RoleMappings, error) {
+	if groupID == "" {
+		return nil, fmt.Errorf("groupID parameter cannot be empty")
+	}
+
+	var result mappingsRepresentation
+
+	resp, err := g.getRequest(ctx).
+		SetResult(&result).
+		Execute(endpointGroupRoleMaps.Method, g.client.buildURL(endpointGroupRoleMaps, map[string]string{"groupID": groupID}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role mappings: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("unable to get role mappings: %w", newAPIError(resp))
+	}
+
+	return result.toRoleMappings(), nil
+}
+
+// AddDefaultGroup makes the group a default group of the realm.
+// A 409 Conflict for a group that is already a default group is treated as success when
+// opts.Idempotent is set. Returns ErrGroupNotFound if the group does not exist.
+func (g *groupsClient) AddDefaultGroup(ctx context.Context, groupID string, opts DefaultGroupOptions) error {
+	if groupID == "" {
+		return fmt.Errorf("groupID parameter cannot be empty")
+	}
+
+	resp, err := g.getRequest(ctx).
+		Execute(endpointDefaultGroupAdd.Method, g.client.buildURL(endpointDefaultGroupAdd, map[string]string{"groupID": groupID}))
+	if err != nil {
+		return fmt.Errorf("unable to add default group: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		switch {
+		case resp.StatusCode() == http.StatusConflict && opts.Idempotent:
+			return nil
+		case resp.StatusCode() == http.StatusNotFound:
+			return ErrGroupNotFound
+		}
+		return fmt.Errorf("unable to add default group: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+// RemoveDefaultGroup removes the group from the default groups of the realm.
+// A 404 Not Found is treated as success when opts.Idempotent is set, and reported as
+// ErrGroupNotFound otherwise.
+func (g *groupsClient) RemoveDefaultGroup(ctx context.Context, groupID string, opts DefaultGroupOptions) error {
+	if groupID == "" {
+		return fmt.Errorf("groupID parameter cannot be empty")
+	}
+
+	resp, err := g.getRequest(ctx).
+		Execute(endpointDefaultGroupRemove.Method, g.client.buildURL(endpointDefaultGroupRemove, map[string]string{"groupID": groupID}))
+	if err != nil {
+		return fmt.Errorf("unable to remove default group: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			if opts.Idempotent {
+				return nil
+			}
+			return ErrGroupNotFound
+		}
+		return fmt.Errorf("unable to remove default group: %w", newAPIError(resp))
+	}
+
+	return nil
+}
@@ -15,6 +15,7 @@
 package keycloak
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -245,8 +246,90 @@ func BenchmarkMapper(b *testing.B) {
 	}
 }
 
-// BenchmarkGetID benchmarks extracting ID from Location header
-func BenchmarkGetID(b *testing.B) {
+// BenchmarkUnmarshalJSON_LargeList compares decoding a large group list with the pooled
+// decoder used for responses against a fresh decoder per response
+func BenchmarkUnmarshalJSON_LargeList(b *testing.B) {
+	groups := make([]*Group, 1000)
+	for i := range groups {
+		groups[i] = &Group{
+			ID:         ptr.String(fmt.Sprintf("group-%d", i)),
+			Name:       ptr.String(fmt.Sprintf("Group %d", i)),
+			Path:       ptr.String(fmt.Sprintf("/Group %d", i)),
+			Attributes: &map[string][]string{"customID": {fmt.Sprintf("id-%d", i)}},
+		}
+	}
+	body, err := json.Marshal(groups)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var result []*Group
+			if err := unmarshalJSON(body, &result); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var result []*Group
+			if err := decodeJSON(bytes.NewReader(body), &result); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkGroupsClient_ListStreaming compares listing a large group page with buffered
+// decoding against WithStreamingDecode
+func BenchmarkGroupsClient_ListStreaming(b *testing.B) {
+	groups := make([]*Group, 1000)
+	for i := range groups {
+		groups[i] = &Group{
+			ID:         ptr.String(fmt.Sprintf("group-%d", i)),
+			Name:       ptr.String(fmt.Sprintf("Group %d", i)),
+			Path:       ptr.String(fmt.Sprintf("/Group %d", i)),
+			Attributes: &map[string][]string{"customID": {fmt.Sprintf("id-%d", i)}},
+		}
+	}
+	body, err := json.Marshal(groups)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "buffered"},
+		{name: "streaming", opts: []Option{WithStreamingDecode(true)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			client := newTestClient(server.URL, bench.opts...)
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := client.Groups.ListWithParams(ctx, SearchGroupParams{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCreatedID benchmarks extracting ID from Location header
+func BenchmarkCreatedID(b *testing.B) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Location", "https://keycloak.example.com/admin/realms/test-realm/groups/test-group-id-123-456-789")
 		w.WriteHeader(http.StatusCreated)
@@ -261,7 +344,7 @@ func BenchmarkGetID(b *testing.B) {
 		if err != nil {
 			b.Fatal(err)
 		}
-		_ = getID(resp)
+		_, _ = createdID(resp, "test-realm", "groups")
 	}
 }
 
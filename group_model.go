@@ -14,6 +14,12 @@
 
 package keycloak
 
+import (
+	"slices"
+	"strconv"
+	"time"
+)
+
 // Group represents a Keycloak group with all its properties.
 // Groups can contain subgroups (hierarchical structure) and have custom attributes.
 // The ID, Name, and Attributes fields are the most commonly used.
@@ -32,6 +38,113 @@ type Group struct {
 	RealmRoles    *[]string            `json:"realmRoles,omitempty"`    // Realm-level roles assigned to the group
 }
 
+// attribute returns the first value stored under key, if any.
+func (g *Group) attribute(key string) (string, bool) {
+	if g == nil || g.Attributes == nil {
+		return "", false
+	}
+	values := (*g.Attributes)[key]
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// AttributeInt parses the first value of the attribute as a base-10 integer.
+// Returns ok=false if the attribute is missing or cannot be parsed.
+//
+// Example:
+//
+//	if ts, ok := group.AttributeInt("lastModified"); ok {
+//	    modified := time.Unix(ts, 0)
+//	}
+func (g *Group) AttributeInt(key string) (int64, bool) {
+	value, ok := g.attribute(key)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// AttributeBool parses the first value of the attribute as a boolean.
+// Accepts the values understood by strconv.ParseBool (e.g. "true", "false", "1", "0").
+// Returns ok=false if the attribute is missing or cannot be parsed.
+func (g *Group) AttributeBool(key string) (bool, bool) {
+	value, ok := g.attribute(key)
+	if !ok {
+		return false, false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, false
+	}
+	return b, true
+}
+
+// AttributeTime parses the first value of the attribute as a time using the given layout
+// (see time.Parse). For attributes stored as unix timestamps, use AttributeInt with time.Unix.
+// Returns ok=false if the attribute is missing or cannot be parsed.
+func (g *Group) AttributeTime(key, layout string) (time.Time, bool) {
+	value, ok := g.attribute(key)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// IsBrief reports whether the group looks like a brief representation, i.e. it was returned
+// by a call with briefRepresentation=true and its attributes were omitted, rather than a
+// group that genuinely has no attributes. Keycloak always includes the attributes (possibly
+// empty) and the realm and client roles in full representations, so a group returned by the
+// server with an ID but none of these fields is assumed to be brief.
+//
+// This is a heuristic: it reports false positives for groups constructed in code or decoded
+// from a source that drops empty fields, and for groups from Keycloak versions that omit
+// empty attributes in full representations. It is safe to call with a nil group.
+//
+// Example:
+//
+//	if group.IsBrief() {
+//	    group, err = client.Groups.Get(ctx, *group.ID)
+//	}
+func (g *Group) IsBrief() bool {
+	if g == nil || g.ID == nil {
+		return false
+	}
+	return g.Attributes == nil && g.RealmRoles == nil && g.ClientRoles == nil
+}
+
+// NormalizeAttributes sorts the values of every attribute of the group and its subgroups in
+// place, so that groups holding the same values in a different order compare equal.
+// Keycloak does not guarantee the order of attribute values across round trips, which makes
+// direct comparisons of fetched and expected groups report false differences.
+//
+// Normalization is opt-in: the client never reorders attributes it sends or receives.
+// It is safe to call with a nil group.
+func NormalizeAttributes(group *Group) {
+	if group == nil {
+		return
+	}
+	if group.Attributes != nil {
+		for _, values := range *group.Attributes {
+			slices.Sort(values)
+		}
+	}
+	if group.SubGroups != nil {
+		for _, subGroup := range *group.SubGroups {
+			NormalizeAttributes(subGroup)
+		}
+	}
+}
+
 // GroupAttribute represents a key-value pair for searching groups by attributes.
 // Use this to search for groups with specific attribute values.
 type GroupAttribute struct {
@@ -85,6 +198,34 @@ type GroupMembersParams struct {
 	Max                 *int  `json:"max,string,omitempty"`                 // Maximum results to return (default: 100)
 }
 
+// MemberPage is one page of group members returned by ListMembersPage, with a cursor for the
+// next page.
+type MemberPage struct {
+	Members []*User             // Members on this page
+	HasMore bool                // Whether a full page was returned, so more members may follow
+	Next    *GroupMembersParams // Parameters for the next page (nil if HasMore is false)
+}
+
+// BatchResult is the outcome for one group of a bulk operation such as TagMatching.
+type BatchResult struct {
+	ID  string // ID of the group
+	Err error  // Error of the operation on this group, nil on success
+}
+
+// ReconcileTreeOptions configures ReconcileTree.
+type ReconcileTreeOptions struct {
+	// Prune deletes existing subgroups that are not in the desired tree, together with their
+	// own subgroups. Without it, extra groups are left in place.
+	Prune bool
+}
+
+// DefaultGroupOptions configures AddDefaultGroup and RemoveDefaultGroup.
+type DefaultGroupOptions struct {
+	// Idempotent treats adding a group that is already a default group, or removing one that
+	// is absent, as success. Useful for declarative reconciliation loops.
+	Idempotent bool
+}
+
 // ManagementPermissionReference represents the authorization permissions status for a group.
 // Used with /admin/realms/{realm}/groups/{group-id}/management/permissions endpoint.
 type ManagementPermissionReference struct {
@@ -255,11 +255,13 @@ func TestFindGroupByAttribute(t *testing.T) {
 	}
 }
 
-func TestGetID(t *testing.T) {
+func TestCreatedID(t *testing.T) {
 	tests := []struct {
 		name       string
 		location   string
+		collection string
 		expectedID string
+		wantErr    bool
 	}{
 		{
 			name:       "extract ID from Location header",
@@ -276,26 +278,57 @@ func TestGetID(t *testing.T) {
 			location:   "",
 			expectedID: "",
 		},
-		{
-			name:       "empty Location header",
-			location:   "",
-			expectedID: "",
-		},
 		{
 			name:       "Location header with trailing slash",
 			location:   "https://keycloak.example.com/admin/realms/test-realm/groups/test-group-id/",
 			expectedID: "test-group-id",
 		},
-		{
-			name:       "Location header with only base URL",
-			location:   "https://keycloak.example.com",
-			expectedID: "keycloak.example.com",
-		},
 		{
 			name:       "Location with UUID format",
 			location:   "https://keycloak.example.com/admin/realms/test-realm/groups/550e8400-e29b-41d4-a716-446655440000",
 			expectedID: "550e8400-e29b-41d4-a716-446655440000",
 		},
+		{
+			name:       "Location behind a path prefix",
+			location:   "https://keycloak.example.com/auth/admin/realms/test-realm/groups/g1",
+			expectedID: "g1",
+		},
+		{
+			name:       "relative Location",
+			location:   "/admin/realms/test-realm/users/u1",
+			collection: "users",
+			expectedID: "u1",
+		},
+		{
+			name:     "Location header with only base URL",
+			location: "https://keycloak.example.com",
+			wantErr:  true,
+		},
+		{
+			name:     "Location of another resource type",
+			location: "https://keycloak.example.com/admin/realms/test-realm/users/u1",
+			wantErr:  true,
+		},
+		{
+			name:     "Location in another realm",
+			location: "https://keycloak.example.com/admin/realms/other-realm/groups/g1",
+			wantErr:  true,
+		},
+		{
+			name:     "Location without realm",
+			location: "https://keycloak.example.com/admin/groups/g1",
+			wantErr:  true,
+		},
+		{
+			name:     "Location rewritten to a login page",
+			location: "https://sso.example.com/login?next=%2Fgroups",
+			wantErr:  true,
+		},
+		{
+			name:     "malformed Location",
+			location: "https://keycloak.example.com/admin/realms/test-realm/groups/%zz",
+			wantErr:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -314,9 +347,18 @@ func TestGetID(t *testing.T) {
 			resp, err := restyClient.R().Post(server.URL)
 			assert.NoError(t, err)
 
-			// Test the getID function
-			result := getID(resp)
-			assert.Equal(t, tt.expectedID, result)
+			collection := tt.collection
+			if collection == "" {
+				collection = "groups"
+			}
+			result, err := createdID(resp, "test-realm", collection)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnexpectedLocation)
+				assert.Empty(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedID, result)
+			}
 		})
 	}
 }
@@ -394,6 +436,38 @@ func TestBuildURL(t *testing.T) {
 			params:      nil,
 			expectedURL: "https://keycloak.example.com/admin/realms/test-realm-123/groups",
 		},
+		{
+			name:        "realm with spaces",
+			baseURL:     "https://keycloak.example.com",
+			realm:       "test realm",
+			endpoint:    endpointGroupsList,
+			params:      nil,
+			expectedURL: "https://keycloak.example.com/admin/realms/test%20realm/groups",
+		},
+		{
+			name:        "role name with spaces, slash and unicode",
+			baseURL:     "https://keycloak.example.com",
+			realm:       "test-realm",
+			endpoint:    endpointClientRoleGet,
+			params:      map[string]string{"id": "c-1", "roleName": "read/write Größe"},
+			expectedURL: "https://keycloak.example.com/admin/realms/test-realm/clients/c-1/roles/read%2Fwrite%20Gr%C3%B6%C3%9Fe",
+		},
+		{
+			name:        "alias with spaces",
+			baseURL:     "https://keycloak.example.com",
+			realm:       "test-realm",
+			endpoint:    endpoint{http.MethodGet, "/admin/realms/{realm}/identity-provider/instances/{alias}"},
+			params:      map[string]string{"alias": "my idp"},
+			expectedURL: "https://keycloak.example.com/admin/realms/test-realm/identity-provider/instances/my%20idp",
+		},
+		{
+			name:        "path keeps separators and escapes segments",
+			baseURL:     "https://keycloak.example.com",
+			realm:       "test-realm",
+			endpoint:    endpoint{http.MethodGet, "/admin/realms/{realm}/group-by-path/{path}"},
+			params:      map[string]string{"path": "/Parent Group/子 group?"},
+			expectedURL: "https://keycloak.example.com/admin/realms/test-realm/group-by-path/Parent%20Group/%E5%AD%90%20group%3F",
+		},
 	}
 
 	for _, tt := range tests {
@@ -619,3 +693,338 @@ func TestGroupsClient_GetSubGroupByAttribute(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchGroupByAttribute(t *testing.T) {
+	groups := []*Group{
+		{
+			ID:         ptr.String("multi"),
+			Attributes: &map[string][]string{"team": {"alpha", "beta"}},
+		},
+		{
+			ID:         ptr.String("single"),
+			Attributes: &map[string][]string{"team": {"gamma"}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		value     string
+		strict    bool
+		wantID    string
+		wantErrIs error
+	}{
+		{
+			name:   "lenient mode matches multi-value attribute",
+			value:  "alpha",
+			strict: false,
+			wantID: "multi",
+		},
+		{
+			name:      "lenient mode reports missing value as not found",
+			value:     "delta",
+			strict:    false,
+			wantErrIs: ErrGroupNotFound,
+		},
+		{
+			name:   "strict mode matches single-value attribute",
+			value:  "gamma",
+			strict: true,
+			wantID: "single",
+		},
+		{
+			name:      "strict mode reports multi-value match as ambiguous",
+			value:     "beta",
+			strict:    true,
+			wantErrIs: ErrAmbiguousAttribute,
+		},
+		{
+			name:      "strict mode reports missing value as not found",
+			value:     "delta",
+			strict:    true,
+			wantErrIs: ErrGroupNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group, err := matchGroupByAttribute(groups, GroupAttribute{Key: "team", Value: tt.value}, tt.strict)
+
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Nil(t, group)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantID, *group.ID)
+			}
+		})
+	}
+}
+
+func TestCollectSubGroupsByAttribute(t *testing.T) {
+	// newHierarchy builds: root-a (match) > child-a1 (match) > grandchild (match, multi-value)
+	//                      root-a > child-a2 (ParentID set by Keycloak)
+	//                      root-b > child-b1 (match)
+	newHierarchy := func() []*Group {
+		return []*Group{
+			{
+				ID:         ptr.String("root-a"),
+				Attributes: &map[string][]string{"team": {"alpha"}},
+				SubGroups: &[]*Group{
+					{
+						ID:         ptr.String("child-a1"),
+						Attributes: &map[string][]string{"team": {"alpha"}},
+						SubGroups: &[]*Group{
+							{ID: ptr.String("grandchild"), Attributes: &map[string][]string{"team": {"alpha", "beta"}}},
+						},
+					},
+					{ID: ptr.String("child-a2"), ParentID: ptr.String("root-a"), Attributes: &map[string][]string{"team": {"beta"}}},
+					nil,
+				},
+			},
+			{
+				ID: ptr.String("root-b"),
+				SubGroups: &[]*Group{
+					{ID: ptr.String("child-b1"), Attributes: &map[string][]string{"team": {"alpha"}}},
+				},
+			},
+			nil,
+		}
+	}
+
+	ids := func(groups []*Group) []string {
+		result := []string{}
+		for _, group := range groups {
+			result = append(result, *group.ID+"<"+*group.ParentID)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		groups  []*Group
+		value   string
+		strict  bool
+		wantIDs []string
+	}{
+		{
+			name:    "matches subgroups at any depth but not top-level groups",
+			groups:  newHierarchy(),
+			value:   "alpha",
+			wantIDs: []string{"child-a1<root-a", "grandchild<child-a1", "child-b1<root-b"},
+		},
+		{
+			name:    "strict mode skips multi-value attributes",
+			groups:  newHierarchy(),
+			value:   "alpha",
+			strict:  true,
+			wantIDs: []string{"child-a1<root-a", "child-b1<root-b"},
+		},
+		{
+			name:    "keeps ParentID reported by Keycloak",
+			groups:  newHierarchy(),
+			value:   "beta",
+			wantIDs: []string{"grandchild<child-a1", "child-a2<root-a"},
+		},
+		{
+			name:    "no match",
+			groups:  newHierarchy(),
+			value:   "gamma",
+			wantIDs: []string{},
+		},
+		{
+			name:    "no groups",
+			value:   "alpha",
+			wantIDs: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := collectSubGroupsByAttribute(tt.groups, GroupAttribute{Key: "team", Value: tt.value}, tt.strict)
+			assert.NotNil(t, groups)
+			assert.Equal(t, tt.wantIDs, ids(groups))
+		})
+	}
+}
+
+func TestRemoveAttributeValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		attributes  map[string][]string
+		key         string
+		value       string
+		wantChanged bool
+		want        map[string][]string
+	}{
+		{
+			name:        "removes one value of a multi-value attribute",
+			attributes:  map[string][]string{"tags": {"a", "b", "c"}, "owner": {"ops"}},
+			key:         "tags",
+			value:       "b",
+			wantChanged: true,
+			want:        map[string][]string{"tags": {"a", "c"}, "owner": {"ops"}},
+		},
+		{
+			name:        "removes duplicate values",
+			attributes:  map[string][]string{"tags": {"a", "b", "a"}},
+			key:         "tags",
+			value:       "a",
+			wantChanged: true,
+			want:        map[string][]string{"tags": {"b"}},
+		},
+		{
+			name:        "deletes attribute when last value is removed",
+			attributes:  map[string][]string{"tags": {"a"}, "owner": {"ops"}},
+			key:         "tags",
+			value:       "a",
+			wantChanged: true,
+			want:        map[string][]string{"owner": {"ops"}},
+		},
+		{
+			name:       "missing value",
+			attributes: map[string][]string{"tags": {"a"}},
+			key:        "tags",
+			value:      "z",
+			want:       map[string][]string{"tags": {"a"}},
+		},
+		{
+			name:       "missing key",
+			attributes: map[string][]string{"tags": {"a"}},
+			key:        "owner",
+			value:      "a",
+			want:       map[string][]string{"tags": {"a"}},
+		},
+		{
+			name:  "nil attributes",
+			key:   "tags",
+			value: "a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := removeAttributeValue(tt.attributes, tt.key, tt.value)
+			assert.Equal(t, tt.wantChanged, changed)
+			assert.Equal(t, tt.want, tt.attributes)
+		})
+	}
+}
+
+// TestMergeAttributeValues tests that missing values are appended and existing ones kept
+func TestMergeAttributeValues(t *testing.T) {
+	attributes := map[string][]string{"tags": {"a", "b"}}
+
+	assert.True(t, mergeAttributeValues(attributes, "tags", []string{"b", "c", "c"}))
+	assert.Equal(t, []string{"a", "b", "c"}, attributes["tags"])
+
+	assert.False(t, mergeAttributeValues(attributes, "tags", []string{"a"}))
+
+	assert.True(t, mergeAttributeValues(attributes, "owner", []string{"ops"}))
+	assert.Equal(t, map[string][]string{"tags": {"a", "b", "c"}, "owner": {"ops"}}, attributes)
+}
+
+// TestMatchingGroups tests that search hierarchies are flattened to the groups matching
+// the name search and q terms themselves
+func TestMatchingGroups(t *testing.T) {
+	groups := []*Group{
+		{ID: ptr.String("g1"), Name: ptr.String("org"), Attributes: &map[string][]string{"env": {"prod"}}, SubGroups: &[]*Group{
+			{ID: ptr.String("g2"), Name: ptr.String("Team A"), Attributes: &map[string][]string{"env": {"prod", "dev"}, "tier": {"1"}}},
+			{ID: ptr.String("g3"), Name: ptr.String("team"), Attributes: &map[string][]string{"env": {"dev"}}},
+		}},
+		{ID: ptr.String("g2"), Name: ptr.String("Team A")},
+		{Name: ptr.String("team without ID")},
+	}
+
+	tests := []struct {
+		name   string
+		params SearchGroupParams
+		want   []string
+	}{
+		{name: "no filter", want: []string{"g1", "g2", "g3"}},
+		{name: "name search", params: SearchGroupParams{Search: ptr.String("TEAM")}, want: []string{"g2", "g3"}},
+		{name: "exact name search", params: SearchGroupParams{Search: ptr.String("team"), Exact: ptr.Bool(true)}, want: []string{"g3"}},
+		{name: "q", params: SearchGroupParams{Q: ptr.String("env:prod")}, want: []string{"g1", "g2"}},
+		{name: "q with several terms", params: SearchGroupParams{Q: ptr.String("env:prod tier:1")}, want: []string{"g2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := []string{}
+			for _, group := range matchingGroups(groups, tt.params) {
+				ids = append(ids, *group.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+// TestParseSearchQuery tests splitting a q parameter into key:value terms
+func TestParseSearchQuery(t *testing.T) {
+	assert.Equal(t, map[string]string{"env": "prod", "url": "https://x"}, parseSearchQuery(" env:prod  url:https://x invalid "))
+	assert.Empty(t, parseSearchQuery(""))
+}
+
+// TestAttributeLimitsValidate tests each attribute limit and that zero disables it
+func TestAttributeLimitsValidate(t *testing.T) {
+	attributes := map[string][]string{
+		"a": {"x"},
+		"b": {"x", "y", "z"},
+		"c": {"héllo"},
+	}
+
+	tests := []struct {
+		name    string
+		limits  attributeLimits
+		wantErr string
+	}{
+		{name: "no limits", limits: attributeLimits{}},
+		{name: "within all limits", limits: attributeLimits{maxKeys: 3, maxValuesPerKey: 3, maxValueLen: 5}},
+		{name: "too many keys", limits: attributeLimits{maxKeys: 2}, wantErr: "3 attribute keys, at most 2 allowed"},
+		{name: "too many values", limits: attributeLimits{maxValuesPerKey: 2}, wantErr: `attribute "b" has 3 values, at most 2 allowed`},
+		{name: "value too long", limits: attributeLimits{maxValueLen: 4}, wantErr: `value of attribute "c" has 5 characters, at most 4 allowed`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.validate(attributes)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrAttributeLimitExceeded)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestIsTopLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		group *Group
+		want  bool
+	}{
+		{name: "no parent and single segment path", group: &Group{Path: ptr.String("/org")}, want: true},
+		{name: "no path", group: &Group{Name: ptr.String("org")}, want: true},
+		{name: "empty parent ID", group: &Group{ParentID: ptr.String(""), Path: ptr.String("/org")}, want: true},
+		{name: "escaped slash in name", group: &Group{Path: ptr.String("/a~/b")}, want: true},
+		{name: "parent ID", group: &Group{ParentID: ptr.String("p1"), Path: ptr.String("/org")}, want: false},
+		{name: "nested path", group: &Group{Path: ptr.String("/org/team")}, want: false},
+		{name: "nil group", group: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTopLevel(tt.group))
+		})
+	}
+}
+
+func TestGroupsByName(t *testing.T) {
+	named := &Group{Name: ptr.String("alpha")}
+	unnamed := &Group{}
+
+	assert.True(t, GroupsByName(unnamed, named))
+	assert.False(t, GroupsByName(named, unnamed))
+	assert.True(t, GroupsByName(nil, named))
+	assert.False(t, GroupsByName(named, named))
+}
@@ -15,12 +15,22 @@
 package keycloak
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -57,6 +67,13 @@ func TestGroupsClient_CreateWithServer(t *testing.T) {
 			wantErr:        false,
 			wantID:         "simple-id",
 		},
+		{
+			name:           "location rewritten by a proxy",
+			groupName:      "Proxied Group",
+			mockStatusCode: http.StatusCreated,
+			mockLocation:   "/admin/realms/other-realm/groups/foreign-id",
+			wantErr:        true,
+		},
 		{
 			name:           "server returns bad request",
 			groupName:      "",
@@ -85,6 +102,13 @@ func TestGroupsClient_CreateWithServer(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var serverURL string
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// A conflict is followed by a lookup of the existing group
+				if tt.mockStatusCode == http.StatusConflict && r.Method == http.MethodGet {
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte(`[]`))
+					return
+				}
+
 				assert.Equal(t, http.MethodPost, r.Method)
 				assert.Contains(t, r.URL.Path, "/admin/realms/test-realm/groups")
 
@@ -508,6 +532,7 @@ func TestGroupsClient_ListSubGroupsWithServer(t *testing.T) {
 				baseURL:  server.URL,
 				realm:    "test-realm",
 				pageSize: 50,
+				maxPages: 10,
 				resty:    newTestRestyClient(),
 			}
 			client.resty.SetBaseURL(server.URL)
@@ -528,6 +553,42 @@ func TestGroupsClient_ListSubGroupsWithServer(t *testing.T) {
 	}
 }
 
+// TestGroupsClient_ListSubGroupsAllPages tests that ListSubGroups returns all children, not
+// just the first 10 Keycloak returns by default
+func TestGroupsClient_ListSubGroupsAllPages(t *testing.T) {
+	var children []*Group
+	for i := range 25 {
+		children = append(children, &Group{ID: ptr.String(fmt.Sprintf("sub%d", i))})
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "/admin/realms/test-realm/groups/parent-id/children", r.URL.Path)
+		first, max := 0, 10 // Keycloak's defaults
+		if v := r.URL.Query().Get("first"); v != "" {
+			first, _ = strconv.Atoi(v)
+		}
+		if v := r.URL.Query().Get("max"); v != "" {
+			max, _ = strconv.Atoi(v)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(children[min(first, len(children)):min(first+max, len(children))])
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithPageSize(10))
+	subGroups, err := client.Groups.ListSubGroups(context.Background(), "parent-id")
+	require.NoError(t, err)
+	require.Len(t, subGroups, 25)
+	assert.Equal(t, "sub24", ptr.ToString(subGroups[24].ID))
+	assert.Equal(t, int32(3), requests.Load())
+
+	limited := newTestClient(server.URL, WithPageSize(10), WithMaxPages(2))
+	_, err = limited.Groups.ListSubGroups(context.Background(), "parent-id")
+	assert.ErrorIs(t, err, ErrPageLimitExceeded)
+	assert.ErrorContains(t, err, "unable to list all sub-groups")
+}
+
 // TestGroupsClient_UpdateWithServer tests Update with a mock HTTP server
 func TestGroupsClient_UpdateWithServer(t *testing.T) {
 	tests := []struct {
@@ -939,6 +1000,27 @@ func TestGroupsClient_ListWithParamsWithServer(t *testing.T) {
 	}
 }
 
+// TestGroupsClient_ListNoCountWithServer tests that ListNoCount disables subgroup counts
+func TestGroupsClient_ListNoCountWithServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "false", r.URL.Query().Get("subGroupsCount"))
+		assert.Equal(t, "team", r.URL.Query().Get("search"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":"g1","name":"team"}]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	// An explicit true is overridden
+	groups, err := client.Groups.ListNoCount(context.Background(), SearchGroupParams{
+		Search:         ptr.String("team"),
+		SubGroupsCount: ptr.Bool(true),
+	})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Nil(t, groups[0].SubGroupCount)
+}
+
 // TestGroupsClient_ListWithSubGroupsWithServer tests the convenience method
 func TestGroupsClient_ListWithSubGroupsWithServer(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -985,6 +1067,153 @@ func TestGroupsClient_ListWithSubGroupsWithServer(t *testing.T) {
 	assert.Len(t, *groups[0].SubGroups, 2)
 }
 
+// TestGroupsClient_ListWithSubGroupsDuplicates tests that a group listed both at the top level
+// and nested under its parent is returned once
+func TestGroupsClient_ListWithSubGroupsDuplicates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"id":"p1","name":"parent","subGroups":[{"id":"c1","name":"child"}]},
+			{"id":"c1","name":"child","attributes":{"code":["x"]},"subGroups":[{"id":"g1","name":"grandchild"}]},
+			{"id":"p2","name":"other"}
+		]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	groups, err := client.Groups.ListWithSubGroups(context.Background(), "child", false, 0, 10)
+	require.NoError(t, err)
+
+	require.Len(t, groups, 2)
+	assert.Equal(t, "p1", *groups[0].ID)
+	assert.Equal(t, "p2", *groups[1].ID)
+	// The nested position is kept, with the more complete representation
+	require.Len(t, *groups[0].SubGroups, 1)
+	child := (*groups[0].SubGroups)[0]
+	assert.Equal(t, "c1", *child.ID)
+	assert.Equal(t, map[string][]string{"code": {"x"}}, *child.Attributes)
+	require.Len(t, *child.SubGroups, 1)
+	assert.Equal(t, "g1", *(*child.SubGroups)[0].ID)
+}
+
+func TestDedupeGroupTree(t *testing.T) {
+	group := func(id string, subGroups ...*Group) *Group {
+		g := &Group{ID: ptr.String(id)}
+		if len(subGroups) > 0 {
+			g.SubGroups = &subGroups
+		}
+		return g
+	}
+	// flatten renders a tree as "id(children...)" for comparison
+	var flatten func(groups []*Group) string
+	flatten = func(groups []*Group) string {
+		var parts []string
+		for _, g := range groups {
+			part := ptr.ToString(g.ID)
+			if g.SubGroups != nil && len(*g.SubGroups) > 0 {
+				part += "(" + flatten(*g.SubGroups) + ")"
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, " ")
+	}
+
+	tests := []struct {
+		name   string
+		groups []*Group
+		want   string
+	}{
+		{name: "no duplicates", groups: []*Group{group("a", group("b")), group("c")}, want: "a(b) c"},
+		{name: "top-level duplicates", groups: []*Group{group("a"), group("b"), group("a", group("c"))}, want: "a(c) b"},
+		{name: "nested and top-level", groups: []*Group{group("c"), group("a", group("b", group("c")))}, want: "a(b(c))"},
+		{name: "more complete top-level copy moves under parent", groups: []*Group{group("a", group("b")), group("b", group("c"))}, want: "a(b(c))"},
+		{name: "empty", groups: []*Group{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, flatten(dedupeGroupTree(tt.groups)))
+		})
+	}
+
+	// Groups without ID cannot be told apart and are all kept
+	assert.Len(t, dedupeGroupTree([]*Group{{Name: ptr.String("x")}, {Name: ptr.String("x")}}), 2)
+}
+
+// TestGroupsClient_CreateWithIDWithServer tests that CreateWithID imports the group with its
+// ID and detects servers assigning their own
+func TestGroupsClient_CreateWithIDWithServer(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      string
+		importStatus int
+		assignedID   string
+		wantErr      error
+		wantStatus   int
+	}{
+		{name: "ID honored", version: "24.0.5", importStatus: http.StatusOK, assignedID: "fixed-id"},
+		{name: "ID ignored", version: "24.0.5", importStatus: http.StatusOK, assignedID: "server-id", wantErr: ErrUnsupportedServer},
+		{name: "server too old", version: "19.0.3", wantErr: ErrUnsupportedServer},
+		{name: "name taken", version: "24.0.5", importStatus: http.StatusConflict, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/admin/serverinfo":
+					w.Write([]byte(`{"systemInfo":{"version":"` + tt.version + `"}}`))
+				case "/admin/realms/test-realm/partialImport":
+					var req PartialImportRequest
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+					assert.Equal(t, PartialImportFail, req.IfResourceExists)
+					require.Len(t, req.Groups, 1)
+					assert.Equal(t, "fixed-id", ptr.ToString(req.Groups[0].ID))
+					assert.Equal(t, map[string][]string{"code": {"x"}}, *req.Groups[0].Attributes)
+					assert.Nil(t, req.Groups[0].SubGroups)
+
+					w.WriteHeader(tt.importStatus)
+					if tt.importStatus == http.StatusOK {
+						fmt.Fprintf(w, `{"added":1,"results":[{"action":"ADDED","resourceType":"GROUP","resourceName":"team","id":%q}]}`, tt.assignedID)
+					}
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			err := client.Groups.CreateWithID(context.Background(), Group{
+				ID:         ptr.String("fixed-id"),
+				Name:       ptr.String("team"),
+				Attributes: &map[string][]string{"code": {"x"}},
+				SubGroups:  &[]*Group{{Name: ptr.String("child")}},
+			})
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+				if tt.assignedID != "" {
+					assert.ErrorContains(t, err, tt.assignedID)
+				}
+			case tt.wantStatus != 0:
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.wantStatus, apiErr.StatusCode)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("ID and name required", func(t *testing.T) {
+		client := newTestClient("http://localhost")
+		assert.Error(t, client.Groups.CreateWithID(context.Background(), Group{Name: ptr.String("team")}))
+		assert.Error(t, client.Groups.CreateWithID(context.Background(), Group{ID: ptr.String("fixed-id")}))
+	})
+}
+
 // TestGroupsClient_CreateSubGroupWithServer tests CreateSubGroup with a mock HTTP server
 func TestGroupsClient_CreateSubGroupWithServer(t *testing.T) {
 	tests := []struct {
@@ -994,7 +1223,9 @@ func TestGroupsClient_CreateSubGroupWithServer(t *testing.T) {
 		attributes     map[string][]string
 		mockStatusCode int
 		mockLocation   string
+		mockBody       string
 		wantErr        bool
+		wantNotFound   bool
 		wantID         string
 	}{
 		{
@@ -1012,6 +1243,33 @@ func TestGroupsClient_CreateSubGroupWithServer(t *testing.T) {
 			parentID:       "missing-parent",
 			subGroupName:   "Child Group",
 			mockStatusCode: http.StatusNotFound,
+			mockBody:       `{"error":"Could not find group by id"}`,
+			wantErr:        true,
+			wantNotFound:   true,
+		},
+		{
+			name:           "parent not found as bad request",
+			parentID:       "missing-parent",
+			subGroupName:   "Child Group",
+			mockStatusCode: http.StatusBadRequest,
+			mockBody:       `{"errorMessage":"Could not find parent"}`,
+			wantErr:        true,
+			wantNotFound:   true,
+		},
+		{
+			name:           "other bad request",
+			parentID:       "parent-1",
+			subGroupName:   "Child Group",
+			mockStatusCode: http.StatusBadRequest,
+			mockBody:       `{"errorMessage":"Group name is missing"}`,
+			wantErr:        true,
+		},
+		{
+			name:           "sibling name conflict",
+			parentID:       "parent-1",
+			subGroupName:   "Child Group",
+			mockStatusCode: http.StatusConflict,
+			mockBody:       `{"errorMessage":"Sibling group named 'Child Group' already exists."}`,
 			wantErr:        true,
 		},
 	}
@@ -1027,7 +1285,11 @@ func TestGroupsClient_CreateSubGroupWithServer(t *testing.T) {
 				if tt.mockLocation != "" {
 					w.Header().Set("Location", serverURL+tt.mockLocation)
 				}
+				if tt.mockBody != "" {
+					w.Header().Set("Content-Type", "application/json")
+				}
 				w.WriteHeader(tt.mockStatusCode)
+				w.Write([]byte(tt.mockBody))
 			}))
 			defer server.Close()
 			serverURL = server.URL
@@ -1048,6 +1310,12 @@ func TestGroupsClient_CreateSubGroupWithServer(t *testing.T) {
 
 			if tt.wantErr {
 				assert.Error(t, err)
+				assert.Equal(t, tt.wantNotFound, errors.Is(err, ErrGroupNotFound))
+				var apiErr *APIError
+				assert.Equal(t, !tt.wantNotFound, errors.As(err, &apiErr))
+				if tt.mockStatusCode == http.StatusConflict {
+					assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.wantID, subGroupID)
@@ -1055,3 +1323,2559 @@ func TestGroupsClient_CreateSubGroupWithServer(t *testing.T) {
 		})
 	}
 }
+
+// TestGroupsClient_CreateSubGroupRelocate tests that attributes are applied with a follow-up
+// update when Keycloak relocates an existing group instead of creating one
+func TestGroupsClient_CreateSubGroupRelocate(t *testing.T) {
+	var updated Group
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/admin/realms/test-realm/groups/parent-1/children":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && r.URL.Path == "/admin/realms/test-realm/groups/parent-1/children":
+			assert.Equal(t, "true", r.URL.Query().Get("exact"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"id":"other","name":"child-2"},{"id":"child-id","name":"child"}]`))
+		case r.Method == http.MethodPut && r.URL.Path == "/admin/realms/test-realm/groups/child-id":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&updated))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	ctx := context.Background()
+
+	id, err := client.Groups.CreateSubGroup(ctx, "parent-1", "child", map[string][]string{"code": {"x"}})
+	require.NoError(t, err)
+	assert.Equal(t, "child-id", id)
+	assert.Equal(t, "child", ptr.ToString(updated.Name))
+	require.NotNil(t, updated.Attributes)
+	assert.Equal(t, map[string][]string{"code": {"x"}}, *updated.Attributes)
+
+	// Without attributes, the relocate is not followed up
+	id, err = client.Groups.CreateSubGroup(ctx, "parent-1", "child", nil)
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	_, err = client.Groups.CreateSubGroup(ctx, "parent-1", "missing", map[string][]string{"code": {"x"}})
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+}
+
+// TestGroupsClient_AncestryWithServer tests Ancestry following ParentID, and following the
+// path on servers not reporting ParentID
+func TestGroupsClient_AncestryWithServer(t *testing.T) {
+	tests := []struct {
+		name      string
+		parentIDs bool
+		groupID   string
+		want      []string
+		wantErr   error
+	}{
+		{name: "nested group", parentIDs: true, groupID: "team", want: []string{"org", "dept"}},
+		{name: "direct child", parentIDs: true, groupID: "dept", want: []string{"org"}},
+		{name: "top-level group", parentIDs: true, groupID: "org", want: []string{}},
+		{name: "nested group by path", groupID: "team", want: []string{"org", "dept"}},
+		{name: "top-level group by path", groupID: "org", want: []string{}},
+		{name: "unknown group", parentIDs: true, groupID: "missing", wantErr: ErrGroupNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := map[string]*Group{
+				"org":  {ID: ptr.String("org"), Name: ptr.String("org"), Path: ptr.String("/org")},
+				"dept": {ID: ptr.String("dept"), Name: ptr.String("r&d/ops"), Path: ptr.String("/org/r&d~/ops"), ParentID: ptr.String("org")},
+				"team": {ID: ptr.String("team"), Name: ptr.String("team"), Path: ptr.String("/org/r&d~/ops/team"), ParentID: ptr.String("dept")},
+			}
+			if !tt.parentIDs {
+				for _, group := range groups {
+					group.ParentID = nil
+				}
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				rest := strings.TrimPrefix(r.URL.Path, "/admin/realms/test-realm/groups")
+				search := r.URL.Query().Get("search")
+				switch id := strings.Trim(rest, "/"); {
+				case rest == "":
+					assert.Equal(t, "org", search)
+					// Exact search results embed the matching subtree
+					json.NewEncoder(w).Encode([]*Group{{ID: ptr.String("org"), Name: ptr.String("org"), SubGroups: &[]*Group{groups["dept"]}}})
+				case strings.HasSuffix(id, "/children"):
+					assert.Equal(t, "org/children", id)
+					assert.Equal(t, "r&d/ops", search)
+					json.NewEncoder(w).Encode([]*Group{groups["dept"]})
+				case groups[id] != nil:
+					json.NewEncoder(w).Encode(groups[id])
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			ancestry, err := client.Groups.Ancestry(context.Background(), tt.groupID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			ids := []string{}
+			for _, group := range ancestry {
+				ids = append(ids, *group.ID)
+				assert.Nil(t, group.SubGroups)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestSplitGroupPath(t *testing.T) {
+	assert.Equal(t, []string{"org"}, splitGroupPath("/org"))
+	assert.Equal(t, []string{"org", "r&d/ops", "team"}, splitGroupPath("/org/r&d~/ops/team"))
+	assert.Equal(t, []string{"a~b", "c"}, splitGroupPath("/a~b/c/"))
+}
+
+// TestGroupsClient_GetWithSubGroupsWithServer tests GetWithSubGroups with a mock HTTP server
+func TestGroupsClient_GetWithSubGroupsWithServer(t *testing.T) {
+	children := map[string][]*Group{
+		"root": {
+			{ID: ptr.String("child-1"), Name: ptr.String("Child 1"), SubGroupCount: ptr.Int64(1)},
+			{ID: ptr.String("child-2"), Name: ptr.String("Child 2"), SubGroupCount: ptr.Int64(0)},
+		},
+		"child-1": {
+			{ID: ptr.String("grandchild-1"), Name: ptr.String("Grandchild 1"), SubGroupCount: ptr.Int64(0)},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		depth            int
+		wantChildren     int
+		wantGrandchild   bool
+		wantChildrenCall int
+	}{
+		{
+			name:             "depth zero fetches group only",
+			depth:            0,
+			wantChildren:     0,
+			wantChildrenCall: 0,
+		},
+		{
+			name:             "depth one fetches direct children",
+			depth:            1,
+			wantChildren:     2,
+			wantChildrenCall: 1,
+		},
+		{
+			name:             "negative depth fetches whole subtree",
+			depth:            -1,
+			wantChildren:     2,
+			wantGrandchild:   true,
+			wantChildrenCall: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			childrenCalls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.URL.Path == "/admin/realms/test-realm/groups/root":
+					json.NewEncoder(w).Encode(Group{ID: ptr.String("root"), Name: ptr.String("Root")})
+				case strings.HasSuffix(r.URL.Path, "/children"):
+					childrenCalls++
+					parentID := path.Base(path.Dir(r.URL.Path))
+					json.NewEncoder(w).Encode(children[parentID])
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			client := &Client{
+				baseURL:  server.URL,
+				realm:    "test-realm",
+				pageSize: 50,
+				maxPages: 10,
+				resty:    newTestRestyClient(),
+			}
+			gc := &groupsClient{
+				client: client,
+			}
+
+			group, err := gc.GetWithSubGroups(context.Background(), "root", tt.depth)
+
+			require.NoError(t, err)
+			require.NotNil(t, group.SubGroups)
+			assert.Len(t, *group.SubGroups, tt.wantChildren)
+			assert.Equal(t, tt.wantChildrenCall, childrenCalls)
+			if tt.wantGrandchild {
+				child := (*group.SubGroups)[0]
+				require.NotNil(t, child.SubGroups)
+				assert.Len(t, *child.SubGroups, 1)
+				assert.Equal(t, "grandchild-1", *(*child.SubGroups)[0].ID)
+			}
+		})
+	}
+}
+
+// TestGroupsClient_GetWithSubGroupsNotFound tests that a missing root group is reported as not found
+func TestGroupsClient_GetWithSubGroupsNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:  server.URL,
+		realm:    "test-realm",
+		pageSize: 50,
+		resty:    newTestRestyClient(),
+	}
+	gc := &groupsClient{
+		client: client,
+	}
+
+	group, err := gc.GetWithSubGroups(context.Background(), "missing", -1)
+
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+	assert.Nil(t, group)
+}
+
+// TestGroupsClient_SubGroupsParentNotFound tests that both subgroup list methods
+// report a missing parent group with ErrGroupNotFound
+func TestGroupsClient_SubGroupsParentNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/children")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(HTTPErrorResponse{Error: "Could not find group by id"})
+	}))
+	defer server.Close()
+
+	client := &Client{
+		baseURL:  server.URL,
+		realm:    "test-realm",
+		pageSize: 50,
+		maxPages: 10,
+		resty:    newTestRestyClient(),
+	}
+	gc := &groupsClient{
+		client: client,
+	}
+	ctx := context.Background()
+
+	t.Run("ListSubGroups", func(t *testing.T) {
+		groups, err := gc.ListSubGroups(ctx, "missing-parent")
+		assert.True(t, errors.Is(err, ErrGroupNotFound))
+		assert.Nil(t, groups)
+	})
+
+	t.Run("ListSubGroupsPaginated", func(t *testing.T) {
+		groups, err := gc.ListSubGroupsPaginated(ctx, "missing-parent", SubGroupSearchParams{Max: ptr.Int(10)})
+		assert.True(t, errors.Is(err, ErrGroupNotFound))
+		assert.Nil(t, groups)
+	})
+}
+
+// TestGroupsClient_GetByAttributeStrictWithServer tests that strict attribute matching
+// distinguishes ambiguous multi-value matches from missing groups
+func TestGroupsClient_GetByAttributeStrictWithServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("q") != "team:alpha" {
+			json.NewEncoder(w).Encode([]*Group{})
+			return
+		}
+		json.NewEncoder(w).Encode([]*Group{
+			{ID: ptr.String("g1"), Attributes: &map[string][]string{"team": {"alpha", "beta"}}},
+		})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	t.Run("default mode matches multi-value attribute", func(t *testing.T) {
+		client := newTestClient(server.URL)
+		group, err := client.Groups.GetByAttribute(ctx, &GroupAttribute{Key: "team", Value: "alpha"})
+		require.NoError(t, err)
+		assert.Equal(t, "g1", *group.ID)
+	})
+
+	t.Run("strict mode reports ambiguity", func(t *testing.T) {
+		client := newTestClient(server.URL, WithStrictAttributeMatching(true))
+		group, err := client.Groups.GetByAttribute(ctx, &GroupAttribute{Key: "team", Value: "alpha"})
+		assert.ErrorIs(t, err, ErrAmbiguousAttribute)
+		assert.NotErrorIs(t, err, ErrGroupNotFound)
+		assert.Nil(t, group)
+	})
+
+	t.Run("strict mode reports not found", func(t *testing.T) {
+		client := newTestClient(server.URL, WithStrictAttributeMatching(true))
+		group, err := client.Groups.GetByAttribute(ctx, &GroupAttribute{Key: "team", Value: "gamma"})
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+		assert.Nil(t, group)
+	})
+}
+
+// TestGroupsClient_GetByAttributeSingleRequest tests that GetByAttribute relies on a single q
+// search and still drops groups returned by the server that do not hold the value themselves
+func TestGroupsClient_GetByAttributeSingleRequest(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "/admin/realms/test-realm/groups", r.URL.Path)
+		assert.Empty(t, r.URL.Query().Get("first"))
+		w.Header().Set("Content-Type", "application/json")
+		// q returns the top-level groups whose hierarchy matches, not only exact holders
+		json.NewEncoder(w).Encode([]*Group{
+			{ID: ptr.String("parent"), Attributes: &map[string][]string{"team": {"alpha-2"}}},
+			{ID: ptr.String("holder"), Attributes: &map[string][]string{"team": {r.URL.Query().Get("q")[len("team:"):]}}},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	group, err := client.Groups.GetByAttribute(context.Background(), &GroupAttribute{Key: "team", Value: "alpha"})
+	require.NoError(t, err)
+	assert.Equal(t, "holder", ptr.ToString(group.ID))
+	assert.Equal(t, int32(1), requests.Load())
+
+	_, err = client.Groups.GetByAttribute(context.Background(), &GroupAttribute{Key: "other", Value: "alpha"})
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+// TestGroupsClient_ListSortedWithServer tests ListSorted with default and custom comparators
+func TestGroupsClient_ListSortedWithServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*Group{
+			{ID: ptr.String("g1"), Name: ptr.String("charlie"), Path: ptr.String("/a/charlie")},
+			{ID: ptr.String("g2"), Name: ptr.String("alpha"), Path: ptr.String("/c/alpha")},
+			{ID: ptr.String("g3"), Name: ptr.String("bravo"), Path: ptr.String("/b/bravo")},
+		})
+	}))
+	defer server.Close()
+
+	ids := func(groups []*Group) []string {
+		var result []string
+		for _, group := range groups {
+			result = append(result, *group.ID)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		less    func(a, b *Group) bool
+		wantIDs []string
+	}{
+		{
+			name:    "nil comparator sorts by name",
+			less:    nil,
+			wantIDs: []string{"g2", "g3", "g1"},
+		},
+		{
+			name:    "sort by path",
+			less:    GroupsByPath,
+			wantIDs: []string{"g1", "g3", "g2"},
+		},
+		{
+			name: "custom comparator sorts by name descending",
+			less: func(a, b *Group) bool {
+				return *a.Name > *b.Name
+			},
+			wantIDs: []string{"g1", "g3", "g2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(server.URL)
+			groups, err := client.Groups.ListSorted(context.Background(), SearchGroupParams{}, tt.less)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantIDs, ids(groups))
+		})
+	}
+}
+
+// TestGroupsClient_ExistsWithServer tests Exists with a mock HTTP server
+// TestGroupsClient_GetRawWithServer tests that GetRaw returns the typed group and the
+// raw body unchanged, including fields not modeled by Group
+func TestGroupsClient_GetRawWithServer(t *testing.T) {
+	body := `{"id":"group-1","name":"team","futureField":{"enabled":true},"attributes":{"dept":["eng"]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		if r.URL.Path != "/admin/realms/test-realm/groups/group-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	group, raw, err := client.Groups.GetRaw(context.Background(), "group-1")
+	require.NoError(t, err)
+	assert.Equal(t, "group-1", *group.ID)
+	assert.Equal(t, "team", *group.Name)
+	assert.Equal(t, body, string(raw))
+
+	var extra struct {
+		FutureField struct {
+			Enabled bool `json:"enabled"`
+		} `json:"futureField"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &extra))
+	assert.True(t, extra.FutureField.Enabled)
+
+	_, raw, err = client.Groups.GetRaw(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+	assert.Nil(t, raw)
+
+	_, _, err = client.Groups.GetRaw(context.Background(), "")
+	assert.Error(t, err)
+}
+
+// TestGroupsClient_GetByNameWithServer tests GetByName with zero, one and several groups
+// having the name, including subgroups returned inside the matching hierarchies
+func TestGroupsClient_GetByNameWithServer(t *testing.T) {
+	subGroup := func(id, name, path string) *Group {
+		return &Group{ID: ptr.String(id), Name: ptr.String(name), Path: ptr.String(path)}
+	}
+	tests := []struct {
+		name    string
+		groups  []*Group
+		wantID  string
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:    "no match",
+			groups:  []*Group{},
+			wantErr: ErrGroupNotFound,
+		},
+		{
+			name:    "only substring matches",
+			groups:  []*Group{subGroup("g1", "teams", "/teams")},
+			wantErr: ErrGroupNotFound,
+		},
+		{
+			name:   "top-level match",
+			groups: []*Group{subGroup("g1", "team", "/team")},
+			wantID: "g1",
+		},
+		{
+			name: "subgroup match",
+			groups: []*Group{{
+				ID: ptr.String("p1"), Name: ptr.String("org"), Path: ptr.String("/org"),
+				SubGroups: &[]*Group{subGroup("g2", "team", "/org/team")},
+			}},
+			wantID: "g2",
+		},
+		{
+			name: "several matches",
+			groups: []*Group{
+				subGroup("g1", "team", "/team"),
+				{
+					ID: ptr.String("p1"), Name: ptr.String("org"), Path: ptr.String("/org"),
+					SubGroups: &[]*Group{subGroup("g2", "team", "/org/team")},
+				},
+			},
+			wantErr: ErrAmbiguousGroupName,
+			wantMsg: `2 groups named "team" (/team, /org/team)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/groups", r.URL.Path)
+				assert.Equal(t, "team", r.URL.Query().Get("search"))
+				assert.Equal(t, "true", r.URL.Query().Get("exact"))
+				assert.Equal(t, "false", r.URL.Query().Get("briefRepresentation"))
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(tt.groups)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			group, err := client.Groups.GetByName(context.Background(), "team")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorContains(t, err, tt.wantMsg)
+				assert.Nil(t, group)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantID, *group.ID)
+		})
+	}
+
+	t.Run("empty name", func(t *testing.T) {
+		_, err := newTestClient("http://localhost").Groups.GetByName(context.Background(), "")
+		assert.Error(t, err)
+	})
+}
+
+func TestGroupsClient_ExistsWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		want           bool
+		wantErr        bool
+	}{
+		{
+			name:           "group exists",
+			mockStatusCode: http.StatusOK,
+			want:           true,
+		},
+		{
+			name:           "group does not exist",
+			mockStatusCode: http.StatusNotFound,
+			want:           false,
+		},
+		{
+			name:           "server error",
+			mockStatusCode: http.StatusInternalServerError,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				assert.Equal(t, "/admin/realms/test-realm/groups/group-1", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.mockStatusCode)
+				if tt.mockStatusCode == http.StatusOK {
+					json.NewEncoder(w).Encode(Group{ID: ptr.String("group-1")})
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			exists, err := client.Groups.Exists(context.Background(), "group-1")
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, exists)
+		})
+	}
+}
+
+// TestGroupsClient_BuildAttributeIndexWithServer tests that BuildAttributeIndex pages through all groups
+func TestGroupsClient_BuildAttributeIndexWithServer(t *testing.T) {
+	pages := [][]*Group{
+		{
+			{ID: ptr.String("g1"), Attributes: &map[string][]string{"code": {"a"}}},
+			{ID: ptr.String("g2"), Attributes: &map[string][]string{"code": {"b", "c"}}},
+		},
+		{
+			{ID: ptr.String("g3"), Attributes: &map[string][]string{"other": {"d"}}, SubGroups: &[]*Group{
+				{ID: ptr.String("g4"), Attributes: &map[string][]string{"code": {"e"}}},
+			}},
+			{ID: ptr.String("g5"), Attributes: &map[string][]string{"code": {"a"}}},
+		},
+		{},
+	}
+
+	var firsts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		firsts = append(firsts, query.Get("first"))
+		assert.Equal(t, "2", query.Get("max"))
+		assert.Equal(t, "false", query.Get("briefRepresentation"))
+
+		first, _ := strconv.Atoi(query.Get("first"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pages[first/2])
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithPageSize(2))
+	index, err := client.Groups.BuildAttributeIndex(context.Background(), "code")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "2", "4"}, firsts)
+	assert.Len(t, index, 4)
+	assert.Equal(t, "g1", *index["a"].ID, "first group with a value wins")
+	assert.Equal(t, "g2", *index["b"].ID)
+	assert.Equal(t, "g2", *index["c"].ID)
+	assert.Equal(t, "g4", *index["e"].ID)
+}
+
+// TestGroupsClient_CreateConflictExistingID tests that a conflicting Create reports the ID of the existing group
+func TestGroupsClient_CreateConflictExistingID(t *testing.T) {
+	tests := []struct {
+		name       string
+		listBody   string
+		listStatus int
+		wantID     string
+	}{
+		{
+			name:       "existing group found",
+			listBody:   `[{"id":"parent-id","name":"Parent","subGroups":[{"id":"sub-id","name":"Team"}]},{"id":"existing-id","name":"Team"}]`,
+			listStatus: http.StatusOK,
+			wantID:     "existing-id",
+		},
+		{
+			name:       "existing group not visible",
+			listBody:   `[]`,
+			listStatus: http.StatusOK,
+		},
+		{
+			name:       "lookup fails",
+			listBody:   `{"error":"unknown_error"}`,
+			listStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/groups", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				switch r.Method {
+				case http.MethodPost:
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(`{"errorMessage":"Top level group named 'Team' already exists."}`))
+				case http.MethodGet:
+					assert.Equal(t, "Team", r.URL.Query().Get("search"))
+					assert.Equal(t, "true", r.URL.Query().Get("exact"))
+					w.WriteHeader(tt.listStatus)
+					w.Write([]byte(tt.listBody))
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			id, err := client.Groups.Create(context.Background(), "Team", nil)
+
+			assert.Empty(t, id)
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+			assert.Equal(t, "Top level group named 'Team' already exists.", apiErr.Response.Message)
+			assert.Equal(t, tt.wantID, apiErr.ExistingID)
+		})
+	}
+}
+
+// TestGroupsClient_FindSubGroupsByAttributeWithServer tests that FindSubGroupsByAttribute pages through the q search and returns the matching subgroups
+func TestGroupsClient_FindSubGroupsByAttributeWithServer(t *testing.T) {
+	pages := [][]*Group{
+		{
+			{ID: ptr.String("p1"), SubGroups: &[]*Group{
+				{ID: ptr.String("s1"), Attributes: &map[string][]string{"code": {"x"}}},
+			}},
+			{ID: ptr.String("p2"), Attributes: &map[string][]string{"code": {"x"}}},
+		},
+		{
+			{ID: ptr.String("p3"), SubGroups: &[]*Group{
+				{ID: ptr.String("s2"), SubGroups: &[]*Group{
+					{ID: ptr.String("s3"), Attributes: &map[string][]string{"code": {"x"}}},
+				}},
+			}},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "code:x", query.Get("q"))
+		assert.Equal(t, "true", query.Get("populateHierarchy"))
+		assert.Equal(t, "false", query.Get("briefRepresentation"))
+
+		first, _ := strconv.Atoi(query.Get("first"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pages[first/2])
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithPageSize(2))
+	groups, err := client.Groups.FindSubGroupsByAttribute(context.Background(), GroupAttribute{Key: "code", Value: "x"})
+
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "s1", *groups[0].ID)
+	assert.Equal(t, "p1", *groups[0].ParentID)
+	assert.Equal(t, "s3", *groups[1].ID)
+	assert.Equal(t, "s2", *groups[1].ParentID)
+
+	_, err = client.Groups.FindSubGroupsByAttribute(context.Background(), GroupAttribute{Value: "x"})
+	assert.Error(t, err)
+}
+
+// TestGroupsClient_BuildAttributeIndexError tests that list errors are propagated
+func TestGroupsClient_BuildAttributeIndexError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+
+	index, err := client.Groups.BuildAttributeIndex(context.Background(), "code")
+	assert.Error(t, err)
+	assert.Nil(t, index)
+
+	index, err = client.Groups.BuildAttributeIndex(context.Background(), "")
+	assert.Error(t, err)
+	assert.Nil(t, index)
+}
+
+// TestGroupsClient_GetRoleMappingsWithServer tests GetRoleMappings against a mock HTTP server
+func TestGroupsClient_GetRoleMappingsWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		mockBody       string
+		wantErr        error
+		wantRealm      []string
+		wantClients    map[string][]string
+	}{
+		{
+			name:           "realm and client mappings",
+			mockStatusCode: http.StatusOK,
+			mockBody: `{
+				"realmMappings": [{"id": "r1", "name": "admin"}],
+				"clientMappings": {"account": {"id": "c-1", "client": "account", "mappings": [{"id": "r2", "name": "view-profile", "clientRole": true}]}}
+			}`,
+			wantRealm:   []string{"admin"},
+			wantClients: map[string][]string{"account": {"view-profile"}},
+		},
+		{
+			name:           "no mappings",
+			mockStatusCode: http.StatusOK,
+			mockBody:       `{}`,
+			wantRealm:      []string{},
+			wantClients:    map[string][]string{},
+		},
+		{
+			name:           "group not found",
+			mockStatusCode: http.StatusNotFound,
+			wantErr:        ErrGroupNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/groups/g1/role-mappings", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.mockStatusCode)
+				w.Write([]byte(tt.mockBody))
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			mappings, err := client.Groups.GetRoleMappings(context.Background(), "g1")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, mappings)
+				return
+			}
+
+			require.NoError(t, err)
+			realm := []string{}
+			for _, role := range mappings.RealmMappings {
+				realm = append(realm, *role.Name)
+			}
+			clients := map[string][]string{}
+			for clientID, roles := range mappings.ClientMappings {
+				for _, role := range roles {
+					clients[clientID] = append(clients[clientID], *role.Name)
+				}
+			}
+			assert.Equal(t, tt.wantRealm, realm)
+			assert.Equal(t, tt.wantClients, clients)
+			assert.NotNil(t, mappings.RealmMappings)
+			assert.NotNil(t, mappings.ClientMappings)
+		})
+	}
+}
+
+// TestGroupsClient_BuildAttributeIndexPageLimit tests that auto-pagination stops at the page limit
+// when the server keeps returning full pages
+func TestGroupsClient_BuildAttributeIndexPageLimit(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*Group{{ID: ptr.String("g1")}, {ID: ptr.String("g2")}})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithPageSize(2), WithMaxPages(3))
+	index, err := client.Groups.BuildAttributeIndex(context.Background(), "code")
+
+	assert.ErrorIs(t, err, ErrPageLimitExceeded)
+	assert.Nil(t, index)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+// TestGroupsClient_CreateWithDefaultAttributes tests that default attributes are merged into created groups
+func TestGroupsClient_CreateWithDefaultAttributes(t *testing.T) {
+	defaults := map[string][]string{
+		"managedBy": {"automation"},
+		"env":       {"prod"},
+	}
+
+	tests := []struct {
+		name       string
+		attributes map[string][]string
+		want       map[string][]string
+	}{
+		{
+			name:       "nil attributes get defaults",
+			attributes: nil,
+			want:       map[string][]string{"managedBy": {"automation"}, "env": {"prod"}},
+		},
+		{
+			name:       "caller attributes are merged",
+			attributes: map[string][]string{"team": {"core"}},
+			want:       map[string][]string{"managedBy": {"automation"}, "env": {"prod"}, "team": {"core"}},
+		},
+		{
+			name:       "caller keys override defaults",
+			attributes: map[string][]string{"env": {"staging"}},
+			want:       map[string][]string{"managedBy": {"automation"}, "env": {"staging"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []Group
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var group Group
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&group))
+				bodies = append(bodies, group)
+				w.Header().Set("Location", "/admin/realms/test-realm/groups/new-id")
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL, WithDefaultAttributes(defaults))
+			ctx := context.Background()
+
+			_, err := client.Groups.Create(ctx, "group", tt.attributes)
+			require.NoError(t, err)
+			_, err = client.Groups.CreateSubGroup(ctx, "parent", "child", tt.attributes)
+			require.NoError(t, err)
+
+			require.Len(t, bodies, 2)
+			for _, body := range bodies {
+				require.NotNil(t, body.Attributes)
+				assert.Equal(t, tt.want, *body.Attributes)
+			}
+			assert.NotContains(t, tt.attributes, "managedBy", "caller map must not be modified")
+		})
+	}
+}
+
+// TestGroupsClient_DetachWithServer tests that Detach posts the existing group to the top-level endpoint
+func TestGroupsClient_DetachWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		getStatusCode  int
+		postStatusCode int
+		wantErr        error
+		wantPost       bool
+	}{
+		{
+			name:           "subgroup detached",
+			getStatusCode:  http.StatusOK,
+			postStatusCode: http.StatusNoContent,
+			wantPost:       true,
+		},
+		{
+			name:          "group not found",
+			getStatusCode: http.StatusNotFound,
+			wantErr:       ErrGroupNotFound,
+		},
+		{
+			name:           "name conflict",
+			getStatusCode:  http.StatusOK,
+			postStatusCode: http.StatusConflict,
+			wantPost:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted *Group
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/admin/realms/test-realm/groups/child":
+					w.WriteHeader(tt.getStatusCode)
+					json.NewEncoder(w).Encode(Group{
+						ID:         ptr.String("child"),
+						Name:       ptr.String("child"),
+						Path:       ptr.String("/parent/child"),
+						ParentID:   ptr.String("parent"),
+						Attributes: &map[string][]string{"code": {"c"}},
+					})
+				case r.Method == http.MethodPost && r.URL.Path == "/admin/realms/test-realm/groups":
+					posted = &Group{}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(posted))
+					w.WriteHeader(tt.postStatusCode)
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			err := client.Groups.Detach(context.Background(), "child")
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.postStatusCode >= 300:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+
+			if !tt.wantPost {
+				assert.Nil(t, posted)
+				return
+			}
+			require.NotNil(t, posted)
+			assert.Equal(t, "child", *posted.ID)
+			assert.Equal(t, "child", *posted.Name)
+			assert.Nil(t, posted.ParentID)
+			assert.Nil(t, posted.Path)
+			assert.Equal(t, []string{"c"}, (*posted.Attributes)["code"])
+		})
+	}
+}
+
+// TestGroupsClient_EnableManagementPermissionsAndWaitWithServer tests that the permissions are
+// polled until Keycloak reports the resource
+func TestGroupsClient_EnableManagementPermissionsAndWaitWithServer(t *testing.T) {
+	newServer := func(readyAfter int32) (*httptest.Server, *atomic.Int32) {
+		var polls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/admin/realms/test-realm/groups/g1/management/permissions", r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			switch r.Method {
+			case http.MethodPut:
+				var ref ManagementPermissionReference
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&ref))
+				assert.True(t, *ref.Enabled)
+				w.Write([]byte(`{"enabled":true}`))
+			case http.MethodGet:
+				if polls.Add(1) < readyAfter {
+					w.Write([]byte(`{"enabled":true}`))
+					return
+				}
+				w.Write([]byte(`{"enabled":true,"resource":"res-1","scopePermissions":{"view":"perm-1"}}`))
+			}
+		}))
+		return server, &polls
+	}
+
+	t.Run("resource after a few polls", func(t *testing.T) {
+		server, polls := newServer(3)
+		defer server.Close()
+
+		ref, err := newTestClient(server.URL).Groups.EnableManagementPermissionsAndWait(context.Background(), "g1", 5*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "res-1", *ref.Resource)
+		assert.Equal(t, map[string]string{"view": "perm-1"}, *ref.ScopePermissions)
+		assert.Equal(t, int32(3), polls.Load())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server, _ := newServer(math.MaxInt32)
+		defer server.Close()
+
+		_, err := newTestClient(server.URL).Groups.EnableManagementPermissionsAndWait(context.Background(), "g1", 250*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		_, err := newTestClient("http://localhost").Groups.EnableManagementPermissionsAndWait(context.Background(), "g1", 0)
+		assert.Error(t, err)
+	})
+}
+
+// TestGroupsClient_PermissionsEnabledWithServer tests PermissionsEnabled for enabled, disabled and omitted values
+func TestGroupsClient_PermissionsEnabledWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		mockBody       string
+		want           bool
+		wantErr        bool
+	}{
+		{
+			name:           "enabled",
+			mockStatusCode: http.StatusOK,
+			mockBody:       `{"enabled": true, "resource": "res-1"}`,
+			want:           true,
+		},
+		{
+			name:           "disabled",
+			mockStatusCode: http.StatusOK,
+			mockBody:       `{"enabled": false}`,
+			want:           false,
+		},
+		{
+			name:           "omitted",
+			mockStatusCode: http.StatusOK,
+			mockBody:       `{}`,
+			want:           false,
+		},
+		{
+			name:           "server error",
+			mockStatusCode: http.StatusInternalServerError,
+			mockBody:       `{"error": "unknown_error"}`,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/groups/g1/management/permissions", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.mockStatusCode)
+				w.Write([]byte(tt.mockBody))
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			enabled, err := client.Groups.PermissionsEnabled(context.Background(), "g1")
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.False(t, enabled)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, enabled)
+		})
+	}
+}
+
+// newReconcileServer serves the direct members of group g1 and applies membership changes,
+// failing requests for the users in fail.
+func newReconcileServer(t *testing.T, members []string, fail map[string]bool) (*httptest.Server, *sync.Mutex, map[string]bool) {
+	t.Helper()
+	var mu sync.Mutex
+	current := make(map[string]bool, len(members))
+	for _, id := range members {
+		current[id] = true
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == http.MethodGet && r.URL.Path == "/admin/realms/test-realm/groups/g1/members" {
+			var users []*User
+			for id := range current {
+				users = append(users, &User{ID: ptr.String(id)})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(users)
+			return
+		}
+
+		dir, groupID := path.Split(r.URL.Path)
+		userID := path.Base(path.Dir(path.Dir(dir)))
+		require.Equal(t, "g1", groupID)
+		if fail[userID] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			current[userID] = true
+		case http.MethodDelete:
+			delete(current, userID)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &mu, current
+}
+
+// TestGroupsClient_ReconcileMembersWithServer tests that ReconcileMembers applies the membership diff and is idempotent
+func TestGroupsClient_ReconcileMembersWithServer(t *testing.T) {
+	server, mu, current := newReconcileServer(t, []string{"u1", "u2", "u3"}, nil)
+	client := newTestClient(server.URL, WithReconcileConcurrency(2))
+
+	added, removed, err := client.Groups.ReconcileMembers(context.Background(), "g1", []string{"u2", "u4", "u5"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u4", "u5"}, added)
+	assert.Equal(t, []string{"u1", "u3"}, removed)
+
+	mu.Lock()
+	assert.Equal(t, map[string]bool{"u2": true, "u4": true, "u5": true}, current)
+	mu.Unlock()
+
+	added, removed, err = client.Groups.ReconcileMembers(context.Background(), "g1", []string{"u5", "u4", "u2"})
+	require.NoError(t, err)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
+
+// TestGroupsClient_ReconcileMembersPartialFailure tests that successful changes are reported alongside failures
+func TestGroupsClient_ReconcileMembersPartialFailure(t *testing.T) {
+	server, _, _ := newReconcileServer(t, []string{"u1", "u2"}, map[string]bool{"u2": true, "u4": true})
+	client := newTestClient(server.URL)
+
+	added, removed, err := client.Groups.ReconcileMembers(context.Background(), "g1", []string{"u3", "u4"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"u3"}, added)
+	assert.Equal(t, []string{"u1"}, removed)
+	assert.Contains(t, err.Error(), "unable to add user to group")
+	assert.Contains(t, err.Error(), "unable to remove user from group")
+}
+
+// TestGroupsClient_ReconcileMembersCancelled tests that no changes are applied once the context is cancelled
+func TestGroupsClient_ReconcileMembersCancelled(t *testing.T) {
+	server, _, _ := newReconcileServer(t, []string{"u1"}, nil)
+	client := newTestClient(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	added, removed, err := client.Groups.ReconcileMembers(ctx, "g1", []string{"u2"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
+
+// TestGroupsClient_ReconcileMembersValidation tests parameter validation for ReconcileMembers
+func TestGroupsClient_ReconcileMembersValidation(t *testing.T) {
+	client := newTestClient("http://localhost")
+
+	_, _, err := client.Groups.ReconcileMembers(context.Background(), "", nil)
+	assert.Error(t, err)
+
+	_, _, err = client.Groups.ReconcileMembers(context.Background(), "g1", []string{"u1", ""})
+	assert.Error(t, err)
+}
+
+// treeServer is an in-memory group hierarchy serving the group endpoints used by ReconcileTree.
+type treeServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	groups map[string]*Group // by ID, without subgroups
+	parent map[string]string // parent ID by ID, empty for top-level groups
+	writes int               // number of POST, PUT and DELETE requests
+	nextID int
+}
+
+// newTreeServer returns a tree server holding the given top-level groups and their subgroups.
+func newTreeServer(t *testing.T, roots ...*Group) *treeServer {
+	t.Helper()
+	s := &treeServer{groups: map[string]*Group{}, parent: map[string]string{}}
+	var add func(parentID string, group *Group)
+	add = func(parentID string, group *Group) {
+		id := s.add(parentID, *group.Name, group.Attributes)
+		if group.SubGroups != nil {
+			for _, child := range *group.SubGroups {
+				add(id, child)
+			}
+		}
+	}
+	for _, root := range roots {
+		add("", root)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// add stores a new group and returns its ID.
+func (s *treeServer) add(parentID, name string, attributes *map[string][]string) string {
+	s.nextID++
+	id := fmt.Sprintf("g%d", s.nextID)
+	s.groups[id] = &Group{ID: ptr.String(id), Name: ptr.String(name), Attributes: attributes}
+	s.parent[id] = parentID
+	return id
+}
+
+// children returns the direct subgroups of a group, with their subgroup counts.
+func (s *treeServer) children(parentID string) []*Group {
+	var children []*Group
+	for id, group := range s.groups {
+		if s.parent[id] != parentID {
+			continue
+		}
+		child := *group
+		count := int64(len(s.childIDs(id)))
+		child.SubGroupCount = &count
+		children = append(children, &child)
+	}
+	return children
+}
+
+// childIDs returns the IDs of the direct subgroups of a group.
+func (s *treeServer) childIDs(parentID string) []string {
+	var ids []string
+	for id, parent := range s.parent {
+		if parent == parentID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// remove deletes a group and its subtree.
+func (s *treeServer) remove(id string) {
+	for _, child := range s.childIDs(id) {
+		s.remove(child)
+	}
+	delete(s.groups, id)
+	delete(s.parent, id)
+}
+
+func (s *treeServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method != http.MethodGet {
+		s.writes++
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/admin/realms/test-realm/groups")
+	groupID, resource, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+	if _, ok := s.groups[groupID]; groupID != "" && !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	create := func(parentID string) {
+		var group Group
+		json.NewDecoder(r.Body).Decode(&group)
+		id := s.add(parentID, *group.Name, group.Attributes)
+		w.Header().Set("Location", "http://"+r.Host+"/admin/realms/test-realm/groups/"+id)
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case groupID == "" && r.Method == http.MethodGet:
+		var matches []*Group
+		for _, group := range s.children("") {
+			if *group.Name == r.URL.Query().Get("search") {
+				matches = append(matches, group)
+			}
+		}
+		json.NewEncoder(w).Encode(matches)
+	case groupID == "" && r.Method == http.MethodPost:
+		create("")
+	case resource == "children" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(s.children(groupID))
+	case resource == "children" && r.Method == http.MethodPost:
+		create(groupID)
+	case r.Method == http.MethodPut:
+		var group Group
+		json.NewDecoder(r.Body).Decode(&group)
+		s.groups[groupID].Attributes = group.Attributes
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		s.remove(groupID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// tree returns the attributes of every group, keyed by path.
+func (s *treeServer) tree() map[string]map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tree := make(map[string]map[string][]string)
+	for id, group := range s.groups {
+		path := *group.Name
+		for parent := s.parent[id]; parent != ""; parent = s.parent[parent] {
+			path = *s.groups[parent].Name + "/" + path
+		}
+		var attributes map[string][]string
+		if group.Attributes != nil {
+			attributes = *group.Attributes
+		}
+		tree[path] = attributes
+	}
+	return tree
+}
+
+// treeGroup builds a desired group with attributes and subgroups.
+func treeGroup(name string, attributes map[string][]string, subGroups ...*Group) *Group {
+	group := &Group{Name: ptr.String(name)}
+	if attributes != nil {
+		group.Attributes = &attributes
+	}
+	if len(subGroups) > 0 {
+		group.SubGroups = &subGroups
+	}
+	return group
+}
+
+// TestGroupsClient_ReconcileTreeCreates tests that ReconcileTree creates a missing tree
+func TestGroupsClient_ReconcileTreeCreates(t *testing.T) {
+	server := newTreeServer(t, treeGroup("sales", nil))
+	client := newTestClient(server.URL)
+
+	desired := treeGroup("engineering", map[string][]string{"cost-center": {"42"}},
+		treeGroup("backend", map[string][]string{"oncall": {"yes"}},
+			treeGroup("databases", nil)),
+		treeGroup("frontend", nil))
+	require.NoError(t, client.Groups.ReconcileTree(context.Background(), "", desired, ReconcileTreeOptions{}))
+
+	assert.Equal(t, map[string]map[string][]string{
+		"sales":                         nil,
+		"engineering":                   {"cost-center": {"42"}},
+		"engineering/backend":           {"oncall": {"yes"}},
+		"engineering/backend/databases": nil,
+		"engineering/frontend":          nil,
+	}, server.tree())
+	assert.Equal(t, 4, server.writes)
+}
+
+// TestGroupsClient_ReconcileTreeUpdates tests that ReconcileTree updates attributes, prunes
+// extra subgroups only with Prune, leaves siblings alone and is idempotent
+func TestGroupsClient_ReconcileTreeUpdates(t *testing.T) {
+	actual := treeGroup("engineering", map[string][]string{"cost-center": {"7"}},
+		treeGroup("backend", map[string][]string{"oncall": {"yes"}}),
+		treeGroup("legacy", nil, treeGroup("old-team", nil)))
+	desired := treeGroup("engineering", map[string][]string{"cost-center": {"42"}},
+		treeGroup("backend", map[string][]string{"oncall": {"yes"}}),
+		treeGroup("frontend", nil))
+
+	t.Run("without prune", func(t *testing.T) {
+		server := newTreeServer(t, actual, treeGroup("sales", nil))
+		client := newTestClient(server.URL)
+
+		require.NoError(t, client.Groups.ReconcileTree(context.Background(), "", desired, ReconcileTreeOptions{}))
+		assert.Equal(t, map[string]map[string][]string{
+			"sales":                       nil,
+			"engineering":                 {"cost-center": {"42"}},
+			"engineering/backend":         {"oncall": {"yes"}},
+			"engineering/frontend":        nil,
+			"engineering/legacy":          nil,
+			"engineering/legacy/old-team": nil,
+		}, server.tree())
+		assert.Equal(t, 2, server.writes) // attribute update and frontend create
+	})
+
+	t.Run("with prune", func(t *testing.T) {
+		server := newTreeServer(t, actual, treeGroup("sales", nil))
+		client := newTestClient(server.URL)
+		opts := ReconcileTreeOptions{Prune: true}
+
+		require.NoError(t, client.Groups.ReconcileTree(context.Background(), "", desired, opts))
+		assert.Equal(t, map[string]map[string][]string{
+			"sales":                nil,
+			"engineering":          {"cost-center": {"42"}},
+			"engineering/backend":  {"oncall": {"yes"}},
+			"engineering/frontend": nil,
+		}, server.tree())
+		assert.Equal(t, 3, server.writes)
+
+		require.NoError(t, client.Groups.ReconcileTree(context.Background(), "", desired, opts))
+		assert.Equal(t, 3, server.writes, "second run changes nothing")
+	})
+
+	t.Run("nil attributes leave attributes unchanged", func(t *testing.T) {
+		server := newTreeServer(t, actual)
+		client := newTestClient(server.URL)
+
+		require.NoError(t, client.Groups.ReconcileTree(context.Background(), "", treeGroup("engineering", nil), ReconcileTreeOptions{}))
+		assert.Equal(t, map[string][]string{"cost-center": {"7"}}, server.tree()["engineering"])
+		assert.Zero(t, server.writes)
+	})
+}
+
+// TestGroupsClient_ReconcileTreeUnderParent tests reconciling a subtree below an existing group
+func TestGroupsClient_ReconcileTreeUnderParent(t *testing.T) {
+	server := newTreeServer(t, treeGroup("org", nil, treeGroup("engineering", nil, treeGroup("legacy", nil))))
+	client := newTestClient(server.URL)
+
+	desired := treeGroup("engineering", nil, treeGroup("backend", nil))
+	require.NoError(t, client.Groups.ReconcileTree(context.Background(), "g1", desired, ReconcileTreeOptions{Prune: true}))
+	assert.Equal(t, map[string]map[string][]string{
+		"org":                     nil,
+		"org/engineering":         nil,
+		"org/engineering/backend": nil,
+	}, server.tree())
+
+	err := client.Groups.ReconcileTree(context.Background(), "missing", desired, ReconcileTreeOptions{})
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+}
+
+func TestGroupsClient_ReconcileTreeValidation(t *testing.T) {
+	server := newTreeServer(t)
+	client := newTestClient(server.URL)
+	ctx := context.Background()
+
+	assert.Error(t, client.Groups.ReconcileTree(ctx, "", nil, ReconcileTreeOptions{}))
+	assert.Error(t, client.Groups.ReconcileTree(ctx, "", &Group{}, ReconcileTreeOptions{}))
+	assert.Error(t, client.Groups.ReconcileTree(ctx, "", treeGroup("a", nil, treeGroup("b", nil), treeGroup("b", nil)), ReconcileTreeOptions{}))
+	assert.Error(t, client.Groups.ReconcileTree(ctx, "", treeGroup("a", nil, treeGroup("b", nil, &Group{})), ReconcileTreeOptions{}))
+	assert.Zero(t, server.writes)
+	assert.Empty(t, server.tree())
+}
+
+// TestGroupsClient_RemoveAttributeValueWithServer tests the read-modify-write cycle of RemoveAttributeValue
+func TestGroupsClient_RemoveAttributeValueWithServer(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		conflicts int
+		wantPuts  int
+		wantAttrs map[string][]string
+		wantErr   bool
+	}{
+		{
+			name:      "value removed",
+			value:     "b",
+			wantPuts:  1,
+			wantAttrs: map[string][]string{"tags": {"a"}, "owner": {"ops"}},
+		},
+		{
+			name:     "missing value sends no update",
+			value:    "z",
+			wantPuts: 0,
+		},
+		{
+			name:      "retried on conflict",
+			value:     "b",
+			conflicts: 2,
+			wantPuts:  3,
+			wantAttrs: map[string][]string{"tags": {"a"}, "owner": {"ops"}},
+		},
+		{
+			name:      "gives up after repeated conflicts",
+			value:     "b",
+			conflicts: attributeUpdateAttempts,
+			wantPuts:  attributeUpdateAttempts,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gets, puts int
+			var updated Group
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/groups/g1", r.URL.Path)
+				switch r.Method {
+				case http.MethodGet:
+					gets++
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(Group{
+						ID:         ptr.String("g1"),
+						Name:       ptr.String("team"),
+						Attributes: &map[string][]string{"tags": {"a", "b"}, "owner": {"ops"}},
+					})
+				case http.MethodPut:
+					puts++
+					if puts <= tt.conflicts {
+						w.WriteHeader(http.StatusConflict)
+						return
+					}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&updated))
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			err := client.Groups.RemoveAttributeValue(context.Background(), "g1", "tags", tt.value)
+
+			assert.Equal(t, tt.wantPuts, puts)
+			assert.Equal(t, max(tt.wantPuts, 1), gets, "group is re-read before every attempt")
+			if tt.wantErr {
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantAttrs != nil {
+				assert.Equal(t, "team", ptr.ToString(updated.Name))
+				assert.Equal(t, tt.wantAttrs, *updated.Attributes)
+			}
+		})
+	}
+}
+
+// TestGroupsClient_RemoveAttributeValueNotFound tests that a missing group is reported as ErrGroupNotFound
+func TestGroupsClient_RemoveAttributeValueNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	err := client.Groups.RemoveAttributeValue(context.Background(), "g1", "tags", "a")
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+
+	assert.Error(t, client.Groups.RemoveAttributeValue(context.Background(), "", "tags", "a"))
+	assert.Error(t, client.Groups.RemoveAttributeValue(context.Background(), "g1", "", "a"))
+}
+
+// newMembersServer serves total members of group g1, honoring first and max, and counts the requests.
+func newMembersServer(t *testing.T, total int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "/admin/realms/test-realm/groups/g1/members", r.URL.Path)
+		first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("max"))
+
+		users := []*User{}
+		for i := first; i < total && i < first+limit; i++ {
+			users = append(users, &User{ID: ptr.String(fmt.Sprintf("u%d", i))})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(users)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestGroupsClient_IterateMembersWithServer tests that IterateMembers pages through all members lazily
+func TestGroupsClient_IterateMembersWithServer(t *testing.T) {
+	collect := func(seq func(func(*User, error) bool)) ([]string, error) {
+		var ids []string
+		for user, err := range seq {
+			if err != nil {
+				return ids, err
+			}
+			ids = append(ids, *user.ID)
+		}
+		return ids, nil
+	}
+
+	t.Run("all pages with client page size", func(t *testing.T) {
+		server, requests := newMembersServer(t, 5)
+		client := newTestClient(server.URL, WithPageSize(2))
+
+		ids, err := collect(client.Groups.IterateMembers(context.Background(), "g1", GroupMembersParams{}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"u0", "u1", "u2", "u3", "u4"}, ids)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("first and max from params", func(t *testing.T) {
+		server, requests := newMembersServer(t, 6)
+		client := newTestClient(server.URL)
+
+		ids, err := collect(client.Groups.IterateMembers(context.Background(), "g1", GroupMembersParams{First: ptr.Int(1), Max: ptr.Int(3)}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"u1", "u2", "u3", "u4", "u5"}, ids)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("early break fetches no further pages", func(t *testing.T) {
+		server, requests := newMembersServer(t, 10)
+		client := newTestClient(server.URL, WithPageSize(2))
+
+		for user, err := range client.Groups.IterateMembers(context.Background(), "g1", GroupMembersParams{}) {
+			require.NoError(t, err)
+			if *user.ID == "u2" {
+				break
+			}
+		}
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		server, requests := newMembersServer(t, 10)
+		client := newTestClient(server.URL, WithPageSize(2))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var ids []string
+		var iterErr error
+		for user, err := range client.Groups.IterateMembers(ctx, "g1", GroupMembersParams{}) {
+			if err != nil {
+				iterErr = err
+				break
+			}
+			ids = append(ids, *user.ID)
+			cancel()
+		}
+		assert.ErrorIs(t, iterErr, context.Canceled)
+		assert.Equal(t, []string{"u0", "u1"}, ids)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("page limit", func(t *testing.T) {
+		server, _ := newMembersServer(t, 10)
+		client := newTestClient(server.URL, WithPageSize(2), WithMaxPages(2))
+
+		ids, err := collect(client.Groups.IterateMembers(context.Background(), "g1", GroupMembersParams{}))
+		assert.ErrorIs(t, err, ErrPageLimitExceeded)
+		assert.Len(t, ids, 4)
+	})
+
+	t.Run("HTTP error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		client := newTestClient(server.URL)
+
+		ids, err := collect(client.Groups.IterateMembers(context.Background(), "g1", GroupMembersParams{}))
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.Empty(t, ids)
+	})
+}
+
+// failingWriter accepts n writes, then fails.
+type failingWriter struct {
+	bytes.Buffer
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.n--
+	return w.Buffer.Write(p)
+}
+
+// snapshotIDs returns the user IDs of a JSON-lines snapshot.
+func snapshotIDs(t *testing.T, snapshot string) []string {
+	t.Helper()
+	var ids []string
+	for line := range strings.Lines(snapshot) {
+		var user User
+		require.NoError(t, json.Unmarshal([]byte(line), &user))
+		ids = append(ids, *user.ID)
+	}
+	return ids
+}
+
+// TestGroupsClient_SnapshotMembersWithServer tests that SnapshotMembers exports all members
+// and resumes from the returned cursor
+func TestGroupsClient_SnapshotMembersWithServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("all pages", func(t *testing.T) {
+		server, requests := newMembersServer(t, 5)
+		client := newTestClient(server.URL, WithPageSize(2))
+
+		var out bytes.Buffer
+		cursor, err := client.Groups.SnapshotMembers(ctx, "g1", &out, nil)
+		require.NoError(t, err)
+		assert.Nil(t, cursor)
+		assert.Equal(t, []string{"u0", "u1", "u2", "u3", "u4"}, snapshotIDs(t, out.String()))
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("resume from a mid-point cursor", func(t *testing.T) {
+		server, _ := newMembersServer(t, 6)
+		client := newTestClient(server.URL)
+
+		var out bytes.Buffer
+		cursor, err := client.Groups.SnapshotMembers(ctx, "g1", &out, &GroupMembersParams{First: ptr.Int(3), Max: ptr.Int(2)})
+		require.NoError(t, err)
+		assert.Nil(t, cursor)
+		assert.Equal(t, []string{"u3", "u4", "u5"}, snapshotIDs(t, out.String()))
+	})
+
+	t.Run("write error returns the cursor of the first unwritten member", func(t *testing.T) {
+		server, _ := newMembersServer(t, 5)
+		client := newTestClient(server.URL, WithPageSize(2))
+
+		out := &failingWriter{n: 3}
+		cursor, err := client.Groups.SnapshotMembers(ctx, "g1", out, &GroupMembersParams{BriefRepresentation: ptr.Bool(true)})
+		require.ErrorContains(t, err, "disk full")
+		require.NotNil(t, cursor)
+		assert.Equal(t, 3, *cursor.First)
+		assert.Equal(t, 2, *cursor.Max)
+		assert.True(t, *cursor.BriefRepresentation)
+
+		written := out.String()
+		cursor, err = client.Groups.SnapshotMembers(ctx, "g1", &out.Buffer, cursor)
+		require.NoError(t, err)
+		assert.Nil(t, cursor)
+		assert.Equal(t, []string{"u0", "u1", "u2"}, snapshotIDs(t, written))
+		assert.Equal(t, []string{"u0", "u1", "u2", "u3", "u4"}, snapshotIDs(t, out.String()))
+	})
+
+	t.Run("page limit exports in chunks", func(t *testing.T) {
+		server, _ := newMembersServer(t, 5)
+		client := newTestClient(server.URL, WithPageSize(2), WithMaxPages(1))
+
+		var out bytes.Buffer
+		cursor, err := client.Groups.SnapshotMembers(ctx, "g1", &out, nil)
+		chunks := 1
+		for errors.Is(err, ErrPageLimitExceeded) {
+			cursor, err = client.Groups.SnapshotMembers(ctx, "g1", &out, cursor)
+			chunks++
+		}
+		require.NoError(t, err)
+		assert.Equal(t, 3, chunks)
+		assert.Equal(t, []string{"u0", "u1", "u2", "u3", "u4"}, snapshotIDs(t, out.String()))
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		client := newTestClient("http://localhost")
+		_, err := client.Groups.SnapshotMembers(ctx, "", io.Discard, nil)
+		assert.Error(t, err)
+		_, err = client.Groups.SnapshotMembers(ctx, "g1", nil, nil)
+		assert.Error(t, err)
+	})
+}
+
+// TestGroupsClient_AttributeLimitsWithServer tests that attribute limits are enforced before any request is sent
+func TestGroupsClient_AttributeLimitsWithServer(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL,
+		WithAttributeLimits(1, 1, 3),
+		WithDefaultAttributes(map[string][]string{"managedBy": {"ops"}}),
+	)
+	ctx := context.Background()
+
+	_, err := client.Groups.Create(ctx, "group", map[string][]string{"team": {"a"}})
+	assert.ErrorIs(t, err, ErrAttributeLimitExceeded, "default attributes count towards the key limit")
+
+	_, err = client.Groups.CreateSubGroup(ctx, "parent", "child", map[string][]string{"managedBy": {"a", "b"}})
+	assert.ErrorIs(t, err, ErrAttributeLimitExceeded)
+
+	err = client.Groups.Update(ctx, Group{ID: ptr.String("g1"), Attributes: &map[string][]string{"managedBy": {"toolong"}}})
+	assert.ErrorIs(t, err, ErrAttributeLimitExceeded)
+
+	assert.Equal(t, int32(0), requests.Load())
+
+	err = client.Groups.Update(ctx, Group{ID: ptr.String("g1"), Attributes: &map[string][]string{"managedBy": {"ops"}}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+// newHierarchyServer serves the children and members of a mocked group hierarchy and records
+// the requested paths. Unknown groups answer 404.
+func newHierarchyServer(t *testing.T, children map[string][]*Group, members map[string]int) (*httptest.Server, *sync.Map) {
+	t.Helper()
+	var requested sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested.Store(r.URL.Path, true)
+		groupID, resource, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/realms/test-realm/groups/"), "/")
+
+		if _, ok := members[groupID]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch resource {
+		case "children":
+			first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("max"))
+			groups := children[groupID]
+			json.NewEncoder(w).Encode(groups[min(first, len(groups)):min(first+limit, len(groups))])
+		case "members":
+			first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("max"))
+			users := []*User{}
+			for i := first; i < members[groupID] && i < first+limit; i++ {
+				users = append(users, &User{ID: ptr.String(fmt.Sprintf("%s-u%d", groupID, i))})
+			}
+			json.NewEncoder(w).Encode(users)
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requested
+}
+
+// TestGroupsClient_SubtreeSizeWithServer tests SubtreeSize over a mocked hierarchy
+func TestGroupsClient_SubtreeSizeWithServer(t *testing.T) {
+	child := func(id string, subGroupCount int64) *Group {
+		return &Group{ID: ptr.String(id), SubGroupCount: &subGroupCount}
+	}
+	children := map[string][]*Group{
+		"root": {child("a", 2), child("b", 0)},
+		"a":    {child("a1", 0), child("a2", 1)},
+		"a2":   {{ID: ptr.String("a2x")}},
+	}
+	members := map[string]int{"root": 1, "a": 3, "b": 0, "a1": 5, "a2": 2, "a2x": 1}
+
+	t.Run("counts the whole subtree", func(t *testing.T) {
+		server, requested := newHierarchyServer(t, children, members)
+		client := newTestClient(server.URL, WithPageSize(2))
+
+		groups, memberCount, err := client.Groups.SubtreeSize(context.Background(), "root")
+		require.NoError(t, err)
+		assert.Equal(t, 5, groups)
+		assert.Equal(t, 12, memberCount)
+
+		_, listedLeaf := requested.Load("/admin/realms/test-realm/groups/b/children")
+		assert.False(t, listedLeaf, "children of groups without subgroups should not be listed")
+		_, listedUnknown := requested.Load("/admin/realms/test-realm/groups/a2x/children")
+		assert.True(t, listedUnknown, "children should be listed when the subgroup count is unknown")
+	})
+
+	t.Run("leaf group", func(t *testing.T) {
+		server, _ := newHierarchyServer(t, children, members)
+		client := newTestClient(server.URL)
+
+		groups, memberCount, err := client.Groups.SubtreeSize(context.Background(), "a1")
+		require.NoError(t, err)
+		assert.Equal(t, 0, groups)
+		assert.Equal(t, 5, memberCount)
+	})
+
+	t.Run("missing group", func(t *testing.T) {
+		server, _ := newHierarchyServer(t, children, members)
+		client := newTestClient(server.URL)
+
+		_, _, err := client.Groups.SubtreeSize(context.Background(), "missing")
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+	})
+
+	t.Run("error in a descendant", func(t *testing.T) {
+		broken := map[string][]*Group{"root": {child("a", 0), child("gone", 0)}}
+		server, _ := newHierarchyServer(t, broken, map[string]int{"root": 1, "a": 1})
+		client := newTestClient(server.URL)
+
+		_, _, err := client.Groups.SubtreeSize(context.Background(), "root")
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		server, _ := newHierarchyServer(t, children, members)
+		client := newTestClient(server.URL)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := client.Groups.SubtreeSize(ctx, "root")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("empty group ID", func(t *testing.T) {
+		client := newTestClient("http://localhost")
+		_, _, err := client.Groups.SubtreeSize(context.Background(), "")
+		assert.Error(t, err)
+	})
+}
+
+// TestGroupsClient_ListMembersWithRoleWithServer tests that members are filtered by their
+// effective realm roles
+func TestGroupsClient_ListMembersWithRoleWithServer(t *testing.T) {
+	roles := map[string]string{
+		"u1": `[{"name":"default-roles-test-realm"},{"name":"admin"}]`,
+		"u2": `[{"name":"viewer"}]`,
+		"u3": `[{"name":"admin"}]`,
+		"u4": `[]`,
+	}
+	var inFlight, maxInFlight atomic.Int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/admin/realms/test-realm/groups/g1/members" {
+			assert.Equal(t, "10", r.URL.Query().Get("max"))
+			w.Write([]byte(`[{"id":"u1"},{"id":"u2"},{"id":"u3"},{"id":"u4"}]`))
+			return
+		}
+
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		userID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/admin/realms/test-realm/users/"), "/role-mappings/realm/composite")
+		if userID == "u4" && failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(roles[userID]))
+	}))
+	defer server.Close()
+	client := newTestClient(server.URL)
+	ctx := context.Background()
+
+	t.Run("filtered by role", func(t *testing.T) {
+		members, err := client.Groups.ListMembersWithRole(ctx, "g1", "admin", GroupMembersParams{Max: ptr.Int(10)})
+		require.NoError(t, err)
+		var ids []string
+		for _, member := range members {
+			ids = append(ids, *member.ID)
+		}
+		assert.Equal(t, []string{"u1", "u3"}, ids)
+		assert.LessOrEqual(t, maxInFlight.Load(), int32(memberRoleConcurrency))
+
+		members, err = client.Groups.ListMembersWithRole(ctx, "g1", "auditor", GroupMembersParams{Max: ptr.Int(10)})
+		require.NoError(t, err)
+		assert.NotNil(t, members)
+		assert.Empty(t, members)
+	})
+
+	t.Run("role lookup fails", func(t *testing.T) {
+		failing.Store(true)
+		_, err := client.Groups.ListMembersWithRole(ctx, "g1", "admin", GroupMembersParams{Max: ptr.Int(10)})
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	})
+
+	t.Run("role name required", func(t *testing.T) {
+		_, err := client.Groups.ListMembersWithRole(ctx, "g1", "", GroupMembersParams{})
+		assert.Error(t, err)
+	})
+}
+
+// TestGroupsClient_ListMembersPageWithServer tests the page metadata returned by ListMembersPage
+func TestGroupsClient_ListMembersPageWithServer(t *testing.T) {
+	server, _ := newMembersServer(t, 5)
+	client := newTestClient(server.URL)
+	ctx := context.Background()
+
+	t.Run("full page", func(t *testing.T) {
+		page, err := client.Groups.ListMembersPage(ctx, "g1", GroupMembersParams{BriefRepresentation: ptr.Bool(true), Max: ptr.Int(2)})
+		require.NoError(t, err)
+		require.Len(t, page.Members, 2)
+		assert.True(t, page.HasMore)
+		require.NotNil(t, page.Next)
+		assert.Equal(t, GroupMembersParams{BriefRepresentation: ptr.Bool(true), First: ptr.Int(2), Max: ptr.Int(2)}, *page.Next)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		page, err := client.Groups.ListMembersPage(ctx, "g1", GroupMembersParams{First: ptr.Int(4), Max: ptr.Int(2)})
+		require.NoError(t, err)
+		require.Len(t, page.Members, 1)
+		assert.Equal(t, "u4", *page.Members[0].ID)
+		assert.False(t, page.HasMore)
+		assert.Nil(t, page.Next)
+	})
+
+	t.Run("follows the cursor with the client page size", func(t *testing.T) {
+		client := newTestClient(server.URL, WithPageSize(2))
+
+		var ids []string
+		params := GroupMembersParams{}
+		for {
+			page, err := client.Groups.ListMembersPage(ctx, "g1", params)
+			require.NoError(t, err)
+			for _, member := range page.Members {
+				ids = append(ids, *member.ID)
+			}
+			if !page.HasMore {
+				break
+			}
+			params = *page.Next
+		}
+		assert.Equal(t, []string{"u0", "u1", "u2", "u3", "u4"}, ids)
+	})
+
+	t.Run("HTTP error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		page, err := newTestClient(server.URL).Groups.ListMembersPage(ctx, "g1", GroupMembersParams{})
+		assert.Error(t, err)
+		assert.Nil(t, page)
+	})
+}
+
+// TestGroupsClient_SearchByAnyAttributeWithServer tests that per-attribute searches are unioned and deduplicated
+func TestGroupsClient_SearchByAnyAttributeWithServer(t *testing.T) {
+	byQuery := map[string][]*Group{
+		"dept:eng": {{ID: ptr.String("g1")}, {ID: ptr.String("g2")}},
+		"dept:mkt": {{ID: ptr.String("g2")}, {ID: ptr.String("g3")}},
+		"dept:ops": {},
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "/admin/realms/test-realm/groups", r.URL.Path)
+		assert.Equal(t, "false", r.URL.Query().Get("briefRepresentation"))
+		groups, ok := byQuery[r.URL.Query().Get("q")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(groups)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	ctx := context.Background()
+
+	t.Run("union and dedupe", func(t *testing.T) {
+		requests.Store(0)
+		groups, err := client.Groups.SearchByAnyAttribute(ctx, []GroupAttribute{
+			{Key: "dept", Value: "eng"},
+			{Key: "dept", Value: "mkt"},
+			{Key: "dept", Value: "ops"},
+		})
+		require.NoError(t, err)
+
+		var ids []string
+		for _, group := range groups {
+			ids = append(ids, *group.ID)
+		}
+		assert.Equal(t, []string{"g1", "g2", "g3"}, ids)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		groups, err := client.Groups.SearchByAnyAttribute(ctx, []GroupAttribute{{Key: "dept", Value: "ops"}})
+		require.NoError(t, err)
+		assert.Empty(t, groups)
+		assert.NotNil(t, groups)
+	})
+
+	t.Run("failed search", func(t *testing.T) {
+		_, err := client.Groups.SearchByAnyAttribute(ctx, []GroupAttribute{
+			{Key: "dept", Value: "eng"},
+			{Key: "dept", Value: "unknown"},
+		})
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := client.Groups.SearchByAnyAttribute(ctx, nil)
+		assert.Error(t, err)
+		_, err = client.Groups.SearchByAnyAttribute(ctx, []GroupAttribute{{Value: "eng"}})
+		assert.Error(t, err)
+	})
+}
+
+// TestGroupsClient_CancelledContext tests that every group method returns the context error
+// for a cancelled context without sending a request
+func TestGroupsClient_CancelledContext(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithRetry(3, time.Millisecond, time.Millisecond))
+	groups := client.Groups
+	attribute := GroupAttribute{Key: "dept", Value: "eng"}
+	group := Group{ID: ptr.String("g1"), Name: ptr.String("group")}
+
+	methods := map[string]func(ctx context.Context) error{
+		"Create": func(ctx context.Context) error {
+			_, err := groups.Create(ctx, "group", nil)
+			return err
+		},
+		"Update":               func(ctx context.Context) error { return groups.Update(ctx, group) },
+		"RemoveAttributeValue": func(ctx context.Context) error { return groups.RemoveAttributeValue(ctx, "g1", "dept", "eng") },
+		"Delete":               func(ctx context.Context) error { return groups.Delete(ctx, "g1") },
+		"List": func(ctx context.Context) error {
+			_, err := groups.List(ctx, nil, true)
+			return err
+		},
+		"ListWithParams": func(ctx context.Context) error {
+			_, err := groups.ListWithParams(ctx, SearchGroupParams{})
+			return err
+		},
+		"CreateWithID": func(ctx context.Context) error {
+			return groups.CreateWithID(ctx, Group{ID: ptr.String("g1"), Name: ptr.String("group")})
+		},
+		"ListNoCount": func(ctx context.Context) error {
+			_, err := groups.ListNoCount(ctx, SearchGroupParams{})
+			return err
+		},
+		"ListWithSubGroups": func(ctx context.Context) error {
+			_, err := groups.ListWithSubGroups(ctx, "group", true, 0, 10)
+			return err
+		},
+		"ListTopLevel": func(ctx context.Context) error {
+			_, err := groups.ListTopLevel(ctx, SearchGroupParams{})
+			return err
+		},
+		"TagMatching": func(ctx context.Context) error {
+			_, err := groups.TagMatching(ctx, SearchGroupParams{}, "key", []string{"value"})
+			return err
+		},
+		"ListWithAttribute": func(ctx context.Context) error {
+			_, err := groups.ListWithAttribute(ctx, "key", SearchGroupParams{})
+			return err
+		},
+		"AddDefaultGroup": func(ctx context.Context) error {
+			return groups.AddDefaultGroup(ctx, "g1", DefaultGroupOptions{})
+		},
+		"RemoveDefaultGroup": func(ctx context.Context) error {
+			return groups.RemoveDefaultGroup(ctx, "g1", DefaultGroupOptions{})
+		},
+		"ListSorted": func(ctx context.Context) error {
+			_, err := groups.ListSorted(ctx, SearchGroupParams{}, GroupsByName)
+			return err
+		},
+		"BuildAttributeIndex": func(ctx context.Context) error {
+			_, err := groups.BuildAttributeIndex(ctx, "dept")
+			return err
+		},
+		"Count": func(ctx context.Context) error {
+			_, err := groups.Count(ctx, nil, nil)
+			return err
+		},
+		"ListPaginated": func(ctx context.Context) error {
+			_, err := groups.ListPaginated(ctx, nil, true, 0, 10)
+			return err
+		},
+		"Get": func(ctx context.Context) error {
+			_, err := groups.Get(ctx, "g1")
+			return err
+		},
+		"GetRaw": func(ctx context.Context) error {
+			_, _, err := groups.GetRaw(ctx, "g1")
+			return err
+		},
+		"GetByName": func(ctx context.Context) error {
+			_, err := groups.GetByName(ctx, "group")
+			return err
+		},
+		"Exists": func(ctx context.Context) error {
+			_, err := groups.Exists(ctx, "g1")
+			return err
+		},
+		"Ancestry": func(ctx context.Context) error {
+			_, err := groups.Ancestry(ctx, "g1")
+			return err
+		},
+		"GetWithSubGroups": func(ctx context.Context) error {
+			_, err := groups.GetWithSubGroups(ctx, "g1", 1)
+			return err
+		},
+		"GetByAttribute": func(ctx context.Context) error {
+			_, err := groups.GetByAttribute(ctx, &attribute)
+			return err
+		},
+		"SearchByAnyAttribute": func(ctx context.Context) error {
+			_, err := groups.SearchByAnyAttribute(ctx, []GroupAttribute{attribute})
+			return err
+		},
+		"ListSubGroups": func(ctx context.Context) error {
+			_, err := groups.ListSubGroups(ctx, "g1")
+			return err
+		},
+		"ListSubGroupsPaginated": func(ctx context.Context) error {
+			_, err := groups.ListSubGroupsPaginated(ctx, "g1", SubGroupSearchParams{})
+			return err
+		},
+		"CreateSubGroup": func(ctx context.Context) error {
+			_, err := groups.CreateSubGroup(ctx, "g1", "child", nil)
+			return err
+		},
+		"Detach": func(ctx context.Context) error { return groups.Detach(ctx, "g1") },
+		"FindSubGroupsByAttribute": func(ctx context.Context) error {
+			_, err := groups.FindSubGroupsByAttribute(ctx, attribute)
+			return err
+		},
+		"ListMembers": func(ctx context.Context) error {
+			_, err := groups.ListMembers(ctx, "g1", GroupMembersParams{})
+			return err
+		},
+		"ListMembersPage": func(ctx context.Context) error {
+			_, err := groups.ListMembersPage(ctx, "g1", GroupMembersParams{})
+			return err
+		},
+		"ListMembersWithRole": func(ctx context.Context) error {
+			_, err := groups.ListMembersWithRole(ctx, "g1", "admin", GroupMembersParams{})
+			return err
+		},
+		"IterateMembers": func(ctx context.Context) error {
+			for _, err := range groups.IterateMembers(ctx, "g1", GroupMembersParams{}) {
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		"SnapshotMembers": func(ctx context.Context) error {
+			_, err := groups.SnapshotMembers(ctx, "g1", io.Discard, nil)
+			return err
+		},
+		"ReconcileMembers": func(ctx context.Context) error {
+			_, _, err := groups.ReconcileMembers(ctx, "g1", []string{"u1"})
+			return err
+		},
+		"ReconcileTree": func(ctx context.Context) error {
+			return groups.ReconcileTree(ctx, "g1", &Group{Name: ptr.String("group")}, ReconcileTreeOptions{})
+		},
+		"SubtreeSize": func(ctx context.Context) error {
+			_, _, err := groups.SubtreeSize(ctx, "g1")
+			return err
+		},
+		"GetManagementPermissions": func(ctx context.Context) error {
+			_, err := groups.GetManagementPermissions(ctx, "g1")
+			return err
+		},
+		"UpdateManagementPermissions": func(ctx context.Context) error {
+			_, err := groups.UpdateManagementPermissions(ctx, "g1", ManagementPermissionReference{Enabled: ptr.Bool(true)})
+			return err
+		},
+		"EnableManagementPermissionsAndWait": func(ctx context.Context) error {
+			_, err := groups.EnableManagementPermissionsAndWait(ctx, "g1", time.Second)
+			return err
+		},
+		"PermissionsEnabled": func(ctx context.Context) error {
+			_, err := groups.PermissionsEnabled(ctx, "g1")
+			return err
+		},
+		"GetRoleMappings": func(ctx context.Context) error {
+			_, err := groups.GetRoleMappings(ctx, "g1")
+			return err
+		},
+	}
+
+	for name, call := range methods {
+		t.Run(name, func(t *testing.T) {
+			requests.Store(0)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := call(ctx)
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Equal(t, int32(0), requests.Load(), "no request should be sent")
+		})
+	}
+}
+
+// TestGroupsClient_CancelledInFlight tests that cancelling an in-flight call aborts it without retrying
+func TestGroupsClient_CancelledInFlight(t *testing.T) {
+	var requests atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithRetry(3, time.Second, time.Second), WithRetryOnNetworkError(3))
+
+	start := time.Now()
+	_, err := client.Groups.Get(ctx, "g1")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second, "retries should not wait")
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+// TestGroupsClient_ListTopLevelWithServer tests that ListTopLevel drops subgroups from the listed page
+func TestGroupsClient_ListTopLevelWithServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/realms/test-realm/groups", r.URL.Path)
+		assert.Equal(t, "team", r.URL.Query().Get("search"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*Group{
+			{ID: ptr.String("g1"), Path: ptr.String("/org")},
+			{ID: ptr.String("g2"), Path: ptr.String("/org/team"), ParentID: ptr.String("g1")},
+			{ID: ptr.String("g3"), Path: ptr.String("/other/team")},
+			{ID: ptr.String("g4"), Path: ptr.String("/team")},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	groups, err := client.Groups.ListTopLevel(context.Background(), SearchGroupParams{Search: ptr.String("team")})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, group := range groups {
+		ids = append(ids, *group.ID)
+	}
+	assert.Equal(t, []string{"g1", "g4"}, ids)
+}
+
+// TestGroupsClient_DefaultGroupsWithServer tests AddDefaultGroup and RemoveDefaultGroup, including
+// the already-present and already-absent cases with and without Idempotent
+func TestGroupsClient_DefaultGroupsWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		remove         bool
+		idempotent     bool
+		mockStatusCode int
+		wantErr        error
+		wantAPIError   bool
+	}{
+		{name: "add", mockStatusCode: http.StatusNoContent},
+		{name: "add already present", mockStatusCode: http.StatusConflict, wantAPIError: true},
+		{name: "add already present idempotent", idempotent: true, mockStatusCode: http.StatusConflict},
+		{name: "add group not found", idempotent: true, mockStatusCode: http.StatusNotFound, wantErr: ErrGroupNotFound},
+		{name: "remove", remove: true, mockStatusCode: http.StatusNoContent},
+		{name: "remove already absent", remove: true, mockStatusCode: http.StatusNotFound, wantErr: ErrGroupNotFound},
+		{name: "remove already absent idempotent", remove: true, idempotent: true, mockStatusCode: http.StatusNotFound},
+		{name: "remove server error idempotent", remove: true, idempotent: true, mockStatusCode: http.StatusInternalServerError, wantAPIError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantMethod := http.MethodPut
+			if tt.remove {
+				wantMethod = http.MethodDelete
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, wantMethod, r.Method)
+				assert.Equal(t, "/admin/realms/test-realm/default-groups/g1", r.URL.Path)
+				w.WriteHeader(tt.mockStatusCode)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			opts := DefaultGroupOptions{Idempotent: tt.idempotent}
+			var err error
+			if tt.remove {
+				err = client.Groups.RemoveDefaultGroup(context.Background(), "g1", opts)
+			} else {
+				err = client.Groups.AddDefaultGroup(context.Background(), "g1", opts)
+			}
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantAPIError:
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.mockStatusCode, apiErr.StatusCode)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestGroupsClient_ListWithAttributeWithServer tests both the client-side filter and the
+// wildcard search path of ListWithAttribute
+func TestGroupsClient_ListWithAttributeWithServer(t *testing.T) {
+	tree := []*Group{
+		{ID: ptr.String("g1"), Attributes: &map[string][]string{"tag": {}}, SubGroups: &[]*Group{
+			{ID: ptr.String("g2"), Attributes: &map[string][]string{"tag": {"x"}}},
+			{ID: ptr.String("g3"), Attributes: &map[string][]string{"other": {"x"}}},
+		}},
+		{ID: ptr.String("g4")},
+		{ID: ptr.String("g5"), Attributes: &map[string][]string{"tag": {"y"}}},
+	}
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		params     SearchGroupParams
+		wantQ      string
+		wantSearch string
+	}{
+		{name: "client-side filter", params: SearchGroupParams{Search: ptr.String("team")}, wantSearch: "team"},
+		{name: "wildcard search", opts: []Option{WithAttributeWildcardSearch(true)}, wantQ: "tag:*"},
+		{name: "wildcard search with q", opts: []Option{WithAttributeWildcardSearch(true)}, params: SearchGroupParams{Q: ptr.String("env:prod")}, wantQ: "env:prod tag:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/groups", r.URL.Path)
+				assert.Equal(t, tt.wantQ, r.URL.Query().Get("q"))
+				assert.Equal(t, tt.wantSearch, r.URL.Query().Get("search"))
+				assert.Equal(t, "false", r.URL.Query().Get("briefRepresentation"))
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(tree)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL, tt.opts...)
+			groups, err := client.Groups.ListWithAttribute(context.Background(), "tag", tt.params)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, group := range groups {
+				ids = append(ids, *group.ID)
+			}
+			assert.Equal(t, []string{"g1", "g2", "g5"}, ids)
+			assert.Equal(t, "g1", ptr.ToString(groups[1].ParentID))
+		})
+	}
+
+	t.Run("empty key", func(t *testing.T) {
+		client := newTestClient("http://localhost")
+		_, err := client.Groups.ListWithAttribute(context.Background(), "", SearchGroupParams{})
+		assert.Error(t, err)
+	})
+}
+
+// TestGroupsClient_SearchLengthWithServer tests empty and single-character name searches
+// against a server that rejects terms shorter than two characters
+func TestGroupsClient_SearchLengthWithServer(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		query := r.URL.Query()
+		if !query.Has("search") {
+			t.Errorf("search parameter missing: %s", r.URL.RawQuery)
+		}
+		if n := len(query.Get("search")); n == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*Group{
+			{ID: ptr.String("p1"), SubGroups: &[]*Group{{ID: ptr.String("c1")}}},
+		})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		search       string
+		minLength    int
+		wantErr      error
+		wantStatus   int
+		wantRequests int32
+	}{
+		{name: "empty matches all", search: "", wantRequests: 1},
+		{name: "whitespace matches all", search: "  ", minLength: 2, wantRequests: 1},
+		{name: "single character rejected by server", search: "a", wantStatus: http.StatusBadRequest, wantRequests: 1},
+		{name: "single character rejected by client", search: "a", minLength: 2, wantErr: ErrSearchTooShort},
+		{name: "long enough", search: "ab", minLength: 2, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			client := newTestClient(server.URL, WithMinSearchLength(tt.minLength))
+
+			groups, err := client.Groups.ListWithSubGroups(context.Background(), tt.search, false, 0, 10)
+			assert.Equal(t, tt.wantRequests, requests.Load())
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantStatus != 0:
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.wantStatus, apiErr.StatusCode)
+			default:
+				require.NoError(t, err)
+				require.Len(t, groups, 1)
+				assert.NotNil(t, groups[0].SubGroups)
+			}
+		})
+	}
+
+	t.Run("applies to List", func(t *testing.T) {
+		requests.Store(0)
+		client := newTestClient(server.URL, WithMinSearchLength(2))
+		_, err := client.Groups.List(context.Background(), ptr.String("a"), false)
+		assert.ErrorIs(t, err, ErrSearchTooShort)
+		assert.Zero(t, requests.Load())
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		assert.Error(t, WithMinSearchLength(-1)(&Client{}))
+	})
+}
+
+// TestGroupsClient_TagMatchingWithServer tests that TagMatching merges the attribute into the
+// matching groups only and reports a result per group
+func TestGroupsClient_TagMatchingWithServer(t *testing.T) {
+	var mu sync.Mutex
+	stored := map[string]*Group{
+		"g1": {ID: ptr.String("g1"), Name: ptr.String("org")},
+		"g2": {ID: ptr.String("g2"), Name: ptr.String("team-a"), Attributes: &map[string][]string{"tag": {"old"}, "owner": {"ops"}}},
+		"g3": {ID: ptr.String("g3"), Name: ptr.String("team-b"), Attributes: &map[string][]string{"tag": {"new"}}},
+		"g4": {ID: ptr.String("g4"), Name: ptr.String("team-c")},
+	}
+	var puts []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/admin/realms/test-realm/groups" {
+			assert.Equal(t, "team", r.URL.Query().Get("search"))
+			json.NewEncoder(w).Encode([]*Group{
+				{ID: ptr.String("g1"), Name: ptr.String("org"), SubGroups: &[]*Group{stored["g2"], stored["g3"]}},
+				stored["g4"],
+			})
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/admin/realms/test-realm/groups/")
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(stored[id])
+		case http.MethodPut:
+			puts = append(puts, id)
+			if id == "g4" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			var group Group
+			json.NewDecoder(r.Body).Decode(&group)
+			stored[id] = &group
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	results, err := client.Groups.TagMatching(context.Background(), SearchGroupParams{Search: ptr.String("team")}, "tag", []string{"new"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to tag group g4")
+
+	require.Len(t, results, 3)
+	assert.Equal(t, "g2", results[0].ID)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "g3", results[1].ID)
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, "g4", results[2].ID)
+	var apiErr *APIError
+	require.ErrorAs(t, results[2].Err, &apiErr)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{"g2", "g4"}, puts, "g1 does not match and g3 is already tagged")
+	assert.Equal(t, map[string][]string{"tag": {"old", "new"}, "owner": {"ops"}}, *stored["g2"].Attributes)
+}
+
+// TestGroupsClient_TagMatchingValidation tests the parameter checks of TagMatching
+func TestGroupsClient_TagMatchingValidation(t *testing.T) {
+	client := newTestClient("http://localhost")
+	_, err := client.Groups.TagMatching(context.Background(), SearchGroupParams{}, "", []string{"v"})
+	assert.Error(t, err)
+	_, err = client.Groups.TagMatching(context.Background(), SearchGroupParams{}, "tag", nil)
+	assert.Error(t, err)
+}
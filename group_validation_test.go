@@ -288,3 +288,30 @@ func TestGroupsClient_UpdateManagementPermissionsValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestGroupsClient_ExistsValidation(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	gc := &groupsClient{client: client}
+
+	exists, err := gc.Exists(context.Background(), "")
+	assert.Error(t, err)
+	assert.False(t, exists)
+}
+
+// TestGroupsClient_GetRoleMappingsValidation tests GetRoleMappings input validation
+func TestGroupsClient_GetRoleMappingsValidation(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	gc := &groupsClient{client: client}
+
+	mappings, err := gc.GetRoleMappings(context.Background(), "")
+	assert.Error(t, err)
+	assert.Nil(t, mappings)
+}
+
+// TestGroupsClient_DetachValidation tests Detach input validation
+func TestGroupsClient_DetachValidation(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	gc := &groupsClient{client: client}
+
+	assert.Error(t, gc.Detach(context.Background(), ""))
+}
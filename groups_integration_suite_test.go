@@ -294,6 +294,57 @@ func (s *GroupsIntegrationTestSuite) TestSubGroups() {
 	s.Equal(subGroupName, *subGroups[0].Name)
 }
 
+// TestDetachSubGroup tests moving a subgroup to the top level
+func (s *GroupsIntegrationTestSuite) TestDetachSubGroup() {
+	parentName := fmt.Sprintf("test-detach-parent-%d", time.Now().Unix())
+	parentID, err := s.client.Groups.Create(s.ctx, parentName, nil)
+	s.Require().NoError(err)
+	s.trackGroup(parentID)
+
+	childName := fmt.Sprintf("test-detach-child-%d", time.Now().Unix())
+	childID, err := s.client.Groups.CreateSubGroup(s.ctx, parentID, childName, map[string][]string{
+		"code": {"detached"},
+	})
+	s.Require().NoError(err)
+	s.trackGroup(childID)
+
+	s.Require().NoError(s.client.Groups.Detach(s.ctx, childID))
+
+	child, err := s.client.Groups.Get(s.ctx, childID)
+	s.Require().NoError(err)
+	s.Nil(child.ParentID)
+	s.Equal("/"+childName, *child.Path)
+	s.Equal([]string{"detached"}, (*child.Attributes)["code"])
+
+	subGroups, err := s.client.Groups.ListSubGroups(s.ctx, parentID)
+	s.NoError(err)
+	s.Empty(subGroups)
+}
+
+// TestCreateSubGroupAttributes tests that the attributes passed to CreateSubGroup are stored.
+// Keycloak only relocates a group when the posted representation carries its ID, which
+// CreateSubGroup never sends, so the relocate follow-up is covered by the unit tests.
+func (s *GroupsIntegrationTestSuite) TestCreateSubGroupAttributes() {
+	parentName := fmt.Sprintf("test-subgroup-attrs-parent-%d", time.Now().Unix())
+	parentID, err := s.client.Groups.Create(s.ctx, parentName, nil)
+	s.Require().NoError(err)
+	s.trackGroup(parentID)
+
+	childName := fmt.Sprintf("test-subgroup-attrs-child-%d", time.Now().Unix())
+	childID, err := s.client.Groups.CreateSubGroup(s.ctx, parentID, childName, map[string][]string{
+		"code": {"kept"},
+	})
+	s.Require().NoError(err)
+	s.Require().NotEmpty(childID)
+	s.trackGroup(childID)
+
+	child, err := s.client.Groups.Get(s.ctx, childID)
+	s.Require().NoError(err)
+	s.Equal(parentID, *child.ParentID)
+	s.Require().NotNil(child.Attributes)
+	s.Equal([]string{"kept"}, (*child.Attributes)["code"])
+}
+
 // TestGroupCount tests counting groups
 func (s *GroupsIntegrationTestSuite) TestGroupCount() {
 	count, err := s.client.Groups.Count(s.ctx, nil, nil)
@@ -341,7 +392,7 @@ func (s *GroupsIntegrationTestSuite) TestErrorHandling() {
 
 	// Try to create subgroup under non-existent parent
 	_, err = s.client.Groups.CreateSubGroup(s.ctx, "non-existent-parent", "subgroup", nil)
-	s.Error(err)
+	s.ErrorIs(err, keycloak.ErrGroupNotFound)
 }
 
 // TestComplexHierarchy tests multi-level group hierarchy
@@ -852,6 +903,21 @@ func (s *GroupsIntegrationTestSuite) TestSearchSubGroupsByAttributesWithQ() {
 		s.T().Log("Compare the results to understand behavior differences")
 	})
 
+	// Test 4: FindSubGroupsByAttribute extracts the matching subgroups from the q results
+	s.Run("find_subgroups_by_attribute", func() {
+		subGroups, err := s.client.Groups.FindSubGroupsByAttribute(s.ctx, keycloak.GroupAttribute{Key: "team", Value: "backend"})
+		s.Require().NoError(err)
+
+		parents := make(map[string]string)
+		for _, subGroup := range subGroups {
+			parents[ptr.ToString(subGroup.ID)] = ptr.ToString(subGroup.ParentID)
+		}
+		s.Equal(parentID, parents[subGroup1ID], "backend subgroup should be returned with its parent")
+		s.Equal(otherParentID, parents[otherSubGroupID], "backend subgroup of the other parent should be returned")
+		s.NotContains(parents, subGroup2ID, "frontend subgroup should not match")
+		s.NotContains(parents, parentID, "parent groups should not be returned")
+	})
+
 	// Summary
 	s.T().Log(strings.Repeat("-", 70))
 	s.T().Log("SUBGROUP Q PARAMETER TEST CONCLUSION")
@@ -0,0 +1,293 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Logger is the logging interface used by the client. It matches resty's logger, so the
+// same logger also receives the debug output enabled by WithDebug.
+//
+// Loggers that additionally implement StructuredLogger receive request events with their
+// key/value attributes intact; plain loggers get them appended as "key=value" pairs.
+type Logger interface {
+	Errorf(format string, v ...any)
+	Warnf(format string, v ...any)
+	Debugf(format string, v ...any)
+}
+
+// StructuredLogger is an optional extension of Logger for structured logging backends.
+// Attributes are passed as alternating keys and values, as with slog.
+type StructuredLogger interface {
+	Logger
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+// WithLogger sets the logger used for request events and resty's debug output.
+// Each completed request is logged at debug level with its method, path, status and
+// duration; requests that fail without a response are logged at error level.
+// Use SlogLogger to log through the standard library's log/slog.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithLogger(keycloak.SlogLogger(slog.Default())),
+//	)
+func WithLogger(logger Logger) Option {
+	return func(c *Client) error {
+		if logger == nil {
+			return fmt.Errorf("logger cannot be nil")
+		}
+		c.logger = logger
+		c.resty.SetLogger(logger)
+		return nil
+	}
+}
+
+// WithLogFields sets a function that extracts caller-defined key/value pairs (e.g. a tenant
+// or trace ID) from the request context. It is invoked for every log event and the pairs are
+// appended to the event's attributes, so the client's logs carry the caller's context without
+// the client knowing the keys. Has no effect without WithLogger.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithLogger(keycloak.SlogLogger(slog.Default())),
+//	    keycloak.WithLogFields(func(ctx context.Context) []any {
+//	        return []any{"tenant", tenantFromContext(ctx)}
+//	    }),
+//	)
+func WithLogFields(fields func(ctx context.Context) []any) Option {
+	return func(c *Client) error {
+		if fields == nil {
+			return fmt.Errorf("log fields function cannot be nil")
+		}
+		c.logFields = fields
+		return nil
+	}
+}
+
+// truncatedMarker is appended to logged bodies cut by WithMaxLoggedBodyBytes.
+const truncatedMarker = "…(truncated)"
+
+// WithMaxLoggedBodyBytes truncates the request and response bodies in the debug output
+// enabled by WithDebug to n bytes, followed by a "…(truncated)" marker, so that large list
+// responses do not flood the logs. It applies whichever logger receives the debug output
+// (resty's default or the one set with WithLogger); the request events of WithLogger carry
+// no bodies. By default, bodies are logged in full.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithDebug(true),
+//	    keycloak.WithMaxLoggedBodyBytes(4096),
+//	)
+func WithMaxLoggedBodyBytes(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("max logged body bytes must be positive, got %d", n)
+		}
+		c.maxLoggedBody = n
+		return nil
+	}
+}
+
+// truncateLogBody shortens body to at most n bytes, without splitting a UTF-8 sequence, and
+// marks it as truncated. Bodies of at most n bytes are returned unchanged.
+func truncateLogBody(body string, n int) string {
+	if len(body) <= n {
+		return body
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return body[:cut] + truncatedMarker
+}
+
+// truncateDebugBodies registers resty log callbacks that truncate the bodies in the debug
+// output to the configured size.
+func (c *Client) truncateDebugBodies() {
+	c.resty.OnRequestLog(func(log *resty.RequestLog) error {
+		log.Body = truncateLogBody(log.Body, c.maxLoggedBody)
+		return nil
+	})
+	c.resty.OnResponseLog(func(log *resty.ResponseLog) error {
+		log.Body = truncateLogBody(log.Body, c.maxLoggedBody)
+		return nil
+	})
+}
+
+// WithResponseErrorSampleOnSuccessLog logs successful (2xx) responses whose body carries
+// Keycloak error fields (error, errorMessage or error_description) as warnings. Some endpoints
+// answer 200 with such a body to report a soft failure, e.g. a partially applied change,
+// which would otherwise go unnoticed since the call succeeds. Only JSON object bodies are
+// inspected. Has no effect without WithLogger.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithLogger(keycloak.SlogLogger(slog.Default())),
+//	    keycloak.WithResponseErrorSampleOnSuccessLog(),
+//	)
+func WithResponseErrorSampleOnSuccessLog() Option {
+	return func(c *Client) error {
+		c.logSuccessErrors = true
+		return nil
+	}
+}
+
+// logSuccessError is a resty middleware that logs successful responses carrying an error
+// body as warnings.
+func (c *Client) logSuccessError(_ *resty.Client, resp *resty.Response) error {
+	if !resp.IsSuccess() {
+		return nil
+	}
+	// Skip arrays, e.g. list responses, without decoding them
+	body := bytes.TrimSpace(resp.Body())
+	if len(body) == 0 || body[0] != '{' {
+		return nil
+	}
+	var details HTTPErrorResponse
+	if err := unmarshalJSON(body, &details); err != nil || details.Empty() {
+		return nil
+	}
+
+	req := resp.Request
+	c.logEvent(req.Context(), slog.LevelWarn, "keycloak request succeeded with an error body",
+		"method", req.Method,
+		"path", requestPath(req),
+		"status", resp.StatusCode(),
+		"error", details.String(),
+	)
+	return nil
+}
+
+// SlogLogger adapts a *slog.Logger to the Logger and StructuredLogger interfaces.
+// Debugf, Warnf and Errorf map to the Debug, Warn and Error levels, and the attributes
+// of request events (method, path, status, duration) are passed through as slog attributes.
+// A nil logger uses slog.Default().
+func SlogLogger(logger *slog.Logger) StructuredLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+// slogLogger implements StructuredLogger on top of log/slog.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l *slogLogger) Errorf(format string, v ...any) {
+	l.logger.Error(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l *slogLogger) Warnf(format string, v ...any) {
+	l.logger.Warn(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l *slogLogger) Debugf(format string, v ...any) {
+	l.logger.Debug(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l *slogLogger) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	l.logger.Log(ctx, level, msg, args...)
+}
+
+// logEvent emits a log event with key/value attributes through the configured logger.
+// It is a no-op when no logger is configured.
+func (c *Client) logEvent(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if c.logger == nil {
+		return
+	}
+	if c.logFields != nil {
+		args = append(args, c.logFields(ctx)...)
+	}
+	if structured, ok := c.logger.(StructuredLogger); ok {
+		structured.Log(ctx, level, msg, args...)
+		return
+	}
+
+	line := msg + formatLogArgs(args)
+	switch {
+	case level >= slog.LevelError:
+		c.logger.Errorf("%s", line)
+	case level >= slog.LevelWarn:
+		c.logger.Warnf("%s", line)
+	default:
+		c.logger.Debugf("%s", line)
+	}
+}
+
+// formatLogArgs renders alternating keys and values as " key=value" pairs.
+func formatLogArgs(args []any) string {
+	var b strings.Builder
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	return b.String()
+}
+
+// logResponse is a resty middleware that logs every completed request.
+func (c *Client) logResponse(_ *resty.Client, resp *resty.Response) error {
+	req := resp.Request
+	c.logEvent(req.Context(), slog.LevelDebug, "keycloak request completed",
+		"method", req.Method,
+		"path", requestPath(req),
+		"status", resp.StatusCode(),
+		"duration", resp.Time().Round(time.Microsecond),
+	)
+	return nil
+}
+
+// logError is a resty error hook that logs requests failing without a response.
+func (c *Client) logError(req *resty.Request, err error) {
+	var respErr *resty.ResponseError
+	if errors.As(err, &respErr) {
+		if respErr.Response != nil && respErr.Response.RawResponse != nil {
+			// Already logged by logResponse
+			return
+		}
+		err = respErr.Err
+	}
+	c.logEvent(req.Context(), slog.LevelError, "keycloak request failed",
+		"method", req.Method,
+		"path", requestPath(req),
+		"error", err,
+	)
+}
+
+// requestPath returns the URL path of the request, without query parameters.
+func requestPath(req *resty.Request) string {
+	if req.RawRequest != nil {
+		return req.RawRequest.URL.Path
+	}
+	return req.URL
+}
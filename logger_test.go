@@ -0,0 +1,253 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger is a plain Logger that records formatted lines per level.
+type testLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) record(level, format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, v...))
+}
+
+func (l *testLogger) Errorf(format string, v ...any) { l.record("ERROR", format, v...) }
+func (l *testLogger) Warnf(format string, v ...any)  { l.record("WARN", format, v...) }
+func (l *testLogger) Debugf(format string, v ...any) { l.record("DEBUG", format, v...) }
+
+func (l *testLogger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.lines...)
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Run("nil logger", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithLogger(nil)(client))
+	})
+
+	t.Run("plain logger", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		logger := &testLogger{}
+		client := newTestClient(server.URL, WithLogger(logger))
+
+		_, err := client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+
+		lines := logger.Lines()
+		require.Len(t, lines, 1)
+		assert.True(t, strings.HasPrefix(lines[0], "DEBUG keycloak request completed method=GET path=/admin/realms/test-realm/groups status=200 duration="), lines[0])
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		logger := &testLogger{}
+		client := newTestClient(server.URL, WithLogger(logger))
+
+		_, err := client.Groups.List(context.Background(), nil, true)
+		require.Error(t, err)
+
+		lines := logger.Lines()
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "ERROR keycloak request failed method=GET")
+		assert.Contains(t, lines[0], "error=")
+	})
+}
+
+func TestSlogLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	client := newTestClient(server.URL, WithLogger(SlogLogger(slog.New(handler))))
+
+	_, err := client.Groups.Get(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrGroupNotFound)
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &event))
+	assert.Equal(t, "DEBUG", event["level"])
+	assert.Equal(t, "keycloak request completed", event["msg"])
+	assert.Equal(t, "GET", event["method"])
+	assert.Equal(t, "/admin/realms/test-realm/groups/missing", event["path"])
+	assert.Equal(t, float64(http.StatusNotFound), event["status"])
+	assert.Contains(t, event, "duration")
+}
+
+type tenantKey struct{}
+
+func TestWithLogFields(t *testing.T) {
+	t.Run("nil function", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithLogFields(nil)(client))
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	fields := func(ctx context.Context) []any {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return []any{"tenant", tenant, "trace_id", "trace-1"}
+	}
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	t.Run("structured logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+		client := newTestClient(server.URL, WithLogger(SlogLogger(slog.New(handler))), WithLogFields(fields))
+
+		_, err := client.Groups.List(ctx, nil, true)
+		require.NoError(t, err)
+
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &event))
+		assert.Equal(t, "keycloak request completed", event["msg"])
+		assert.Equal(t, "GET", event["method"])
+		assert.Equal(t, "acme", event["tenant"])
+		assert.Equal(t, "trace-1", event["trace_id"])
+	})
+
+	t.Run("plain logger", func(t *testing.T) {
+		logger := &testLogger{}
+		client := newTestClient(server.URL, WithLogger(logger), WithLogFields(fields))
+
+		_, err := client.Groups.List(ctx, nil, true)
+		require.NoError(t, err)
+
+		lines := logger.Lines()
+		require.Len(t, lines, 1)
+		assert.True(t, strings.HasSuffix(lines[0], " tenant=acme trace_id=trace-1"), lines[0])
+	})
+}
+
+func TestSlogLogger_LevelMapping(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger := SlogLogger(slog.New(handler))
+
+	logger.Debugf("debug %d\n", 1)
+	logger.Warnf("warn %d", 2)
+	logger.Errorf("error %d", 3)
+
+	out := buf.String()
+	assert.Contains(t, out, `level=DEBUG msg="debug 1"`)
+	assert.Contains(t, out, `level=WARN msg="warn 2"`)
+	assert.Contains(t, out, `level=ERROR msg="error 3"`)
+}
+
+func TestTruncateLogBody(t *testing.T) {
+	assert.Equal(t, "abcd", truncateLogBody("abcd", 4))
+	assert.Equal(t, "abcd"+truncatedMarker, truncateLogBody("abcde", 4))
+	// A multi-byte rune crossing the limit is dropped as a whole
+	assert.Equal(t, "ab"+truncatedMarker, truncateLogBody("abé", 3))
+}
+
+func TestWithMaxLoggedBodyBytes(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	assert.Error(t, WithMaxLoggedBodyBytes(0)(client))
+	assert.Error(t, WithMaxLoggedBodyBytes(-1)(client))
+
+	body := `[{"id":"` + strings.Repeat("x", 100) + `"}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	logger := &testLogger{}
+	client = newTestClient(server.URL, WithLogger(logger), WithDebug(true), WithMaxLoggedBodyBytes(16))
+
+	_, err := client.Groups.List(context.Background(), nil, true)
+	require.NoError(t, err)
+
+	debug := strings.Join(logger.Lines(), "\n")
+	assert.Contains(t, debug, body[:16]+truncatedMarker)
+	assert.NotContains(t, debug, body[:17])
+}
+
+func TestWithResponseErrorSampleOnSuccessLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/admin/realms/test-realm/groups/warned":
+			w.Write([]byte(`{"id":"warned","errorMessage":"attributes were not applied"}`))
+		case "/admin/realms/test-realm/groups/plain":
+			w.Write([]byte(`{"id":"plain","name":"team"}`))
+		default:
+			w.Write([]byte(`[{"id":"g1","name":"error"}]`))
+		}
+	}))
+	defer server.Close()
+
+	logger := &testLogger{}
+	client := newTestClient(server.URL, WithLogger(logger), WithResponseErrorSampleOnSuccessLog())
+	ctx := context.Background()
+
+	_, err := client.Groups.Get(ctx, "plain")
+	require.NoError(t, err)
+	_, err = client.Groups.List(ctx, nil, true)
+	require.NoError(t, err)
+	for _, line := range logger.Lines() {
+		assert.False(t, strings.HasPrefix(line, "WARN"), line)
+	}
+
+	_, err = client.Groups.Get(ctx, "warned")
+	require.NoError(t, err)
+	lines := logger.Lines()
+	assert.Equal(t, "WARN keycloak request succeeded with an error body method=GET path=/admin/realms/test-realm/groups/warned status=200 error=attributes were not applied", lines[len(lines)-1])
+
+	t.Run("disabled", func(t *testing.T) {
+		logger := &testLogger{}
+		client := newTestClient(server.URL, WithLogger(logger))
+		_, err := client.Groups.Get(ctx, "warned")
+		require.NoError(t, err)
+		for _, line := range logger.Lines() {
+			assert.False(t, strings.HasPrefix(line, "WARN"), line)
+		}
+	})
+}
@@ -17,6 +17,7 @@ package keycloak
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -404,3 +405,113 @@ func TestCountGroupResponse_JSONMarshaling(t *testing.T) {
 		})
 	}
 }
+
+func TestGroup_AttributeCoercion(t *testing.T) {
+	group := &Group{
+		Attributes: &map[string][]string{
+			"lastModified": {"1735689600"},
+			"enabled":      {"true", "false"},
+			"created":      {"2025-01-01T00:00:00Z"},
+			"invalid":      {"not-a-value"},
+			"empty":        {},
+		},
+	}
+
+	t.Run("AttributeInt", func(t *testing.T) {
+		v, ok := group.AttributeInt("lastModified")
+		assert.True(t, ok)
+		assert.Equal(t, int64(1735689600), v)
+
+		_, ok = group.AttributeInt("invalid")
+		assert.False(t, ok)
+		_, ok = group.AttributeInt("missing")
+		assert.False(t, ok)
+		_, ok = group.AttributeInt("empty")
+		assert.False(t, ok)
+	})
+
+	t.Run("AttributeBool uses first value", func(t *testing.T) {
+		v, ok := group.AttributeBool("enabled")
+		assert.True(t, ok)
+		assert.True(t, v)
+
+		_, ok = group.AttributeBool("invalid")
+		assert.False(t, ok)
+	})
+
+	t.Run("AttributeTime", func(t *testing.T) {
+		v, ok := group.AttributeTime("created", time.RFC3339)
+		assert.True(t, ok)
+		assert.True(t, v.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+		_, ok = group.AttributeTime("invalid", time.RFC3339)
+		assert.False(t, ok)
+	})
+
+	t.Run("nil attributes", func(t *testing.T) {
+		var empty Group
+		_, ok := empty.AttributeInt("lastModified")
+		assert.False(t, ok)
+
+		var nilGroup *Group
+		_, ok = nilGroup.AttributeBool("enabled")
+		assert.False(t, ok)
+	})
+}
+
+func TestGroup_IsBrief(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		group *Group
+		want  bool
+	}{
+		{name: "brief representation", json: `{"id":"g1","name":"team","path":"/team","subGroupCount":0,"access":{"view":true}}`, want: true},
+		{name: "full representation without attributes", json: `{"id":"g1","name":"team","attributes":{},"realmRoles":[],"clientRoles":{}}`, want: false},
+		{name: "full representation with attributes", json: `{"id":"g1","name":"team","attributes":{"owner":["ops"]}}`, want: false},
+		{name: "roles only", json: `{"id":"g1","name":"team","realmRoles":["admin"]}`, want: false},
+		{name: "group without ID", group: &Group{Name: ptr.String("team")}, want: false},
+		{name: "nil group", group: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := tt.group
+			if tt.json != "" {
+				require.NoError(t, json.Unmarshal([]byte(tt.json), &group))
+			}
+			assert.Equal(t, tt.want, group.IsBrief())
+		})
+	}
+}
+
+func TestNormalizeAttributes(t *testing.T) {
+	fetched := &Group{
+		Attributes: &map[string][]string{
+			"team":  {"beta", "alpha"},
+			"owner": {"bob"},
+		},
+		SubGroups: &[]*Group{
+			{Attributes: &map[string][]string{"region": {"us", "eu"}}},
+			{},
+			nil,
+		},
+	}
+	expected := &Group{
+		Attributes: &map[string][]string{
+			"team":  {"alpha", "beta"},
+			"owner": {"bob"},
+		},
+		SubGroups: &[]*Group{
+			{Attributes: &map[string][]string{"region": {"eu", "us"}}},
+			{},
+			nil,
+		},
+	}
+
+	assert.NotEqual(t, expected, fetched)
+	NormalizeAttributes(fetched)
+	assert.Equal(t, expected, fetched)
+
+	assert.NotPanics(t, func() { NormalizeAttributes(nil) })
+}
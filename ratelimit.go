@@ -0,0 +1,121 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// RateLimitInfo holds the rate limit state reported by a gateway in front of Keycloak through
+// the X-RateLimit-* or RateLimit-* response headers. Keycloak itself sends none. Fields the
+// response did not report are nil.
+type RateLimitInfo struct {
+	Limit     *int       // Requests allowed in the current window (X-RateLimit-Limit or RateLimit-Limit)
+	Remaining *int       // Requests left in the current window (X-RateLimit-Remaining or RateLimit-Remaining)
+	Reset     *time.Time // When the current window resets (X-RateLimit-Reset or RateLimit-Reset)
+	Time      time.Time  // When the response carrying the headers was received
+}
+
+// resetEpochThreshold separates reset values given as Unix times from values given in seconds
+// from now; no rate limit window lasts anywhere near 2001.
+const resetEpochThreshold = 1_000_000_000
+
+// WithRateLimitTracking parses the rate limit headers a gateway adds to responses and keeps
+// the latest values, available through Client.LastRateLimit, so that callers can throttle
+// themselves before being rejected. Both the X-RateLimit-* and the standard RateLimit-*
+// headers are understood. Responses without rate limit headers leave the last values as they
+// are.
+//
+// If hook is non-nil, it is called with the values of every response carrying rate limit
+// headers, e.g. to record metrics. It runs synchronously on the request path, so it should
+// return quickly.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithRateLimitTracking(func(info keycloak.RateLimitInfo) {
+//	        if info.Remaining != nil {
+//	            rateLimitRemaining.Set(float64(*info.Remaining))
+//	        }
+//	    }),
+//	)
+func WithRateLimitTracking(hook func(RateLimitInfo)) Option {
+	return func(c *Client) error {
+		c.trackRateLimit = true
+		c.rateLimitHook = hook
+		return nil
+	}
+}
+
+// LastRateLimit returns the rate limit values of the most recent response carrying rate limit
+// headers. It returns the zero value if WithRateLimitTracking is not set or no such response
+// has been received yet.
+func (c *Client) LastRateLimit() RateLimitInfo {
+	if info := c.lastRateLimit.Load(); info != nil {
+		return *info
+	}
+	return RateLimitInfo{}
+}
+
+// recordRateLimit is a resty middleware that keeps the rate limit headers of each response.
+// Failed responses are included, since a 429 carries the most useful values.
+func (c *Client) recordRateLimit(_ *resty.Client, resp *resty.Response) error {
+	info, ok := parseRateLimit(resp.Header(), time.Now())
+	if !ok {
+		return nil
+	}
+	c.lastRateLimit.Store(&info)
+	if c.rateLimitHook != nil {
+		c.rateLimitHook(info)
+	}
+	return nil
+}
+
+// parseRateLimit extracts the rate limit headers from header, preferring the X-RateLimit-*
+// variant of each. ok is false if none of them is present and valid.
+func parseRateLimit(header http.Header, now time.Time) (info RateLimitInfo, ok bool) {
+	value := func(name string) (int, bool) {
+		for _, key := range []string{"X-RateLimit-" + name, "RateLimit-" + name} {
+			if n, err := strconv.Atoi(strings.TrimSpace(header.Get(key))); err == nil && n >= 0 {
+				return n, true
+			}
+		}
+		return 0, false
+	}
+
+	if n, found := value("Limit"); found {
+		info.Limit = &n
+	}
+	if n, found := value("Remaining"); found {
+		info.Remaining = &n
+	}
+	if n, found := value("Reset"); found {
+		reset := now.Add(time.Duration(n) * time.Second)
+		if n >= resetEpochThreshold {
+			reset = time.Unix(int64(n), 0)
+		}
+		info.Reset = &reset
+	}
+	if info.Limit == nil && info.Remaining == nil && info.Reset == nil {
+		return RateLimitInfo{}, false
+	}
+	info.Time = now
+	return info, true
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRateLimitTracking(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/admin/realms/test-realm/groups/count":
+			w.Header().Set("X-RateLimit-Limit", "100")
+			w.Header().Set("X-RateLimit-Remaining", "41")
+			w.Header().Set("X-RateLimit-Reset", "30")
+			w.Write([]byte(`{"count":1}`))
+		case "/admin/realms/test-realm/groups/limited":
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"too many requests"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var recorded []RateLimitInfo
+	client := newTestClient(server.URL, WithRateLimitTracking(func(info RateLimitInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		recorded = append(recorded, info)
+	}))
+	assert.Equal(t, RateLimitInfo{}, client.LastRateLimit())
+
+	before := time.Now()
+	_, err := client.Groups.Count(ctx, nil, nil)
+	require.NoError(t, err)
+	info := client.LastRateLimit()
+	require.NotNil(t, info.Limit)
+	require.NotNil(t, info.Remaining)
+	require.NotNil(t, info.Reset)
+	assert.Equal(t, 100, *info.Limit)
+	assert.Equal(t, 41, *info.Remaining)
+	assert.WithinDuration(t, before.Add(30*time.Second), *info.Reset, 5*time.Second)
+	assert.False(t, info.Time.Before(before))
+
+	// Responses without rate limit headers keep the last values
+	_, err = client.Groups.Get(ctx, "other")
+	require.NoError(t, err)
+	assert.Equal(t, info, client.LastRateLimit())
+
+	// Rejected requests are tracked too
+	_, err = client.Groups.Get(ctx, "limited")
+	require.Error(t, err)
+	info = client.LastRateLimit()
+	require.NotNil(t, info.Remaining)
+	assert.Equal(t, 0, *info.Remaining)
+	assert.Nil(t, info.Limit)
+
+	mu.Lock()
+	assert.Len(t, recorded, 2)
+	mu.Unlock()
+
+	t.Run("disabled", func(t *testing.T) {
+		client := newTestClient(server.URL)
+		_, err := client.Groups.Count(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, RateLimitInfo{}, client.LastRateLimit())
+	})
+}
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, ok := parseRateLimit(http.Header{}, now)
+	assert.False(t, ok)
+	_, ok = parseRateLimit(http.Header{"X-Ratelimit-Remaining": {"many"}}, now)
+	assert.False(t, ok)
+
+	// The X-RateLimit-* variant wins over RateLimit-*
+	info, ok := parseRateLimit(http.Header{
+		"X-Ratelimit-Remaining": {"5"},
+		"Ratelimit-Remaining":   {"7"},
+		"Ratelimit-Limit":       {"10"},
+	}, now)
+	require.True(t, ok)
+	assert.Equal(t, 5, *info.Remaining)
+	assert.Equal(t, 10, *info.Limit)
+	assert.Nil(t, info.Reset)
+	assert.Equal(t, now, info.Time)
+
+	// Large reset values are Unix times
+	info, ok = parseRateLimit(http.Header{"X-Ratelimit-Reset": {"1735736400"}}, now)
+	require.True(t, ok)
+	assert.Equal(t, time.Unix(1735736400, 0), *info.Reset)
+}
@@ -0,0 +1,270 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ListRealms returns the realms visible to the authenticated client.
+// Listing realms requires an administrator of the master realm; other clients
+// get ErrForbidden.
+func (c *Client) ListRealms(ctx context.Context) ([]*RealmRepresentation, error) {
+	var (
+		result  []*RealmRepresentation
+		errResp HTTPErrorResponse
+	)
+
+	resp, err := c.executeList(c.resty.R().SetContext(ctx).SetError(&errResp), &result,
+		endpointRealmsList.Method, c.buildURL(endpointRealmsList, nil))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list realms: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusForbidden {
+			return nil, fmt.Errorf("unable to list realms: %w: %w", ErrForbidden, newAPIError(resp))
+		}
+		return nil, fmt.Errorf("unable to list realms: %w", newAPIError(resp))
+	}
+
+	return result, nil
+}
+
+// Counts returns the number of groups, users and clients in the realm, e.g. for a dashboard
+// overview of the realm size. The three counts are fetched concurrently.
+//
+// A failing count does not discard the others: the counts that succeeded are still returned,
+// together with an error naming each failed count. Groups are counted with the groups count
+// endpoint and users with the users count endpoint; Keycloak has no count endpoint for
+// clients, so they are listed with the client's page size, and ErrPageLimitExceeded is
+// reported if the listing reaches the page limit. For WithEndpointTimeouts, the requests are
+// identified as "Groups.Count", "Users.Count" and "Clients.InternalID".
+func (c *Client) Counts(ctx context.Context) (*RealmCounts, error) {
+	var (
+		counts                          RealmCounts
+		groupsErr, usersErr, clientsErr error
+		wg                              sync.WaitGroup
+	)
+	// The unwrapped groups client is used so that, like the other counts and the other Client
+	// methods, the groups count is not subject to WithErrorTransform
+	groups := newGroupsClient(c)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		var n int
+		if n, groupsErr = groups.Count(ctx, nil, nil); groupsErr == nil {
+			counts.Groups = &n
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var n int
+		if n, usersErr = c.countUsers(ctx); usersErr == nil {
+			counts.Users = &n
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var n int
+		if n, clientsErr = c.countClients(ctx); clientsErr == nil {
+			counts.Clients = &n
+		}
+	}()
+	wg.Wait()
+
+	var errs []error
+	for _, failure := range []struct {
+		name string
+		err  error
+	}{
+		{"groups", groupsErr},
+		{"users", usersErr},
+		{"clients", clientsErr},
+	} {
+		if failure.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", failure.name, failure.err))
+		}
+	}
+	if len(errs) > 0 {
+		return &counts, fmt.Errorf("unable to count all resources: %w", errors.Join(errs...))
+	}
+	return &counts, nil
+}
+
+// countUsers returns the number of users in the realm.
+func (c *Client) countUsers(ctx context.Context) (int, error) {
+	var (
+		result  int
+		errResp HTTPErrorResponse
+	)
+
+	resp, err := c.resty.R().
+		SetContext(ctx).
+		SetError(&errResp).
+		SetResult(&result).
+		Execute(endpointUsersCount.Method, c.buildURL(endpointUsersCount, nil))
+	if err != nil {
+		return 0, fmt.Errorf("unable to count users: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return 0, fmt.Errorf("unable to count users: %w", newAPIError(resp))
+	}
+
+	return result, nil
+}
+
+// countClients returns the number of clients in the realm by paging through the client list.
+func (c *Client) countClients(ctx context.Context) (int, error) {
+	count := 0
+	for page := 0; ; page++ {
+		if page >= c.maxPages {
+			return 0, fmt.Errorf("unable to count clients: %w (%d pages of %d)", ErrPageLimitExceeded, c.maxPages, c.pageSize)
+		}
+
+		var (
+			result  []*ClientRepresentation
+			errResp HTTPErrorResponse
+		)
+		req := c.resty.R().
+			SetContext(ctx).
+			SetError(&errResp).
+			SetQueryParam("first", strconv.Itoa(page*c.pageSize)).
+			SetQueryParam("max", strconv.Itoa(c.pageSize))
+		resp, err := c.executeList(req, &result, endpointClientsList.Method, c.buildURL(endpointClientsList, nil))
+		if err != nil {
+			return 0, fmt.Errorf("unable to count clients: %w", err)
+		}
+
+		if !resp.IsSuccess() {
+			return 0, fmt.Errorf("unable to count clients: %w", newAPIError(resp))
+		}
+
+		count += len(result)
+		if len(result) < c.pageSize {
+			return count, nil
+		}
+	}
+}
+
+// minPartialImportVersion is the oldest Keycloak major version PartialImport is used with.
+// Older servers lack parts of the import API and its result format, and are not supported
+// by this package.
+const minPartialImportVersion = 20
+
+// PartialImport creates groups, users and clients in the realm in a single call, which is
+// much faster than creating them one by one for large imports. req.IfResourceExists decides
+// what happens to resources that already exist; with PartialImportFail nothing is imported
+// if any of them exists.
+//
+// The server version is checked first (see ServerVersion) and ErrUnsupportedServer is returned
+// for servers older than Keycloak 20 or servers without the partial import endpoint.
+func (c *Client) PartialImport(ctx context.Context, req PartialImportRequest) (*PartialImportResult, error) {
+	switch req.IfResourceExists {
+	case PartialImportFail, PartialImportSkip, PartialImportOverwrite:
+	default:
+		return nil, fmt.Errorf("invalid ifResourceExists policy %q (use FAIL, SKIP or OVERWRITE)", req.IfResourceExists)
+	}
+
+	version, err := c.ServerVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to import: %w", err)
+	}
+	if major, ok := majorVersion(version); ok && major < minPartialImportVersion {
+		return nil, fmt.Errorf("%w: partial import requires Keycloak %d or later, server runs %s", ErrUnsupportedServer, minPartialImportVersion, version)
+	}
+
+	var (
+		result  PartialImportResult
+		errResp HTTPErrorResponse
+	)
+
+	resp, err := c.resty.R().
+		SetContext(ctx).
+		SetError(&errResp).
+		SetBody(req).
+		SetResult(&result).
+		Execute(endpointPartialImport.Method, c.buildURL(endpointPartialImport, nil))
+	if err != nil {
+		return nil, fmt.Errorf("unable to import: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: partial import endpoint not found on Keycloak %s", ErrUnsupportedServer, version)
+		}
+		return nil, fmt.Errorf("unable to import: %w", newAPIError(resp))
+	}
+
+	return &result, nil
+}
+
+// ServerVersion returns the version of the Keycloak server (e.g. "26.0.5"), as reported by
+// the server info endpoint. The version is cached for the lifetime of the Client once fetched;
+// concurrent first calls may each fetch it.
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	// The lock only guards the cache, so that callers never wait on another caller's request
+	c.serverVersionMu.Lock()
+	version := c.serverVersion
+	c.serverVersionMu.Unlock()
+	if version != "" {
+		return version, nil
+	}
+
+	var (
+		result struct {
+			SystemInfo struct {
+				Version string `json:"version"`
+			} `json:"systemInfo"`
+		}
+		errResp HTTPErrorResponse
+	)
+
+	resp, err := c.resty.R().
+		SetContext(ctx).
+		SetError(&errResp).
+		SetResult(&result).
+		Execute(endpointServerInfo.Method, c.buildURL(endpointServerInfo, nil))
+	if err != nil {
+		return "", fmt.Errorf("unable to get server version: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return "", fmt.Errorf("unable to get server version: %w", newAPIError(resp))
+	}
+	if result.SystemInfo.Version == "" {
+		return "", errors.New("unable to get server version: server info contains no version")
+	}
+
+	c.serverVersionMu.Lock()
+	c.serverVersion = result.SystemInfo.Version
+	c.serverVersionMu.Unlock()
+	return result.SystemInfo.Version, nil
+}
+
+// majorVersion parses the major version from a Keycloak version string such as "26.0.5"
+// or "999.0.0-SNAPSHOT". ok is false if the version does not start with a number.
+func majorVersion(version string) (major int, ok bool) {
+	prefix, _, _ := strings.Cut(version, ".")
+	major, err := strconv.Atoi(prefix)
+	return major, err == nil
+}
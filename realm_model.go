@@ -0,0 +1,71 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+// RealmRepresentation holds the basic properties of a Keycloak realm.
+// This struct maps to a subset of Keycloak's RealmRepresentation.
+type RealmRepresentation struct {
+	ID          *string `json:"id,omitempty"`          // Internal ID of the realm
+	Realm       *string `json:"realm,omitempty"`       // Realm name, as used in URLs
+	Enabled     *bool   `json:"enabled,omitempty"`     // Whether the realm is enabled
+	DisplayName *string `json:"displayName,omitempty"` // Human-readable name of the realm
+}
+
+// RealmCounts holds the number of resources in a realm, as returned by Counts.
+// A nil field means the count could not be fetched.
+type RealmCounts struct {
+	Groups  *int // Number of groups, including subgroups
+	Users   *int // Number of users
+	Clients *int // Number of clients
+}
+
+// PartialImportPolicy determines how PartialImport handles resources that already exist.
+type PartialImportPolicy string
+
+const (
+	// PartialImportFail aborts the whole import if any resource already exists.
+	PartialImportFail PartialImportPolicy = "FAIL"
+	// PartialImportSkip leaves existing resources untouched and imports the rest.
+	PartialImportSkip PartialImportPolicy = "SKIP"
+	// PartialImportOverwrite replaces existing resources with the imported ones.
+	PartialImportOverwrite PartialImportPolicy = "OVERWRITE"
+)
+
+// PartialImportRequest holds the resources to import into the realm in a single call.
+// This struct maps to the subset of Keycloak's PartialImportRepresentation used by this package.
+type PartialImportRequest struct {
+	IfResourceExists PartialImportPolicy     `json:"ifResourceExists"`  // Policy for resources that already exist (required)
+	Groups           []*Group                `json:"groups,omitempty"`  // Groups to import, including their subgroups
+	Users            []*User                 `json:"users,omitempty"`   // Users to import
+	Clients          []*ClientRepresentation `json:"clients,omitempty"` // Clients to import
+}
+
+// PartialImportResult summarizes the outcome of a partial import.
+// This struct maps to Keycloak's PartialImportResults.
+type PartialImportResult struct {
+	Added       int                         `json:"added"`             // Number of resources added
+	Skipped     int                         `json:"skipped"`           // Number of existing resources skipped
+	Overwritten int                         `json:"overwritten"`       // Number of existing resources overwritten
+	Results     []*PartialImportResultEntry `json:"results,omitempty"` // Outcome per resource
+}
+
+// PartialImportResultEntry describes what happened to a single imported resource.
+// This struct maps to Keycloak's PartialImportResult.
+type PartialImportResultEntry struct {
+	Action       string `json:"action,omitempty"`       // ADDED, SKIPPED or OVERWRITTEN
+	ResourceType string `json:"resourceType,omitempty"` // Type of the resource (e.g. GROUP, USER, CLIENT)
+	ResourceName string `json:"resourceName,omitempty"` // Name of the resource
+	ID           string `json:"id,omitempty"`           // ID of the resource
+}
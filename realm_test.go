@@ -0,0 +1,325 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.companyinfo.dev/ptr"
+)
+
+// TestClient_ListRealmsWithServer tests ListRealms for a master realm admin and a client without access
+func TestClient_ListRealmsWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		mockBody       string
+		wantRealms     []string
+		wantErr        error
+	}{
+		{
+			name:           "realms listed",
+			mockStatusCode: http.StatusOK,
+			mockBody:       `[{"id":"r-1","realm":"master","enabled":true},{"id":"r-2","realm":"acme","enabled":false,"displayName":"ACME"}]`,
+			wantRealms:     []string{"master", "acme"},
+		},
+		{
+			name:           "forbidden",
+			mockStatusCode: http.StatusForbidden,
+			mockBody:       `{"error":"unknown_error"}`,
+			wantErr:        ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.mockStatusCode)
+				w.Write([]byte(tt.mockBody))
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			realms, err := client.ListRealms(context.Background())
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				var apiErr *APIError
+				require.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, tt.mockStatusCode, apiErr.StatusCode)
+				assert.Nil(t, realms)
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			for _, realm := range realms {
+				names = append(names, ptr.ToString(realm.Realm))
+			}
+			assert.Equal(t, tt.wantRealms, names)
+			assert.Equal(t, "ACME", ptr.ToString(realms[1].DisplayName))
+			assert.Equal(t, ptr.Bool(false), realms[1].Enabled)
+		})
+	}
+}
+
+// TestClient_PartialImportWithServer tests PartialImport against servers of different versions
+func TestClient_PartialImportWithServer(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       string
+		importStatus  int
+		wantErr       error
+		wantImportHit bool
+	}{
+		{
+			name:          "supported server",
+			version:       "26.0.5",
+			importStatus:  http.StatusOK,
+			wantImportHit: true,
+		},
+		{
+			name:          "snapshot build",
+			version:       "999.0.0-SNAPSHOT",
+			importStatus:  http.StatusOK,
+			wantImportHit: true,
+		},
+		{
+			name:    "old server",
+			version: "19.0.3",
+			wantErr: ErrUnsupportedServer,
+		},
+		{
+			name:          "endpoint missing",
+			version:       "unknown",
+			importStatus:  http.StatusNotFound,
+			wantErr:       ErrUnsupportedServer,
+			wantImportHit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var importHit bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/admin/serverinfo":
+					w.Write([]byte(`{"systemInfo":{"version":"` + tt.version + `"}}`))
+				case "/admin/realms/test-realm/partialImport":
+					importHit = true
+					assert.Equal(t, http.MethodPost, r.Method)
+					var body map[string]any
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+					assert.Equal(t, "SKIP", body["ifResourceExists"])
+					assert.Len(t, body["groups"], 1)
+					assert.NotContains(t, body, "users")
+
+					w.WriteHeader(tt.importStatus)
+					if tt.importStatus == http.StatusOK {
+						w.Write([]byte(`{"added":1,"skipped":0,"overwritten":0,"results":[{"action":"ADDED","resourceType":"GROUP","resourceName":"g","id":"g-1"}]}`))
+					}
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			result, err := client.PartialImport(context.Background(), PartialImportRequest{
+				IfResourceExists: PartialImportSkip,
+				Groups:           []*Group{{Name: ptr.String("g")}},
+			})
+
+			assert.Equal(t, tt.wantImportHit, importHit)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, result.Added)
+			require.Len(t, result.Results, 1)
+			assert.Equal(t, "g-1", result.Results[0].ID)
+		})
+	}
+}
+
+// TestClient_PartialImportValidation tests that an invalid policy is rejected before any request is sent
+func TestClient_PartialImportValidation(t *testing.T) {
+	client := newTestClient("http://localhost")
+
+	for _, policy := range []PartialImportPolicy{"", "skip", "MERGE"} {
+		result, err := client.PartialImport(context.Background(), PartialImportRequest{IfResourceExists: policy})
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	}
+}
+
+// TestClient_ServerVersionCached tests that the server version is fetched only once
+func TestClient_ServerVersionCached(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "/admin/serverinfo", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"systemInfo":{"version":"26.0.5"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	for range 2 {
+		version, err := client.ServerVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "26.0.5", version)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+// TestClient_CountsWithServer tests Counts with all counts available and with a failing one
+func TestClient_CountsWithServer(t *testing.T) {
+	var usersDown, groupsDown atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/admin/realms/test-realm/groups/count":
+			if groupsDown.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"error":"unknown_error"}`))
+				return
+			}
+			w.Write([]byte(`{"count":12}`))
+		case "/admin/realms/test-realm/users/count":
+			if usersDown.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"error":"unknown_error"}`))
+				return
+			}
+			w.Write([]byte(`42`))
+		case "/admin/realms/test-realm/clients":
+			// Five clients, listed in pages
+			first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+			max, _ := strconv.Atoi(r.URL.Query().Get("max"))
+			clients := make([]*ClientRepresentation, 0, max)
+			for i := first; i < min(first+max, 5); i++ {
+				clients = append(clients, &ClientRepresentation{ClientID: ptr.String(strconv.Itoa(i))})
+			}
+			json.NewEncoder(w).Encode(clients)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, WithPageSize(2))
+	counts, err := client.Counts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &RealmCounts{Groups: ptr.Int(12), Users: ptr.Int(42), Clients: ptr.Int(5)}, counts)
+
+	t.Run("partial failure", func(t *testing.T) {
+		usersDown.Store(true)
+		counts, err := client.Counts(context.Background())
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "users:")
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.Equal(t, &RealmCounts{Groups: ptr.Int(12), Clients: ptr.Int(5)}, counts)
+	})
+
+	t.Run("error transform not applied", func(t *testing.T) {
+		groupsDown.Store(true)
+		usersDown.Store(true)
+		transformed := newTestClient(server.URL, WithPageSize(2), WithErrorTransform(func(err error) error {
+			return errors.New("transformed")
+		}))
+		_, err := transformed.Counts(context.Background())
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "transformed")
+		assert.ErrorContains(t, err, "groups:")
+		assert.ErrorContains(t, err, "users:")
+	})
+}
+
+// TestClient_ServerVersionConcurrentDeadline tests that a caller does not wait for another
+// caller's slow server info request beyond its own deadline
+func TestClient_ServerVersionConcurrentDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"systemInfo":{"version":"26.0.5"}}`))
+	}))
+	defer server.Close()
+	client := newTestClient(server.URL)
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := client.ServerVersion(context.Background())
+		slow <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.ServerVersion(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	close(release)
+	require.NoError(t, <-slow)
+	version, err := client.ServerVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "26.0.5", version)
+}
+
+func TestMajorVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		wantMajor int
+		wantOK    bool
+	}{
+		{version: "26.0.5", wantMajor: 26, wantOK: true},
+		{version: "999.0.0-SNAPSHOT", wantMajor: 999, wantOK: true},
+		{version: "20", wantMajor: 20, wantOK: true},
+		{version: "unknown"},
+		{version: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			major, ok := majorVersion(tt.version)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.wantMajor, major)
+			}
+		})
+	}
+}
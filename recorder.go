@@ -0,0 +1,261 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// defaultRecorderBodyLimit is the default number of body bytes kept per recorded request/response.
+	defaultRecorderBodyLimit = 4096
+
+	redactedValue  = "[REDACTED]"
+	truncatedLabel = "...(truncated)"
+)
+
+// sensitiveHeaders lists the headers whose values are never written by the recorder.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveFields lists the JSON body fields (case-insensitive) whose values are never written by the recorder.
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"secret":        true,
+	"secretdata":    true,
+	"client_secret": true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+}
+
+// credentialTypes lists the "type" values of Keycloak credential representations, whose
+// "value" field holds the secret itself, e.g. in reset-password bodies. Other "value" fields,
+// such as attribute or config values, are recorded as they are.
+var credentialTypes = map[string]bool{
+	"password": true,
+	"otp":      true,
+	"totp":     true,
+	"hotp":     true,
+}
+
+// HTTPRecord is a single request/response pair written by WithHTTPRecorder as one JSON line.
+// Sensitive headers and body fields are redacted and bodies are truncated.
+type HTTPRecord struct {
+	Time            time.Time   `json:"time"`                      // When the request was sent
+	Method          string      `json:"method"`                    // HTTP method
+	URL             string      `json:"url"`                       // Full request URL including query
+	RequestHeaders  http.Header `json:"requestHeaders,omitempty"`  // Request headers (redacted)
+	RequestBody     string      `json:"requestBody,omitempty"`     // Request body (redacted, truncated)
+	StatusCode      int         `json:"statusCode,omitempty"`      // Response status code (0 if no response)
+	ResponseHeaders http.Header `json:"responseHeaders,omitempty"` // Response headers (redacted)
+	ResponseBody    string      `json:"responseBody,omitempty"`    // Response body (redacted, truncated)
+	Duration        string      `json:"duration,omitempty"`        // Time until the response was received
+	Error           string      `json:"error,omitempty"`           // Transport error, if any
+}
+
+// httpRecorder writes HTTPRecords as JSON lines to a writer.
+type httpRecorder struct {
+	mu        sync.Mutex
+	w         io.Writer
+	bodyLimit int
+}
+
+// WithHTTPRecorder writes every request/response pair as a JSON line (see HTTPRecord) to w.
+// Unlike WithDebug, the output is machine-parseable and suited for CI artifacts when
+// debugging flaky integration tests. Authorization and cookie headers, as well as
+// password, secret and token fields in JSON bodies, are redacted. Bodies are truncated
+// to 4096 bytes unless changed with WithHTTPRecorderBodyLimit.
+//
+// Example:
+//
+//	f, _ := os.Create("keycloak-http.jsonl")
+//	defer f.Close()
+//	client, err := keycloak.New(ctx, config, keycloak.WithHTTPRecorder(f))
+func WithHTTPRecorder(w io.Writer) Option {
+	return func(c *Client) error {
+		if w == nil {
+			return errors.New("recorder writer cannot be nil")
+		}
+		limit := defaultRecorderBodyLimit
+		if c.recorder != nil {
+			limit = c.recorder.bodyLimit
+		}
+		c.recorder = &httpRecorder{w: w, bodyLimit: limit}
+		return nil
+	}
+}
+
+// WithHTTPRecorderBodyLimit sets how many bytes of each request and response body are
+// written by WithHTTPRecorder. Zero omits bodies entirely. Default is 4096.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithHTTPRecorder(f),
+//	    keycloak.WithHTTPRecorderBodyLimit(1024),
+//	)
+func WithHTTPRecorderBodyLimit(n int) Option {
+	return func(c *Client) error {
+		if n < 0 {
+			return errors.New("recorder body limit must be non-negative")
+		}
+		if c.recorder == nil {
+			c.recorder = &httpRecorder{}
+		}
+		c.recorder.bodyLimit = n
+		return nil
+	}
+}
+
+// onResponse records a completed request/response pair.
+func (r *httpRecorder) onResponse(_ *resty.Client, resp *resty.Response) error {
+	record := r.newRecord(resp.Request)
+	record.StatusCode = resp.StatusCode()
+	record.ResponseHeaders = redactHeaders(resp.Header())
+	record.ResponseBody = r.body(resp.Body())
+	record.Duration = resp.Time().String()
+	r.write(record)
+	return nil
+}
+
+// onError records a request that failed without a usable response.
+func (r *httpRecorder) onError(req *resty.Request, err error) {
+	var respErr *resty.ResponseError
+	if errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.RawResponse != nil {
+		// Already recorded by onResponse
+		return
+	}
+	record := r.newRecord(req)
+	record.Error = err.Error()
+	r.write(record)
+}
+
+// newRecord fills the request part of a record.
+func (r *httpRecorder) newRecord(req *resty.Request) HTTPRecord {
+	record := HTTPRecord{
+		Time:   req.Time,
+		Method: req.Method,
+		URL:    req.URL,
+	}
+	if req.RawRequest != nil {
+		record.URL = req.RawRequest.URL.String()
+		record.RequestHeaders = redactHeaders(req.RawRequest.Header)
+	}
+	if req.Body != nil {
+		record.RequestBody = r.body(requestBodyBytes(req.Body))
+	}
+	return record
+}
+
+// body redacts and truncates a body for recording.
+func (r *httpRecorder) body(b []byte) string {
+	if r.bodyLimit == 0 || len(b) == 0 {
+		return ""
+	}
+	return truncateBody(redactBody(b), r.bodyLimit)
+}
+
+// write serializes a record as a single JSON line. Errors are ignored because
+// recording must never affect the outcome of a request.
+func (r *httpRecorder) write(record HTTPRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.w.Write(append(line, '\n'))
+}
+
+// requestBodyBytes returns the serialized form of a resty request body.
+func requestBodyBytes(body any) []byte {
+	switch v := body.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+}
+
+// redactHeaders returns a copy of the headers with sensitive values replaced.
+func redactHeaders(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	redacted := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, redactedValue)
+		}
+	}
+	return redacted
+}
+
+// redactBody replaces the values of sensitive fields in a JSON body.
+// Bodies that are not JSON are returned unchanged.
+func redactBody(b []byte) []byte {
+	var generic any
+	if err := unmarshalJSON(b, &generic); err != nil {
+		return b
+	}
+	redacted, err := json.Marshal(redactValue(generic))
+	if err != nil {
+		return b
+	}
+	return redacted
+}
+
+// redactValue walks a decoded JSON value and replaces sensitive fields.
+func redactValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		credentialType, _ := value["type"].(string)
+		credential := credentialTypes[strings.ToLower(credentialType)]
+		for key, field := range value {
+			if sensitiveFields[strings.ToLower(key)] || (credential && strings.EqualFold(key, "value")) {
+				value[key] = redactedValue
+				continue
+			}
+			value[key] = redactValue(field)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+	return v
+}
+
+// truncateBody shortens a body to limit bytes, appending a marker when truncated.
+func truncateBody(b []byte, limit int) string {
+	if len(b) <= limit {
+		return string(b)
+	}
+	return string(b[:limit]) + truncatedLabel
+}
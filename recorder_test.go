@@ -0,0 +1,169 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readRecords parses the JSON lines written by the recorder.
+func readRecords(t *testing.T, buf *bytes.Buffer) []HTTPRecord {
+	t.Helper()
+	var records []HTTPRecord
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var record HTTPRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestWithHTTPRecorder(t *testing.T) {
+	t.Run("nil writer", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithHTTPRecorder(nil)(client))
+	})
+
+	t.Run("negative body limit", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithHTTPRecorderBodyLimit(-1)(client))
+	})
+
+	t.Run("body limit is kept regardless of option order", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		require.NoError(t, WithHTTPRecorderBodyLimit(10)(client))
+		require.NoError(t, WithHTTPRecorder(&bytes.Buffer{})(client))
+		assert.Equal(t, 10, client.recorder.bodyLimit)
+	})
+}
+
+func TestHTTPRecorder_RecordsRedactedPairs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := newTestClient(server.URL,
+		WithHTTPRecorder(&buf),
+		WithHeaders(map[string]string{"Authorization": "Bearer secret-token"}),
+	)
+
+	err := client.Users.ResetPassword(context.Background(), "user-1", "hunter2", false)
+	require.NoError(t, err)
+
+	records := readRecords(t, &buf)
+	require.Len(t, records, 1)
+	record := records[0]
+
+	assert.Equal(t, http.MethodPut, record.Method)
+	assert.Equal(t, server.URL+"/admin/realms/test-realm/users/user-1/reset-password", record.URL)
+	assert.Equal(t, http.StatusNoContent, record.StatusCode)
+	assert.Equal(t, redactedValue, record.RequestHeaders.Get("Authorization"))
+	assert.Equal(t, redactedValue, record.ResponseHeaders.Get("Set-Cookie"))
+	assert.Contains(t, record.RequestBody, `"type":"password"`)
+	assert.NotContains(t, record.RequestBody, "hunter2")
+	assert.NotEmpty(t, record.Duration)
+}
+
+func TestHTTPRecorder_TruncatesBodies(t *testing.T) {
+	large := `[{"name":"` + strings.Repeat("x", 100) + `"}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(large))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := newTestClient(server.URL, WithHTTPRecorder(&buf), WithHTTPRecorderBodyLimit(16))
+
+	_, err := client.Groups.List(context.Background(), nil, true)
+	require.NoError(t, err)
+
+	records := readRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, large[:16]+truncatedLabel, records[0].ResponseBody)
+}
+
+func TestHTTPRecorder_RecordsTransportErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	var buf bytes.Buffer
+	client := newTestClient(serverURL, WithHTTPRecorder(&buf))
+
+	_, err := client.Groups.List(context.Background(), nil, true)
+	require.Error(t, err)
+
+	records := readRecords(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, http.MethodGet, records[0].Method)
+	assert.Zero(t, records[0].StatusCode)
+	assert.NotEmpty(t, records[0].Error)
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "nested sensitive fields",
+			body: `{"username":"jdoe","credentials":[{"type":"password","value":"hunter2"}]}`,
+			want: `{"credentials":[{"type":"password","value":"[REDACTED]"}],"username":"jdoe"}`,
+		},
+		{
+			name: "reset password body",
+			body: `{"type":"password","value":"hunter2","temporary":false}`,
+			want: `{"temporary":false,"type":"password","value":"[REDACTED]"}`,
+		},
+		{
+			name: "non-credential values are kept",
+			body: `{"config":{"claim.name":"team"},"attributes":[{"name":"tier","value":"gold"}],"type":"string","value":"plain"}`,
+			want: `{"attributes":[{"name":"tier","value":"gold"}],"config":{"claim.name":"team"},"type":"string","value":"plain"}`,
+		},
+		{
+			name: "token response",
+			body: `{"access_token":"abc","expires_in":300}`,
+			want: `{"access_token":"[REDACTED]","expires_in":300}`,
+		},
+		{
+			name: "non-JSON body is unchanged",
+			body: `grant_type=client_credentials`,
+			want: `grant_type=client_credentials`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(redactBody([]byte(tt.body))))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// WithMaxRetryAfter caps how long the client waits on a Retry-After header. With WithRetry,
+// 429 Too Many Requests and 503 Service Unavailable responses carrying Retry-After are retried
+// after the requested delay (bounded by WithRetry's maxWaitTime). When the server asks for
+// more than d, the client gives up at once and returns an error wrapping both
+// ErrRetryAfterExceeded and the APIError of the response, instead of stalling or retrying
+// too early. Default is 0 (no cap).
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithRetry(3, time.Second, time.Minute),
+//	    keycloak.WithMaxRetryAfter(30*time.Second),
+//	)
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(c *Client) error {
+		if d < 0 {
+			return fmt.Errorf("max retry after must be non-negative, got %s", d)
+		}
+		c.maxRetryAfter = d
+		return nil
+	}
+}
+
+// retryOnRetryAfter is a retry condition matching throttling and unavailability responses
+// in which the server states when to retry. Registering a condition replaces resty's default
+// of retrying requests that failed without a response, so that case is kept here; errors
+// raised by response middleware are not retried, as before.
+func retryOnRetryAfter(resp *resty.Response, err error) bool {
+	if resp == nil || resp.RawResponse == nil {
+		return err != nil
+	}
+	if err != nil {
+		return false
+	}
+	switch resp.StatusCode() {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return resp.Header().Get("Retry-After") != ""
+	}
+	return false
+}
+
+// retryAfter returns the delay requested by the Retry-After header of resp, or zero (meaning
+// resty's default backoff) if there is none. A delay above the WithMaxRetryAfter cap stops
+// the retries with an error.
+func (c *Client) retryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	wait, ok := parseRetryAfter(resp.Header().Get("Retry-After"), time.Now())
+	if !ok {
+		return 0, nil
+	}
+	if c.maxRetryAfter > 0 && wait > c.maxRetryAfter {
+		return 0, fmt.Errorf("%w: server asked to wait %s, at most %s allowed: %w",
+			ErrRetryAfterExceeded, wait, c.maxRetryAfter, newAPIError(resp))
+	}
+	return wait, nil
+}
+
+// parseRetryAfter parses a Retry-After value given either in seconds or as an HTTP date.
+// Dates in the past yield a zero delay.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		// Avoid overflowing time.Duration on absurd values
+		seconds = min(seconds, math.MaxInt64/int64(time.Second))
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxRetryAfter(t *testing.T) {
+	t.Run("negative", func(t *testing.T) {
+		assert.Error(t, WithMaxRetryAfter(-time.Second)(&Client{}))
+	})
+
+	t.Run("oversized Retry-After gives up", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL,
+			WithRetry(3, time.Millisecond, time.Hour),
+			WithMaxRetryAfter(time.Second),
+		)
+
+		start := time.Now()
+		_, err := client.Groups.Get(context.Background(), "g1")
+		assert.Less(t, time.Since(start), time.Second)
+		assert.ErrorIs(t, err, ErrRetryAfterExceeded)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("Retry-After within cap is honored", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"g1"}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL,
+			WithRetry(3, time.Millisecond, 10*time.Millisecond),
+			WithMaxRetryAfter(time.Second),
+		)
+
+		group, err := client.Groups.Get(context.Background(), "g1")
+		require.NoError(t, err)
+		assert.Equal(t, "g1", *group.ID)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("errors without Retry-After are not retried", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, WithRetry(3, time.Millisecond, time.Millisecond))
+		_, err := client.Groups.Get(context.Background(), "g1")
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: "-1", wantOK: false},
+		{value: "99999999999999999", want: time.Duration(math.MaxInt64/int64(time.Second)) * time.Second, wantOK: true},
+		{value: "soon", wantOK: false},
+		{value: "Wed, 01 Jan 2025 12:01:00 GMT", want: time.Minute, wantOK: true},
+		{value: "Wed, 01 Jan 2025 11:00:00 GMT", want: 0, wantOK: true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		assert.Equal(t, tt.wantOK, ok, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+// Role represents a Keycloak realm or client role.
+// This struct maps to Keycloak's RoleRepresentation.
+type Role struct {
+	ID          *string              `json:"id,omitempty"`          // Unique identifier for the role
+	Name        *string              `json:"name,omitempty"`        // Role name
+	Description *string              `json:"description,omitempty"` // Description of the role
+	Composite   *bool                `json:"composite,omitempty"`   // Whether the role is a composite of other roles
+	ClientRole  *bool                `json:"clientRole,omitempty"`  // Whether the role belongs to a client (false for realm roles)
+	ContainerID *string              `json:"containerId,omitempty"` // ID of the realm or client owning the role
+	Attributes  *map[string][]string `json:"attributes,omitempty"`  // Custom role attributes
+}
+
+// RoleMappings holds the realm and client roles mapped to a group or user.
+// ClientMappings is keyed by the client's clientId (not its internal ID).
+// Both fields are empty, not nil, when nothing is mapped.
+type RoleMappings struct {
+	RealmMappings  []*Role            // Realm-level roles
+	ClientMappings map[string][]*Role // Client roles, keyed by clientId
+}
+
+// mappingsRepresentation is the wire format of Keycloak's MappingsRepresentation.
+type mappingsRepresentation struct {
+	RealmMappings  []*Role                                 `json:"realmMappings,omitempty"`
+	ClientMappings map[string]clientMappingsRepresentation `json:"clientMappings,omitempty"`
+}
+
+// clientMappingsRepresentation is the wire format of Keycloak's ClientMappingsRepresentation.
+type clientMappingsRepresentation struct {
+	ID       string  `json:"id,omitempty"`       // Internal ID of the client
+	Client   string  `json:"client,omitempty"`   // clientId of the client
+	Mappings []*Role `json:"mappings,omitempty"` // Roles of the client that are mapped
+}
+
+// toRoleMappings converts the wire format into RoleMappings.
+func (m mappingsRepresentation) toRoleMappings() *RoleMappings {
+	result := &RoleMappings{
+		RealmMappings:  m.RealmMappings,
+		ClientMappings: make(map[string][]*Role, len(m.ClientMappings)),
+	}
+	if result.RealmMappings == nil {
+		result.RealmMappings = []*Role{}
+	}
+	for clientID, mappings := range m.ClientMappings {
+		if mappings.Client != "" {
+			clientID = mappings.Client
+		}
+		result.ClientMappings[clientID] = mappings.Mappings
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// SlowCall describes a request that took longer than the WithSlowCallThreshold threshold.
+type SlowCall struct {
+	Method     string        // HTTP method of the request
+	Path       string        // URL path of the request
+	StatusCode int           // Response status code (0 if the call was cancelled)
+	Duration   time.Duration // Time the call took, or the threshold if it was cancelled
+	Cancelled  bool          // Whether the call was cancelled by WithCancelSlowCalls
+}
+
+// errSlowCallTimeout is the context cause used when a slow call is cancelled.
+var errSlowCallTimeout = errors.New("slow call threshold exceeded")
+
+// WithSlowCallThreshold reports calls taking longer than threshold, which helps spotting
+// a degraded Keycloak without failing calls that are merely slow. Slow calls are logged
+// as warnings through the logger set with WithLogger and passed to the WithSlowCallHook
+// callback, if any. Unlike WithTimeout, calls are not aborted unless WithCancelSlowCalls
+// is also set.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithSlowCallThreshold(2*time.Second),
+//	    keycloak.WithSlowCallHook(func(call keycloak.SlowCall) {
+//	        slowCalls.WithLabelValues(call.Method).Inc()
+//	    }),
+//	)
+func WithSlowCallThreshold(threshold time.Duration) Option {
+	return func(c *Client) error {
+		if threshold <= 0 {
+			return fmt.Errorf("slow call threshold must be positive, got %v", threshold)
+		}
+		c.slowThreshold = threshold
+		return nil
+	}
+}
+
+// WithSlowCallHook registers a callback invoked for every call exceeding the
+// WithSlowCallThreshold threshold, e.g. to record metrics. The callback runs
+// synchronously on the request path, so it should return quickly.
+// Has no effect without WithSlowCallThreshold.
+func WithSlowCallHook(hook func(SlowCall)) Option {
+	return func(c *Client) error {
+		if hook == nil {
+			return fmt.Errorf("slow call hook cannot be nil")
+		}
+		c.slowCallHook = hook
+		return nil
+	}
+}
+
+// WithCancelSlowCalls aborts calls once they exceed the WithSlowCallThreshold threshold.
+// Cancelled calls fail with an error wrapping ErrSlowCall. Each attempt of a retried
+// call gets the full threshold. Has no effect without WithSlowCallThreshold.
+func WithCancelSlowCalls(cancel bool) Option {
+	return func(c *Client) error {
+		c.cancelSlowCalls = cancel
+		return nil
+	}
+}
+
+// reportSlowCall logs the slow call and passes it to the hook, if any.
+func (c *Client) reportSlowCall(ctx context.Context, call SlowCall) {
+	c.logEvent(ctx, slog.LevelWarn, "keycloak slow call",
+		"method", call.Method,
+		"path", call.Path,
+		"status", call.StatusCode,
+		"duration", call.Duration,
+		"cancelled", call.Cancelled,
+	)
+	if c.slowCallHook != nil {
+		c.slowCallHook(call)
+	}
+}
+
+// checkSlowCall is a resty middleware that reports completed calls exceeding the threshold.
+func (c *Client) checkSlowCall(_ *resty.Client, resp *resty.Response) error {
+	if resp.Time() < c.slowThreshold {
+		return nil
+	}
+	req := resp.Request
+	c.reportSlowCall(req.Context(), SlowCall{
+		Method:     req.Method,
+		Path:       requestPath(req),
+		StatusCode: resp.StatusCode(),
+		Duration:   resp.Time(),
+	})
+	return nil
+}
+
+// slowCallTransport is an http.RoundTripper that cancels requests exceeding a threshold.
+// The deadline covers the round trip and reading the response body.
+type slowCallTransport struct {
+	base      http.RoundTripper
+	threshold time.Duration
+	report    func(ctx context.Context, call SlowCall)
+}
+
+// newSlowCallTransport wraps base so that requests are cancelled after threshold.
+func newSlowCallTransport(base http.RoundTripper, threshold time.Duration, report func(context.Context, SlowCall)) *slowCallTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &slowCallTransport{
+		base:      base,
+		threshold: threshold,
+		report:    report,
+	}
+}
+
+// RoundTrip sends the request with a deadline of the threshold.
+func (t *slowCallTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeoutCause(req.Context(), t.threshold, errSlowCallTimeout)
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(context.Cause(ctx), errSlowCallTimeout) {
+			t.report(req.Context(), SlowCall{
+				Method:    req.Method,
+				Path:      req.URL.Path,
+				Duration:  t.threshold,
+				Cancelled: true,
+			})
+			return nil, fmt.Errorf("%w: %s %s took longer than %s", ErrSlowCall, req.Method, req.URL.Path, t.threshold)
+		}
+		return nil, err
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: cancel}
+	return resp, nil
+}
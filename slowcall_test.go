@@ -0,0 +1,118 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSlowCallThreshold(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	assert.Error(t, WithSlowCallThreshold(0)(client))
+	assert.Error(t, WithSlowCallThreshold(-time.Second)(client))
+	assert.Error(t, WithSlowCallHook(nil)(client))
+	assert.NoError(t, WithSlowCallThreshold(time.Second)(client))
+	assert.Equal(t, time.Second, client.slowThreshold)
+}
+
+// newSlowServer returns a server that delays responses to paths containing "slow".
+func newSlowServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "slow") {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+}
+
+func TestSlowCallHook(t *testing.T) {
+	server := newSlowServer(100 * time.Millisecond)
+	defer server.Close()
+
+	var mu sync.Mutex
+	var calls []SlowCall
+	logger := &testLogger{}
+	client := newTestClient(server.URL,
+		WithLogger(logger),
+		WithSlowCallThreshold(50*time.Millisecond),
+		WithSlowCallHook(func(call SlowCall) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, call)
+		}),
+	)
+
+	_, err := client.Groups.Get(context.Background(), "fast")
+	require.NoError(t, err)
+	_, err = client.Groups.Get(context.Background(), "slow")
+	require.NoError(t, err, "slow calls must not fail without WithCancelSlowCalls")
+
+	require.Len(t, calls, 1)
+	assert.Equal(t, "GET", calls[0].Method)
+	assert.Equal(t, "/admin/realms/test-realm/groups/slow", calls[0].Path)
+	assert.Equal(t, http.StatusOK, calls[0].StatusCode)
+	assert.GreaterOrEqual(t, calls[0].Duration, 50*time.Millisecond)
+	assert.False(t, calls[0].Cancelled)
+
+	var warnings []string
+	for _, line := range logger.Lines() {
+		if strings.HasPrefix(line, "WARN") {
+			warnings = append(warnings, line)
+		}
+	}
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "keycloak slow call method=GET path=/admin/realms/test-realm/groups/slow")
+}
+
+func TestWithCancelSlowCalls(t *testing.T) {
+	server := newSlowServer(time.Second)
+	defer server.Close()
+
+	var calls []SlowCall
+	client := newTestClient(server.URL,
+		WithSlowCallThreshold(50*time.Millisecond),
+		WithCancelSlowCalls(true),
+		WithSlowCallHook(func(call SlowCall) {
+			calls = append(calls, call)
+		}),
+	)
+
+	start := time.Now()
+	_, err := client.Groups.Get(context.Background(), "slow")
+	assert.ErrorIs(t, err, ErrSlowCall)
+	assert.Less(t, time.Since(start), time.Second)
+
+	require.Len(t, calls, 1)
+	assert.True(t, calls[0].Cancelled)
+	assert.Equal(t, "/admin/realms/test-realm/groups/slow", calls[0].Path)
+
+	_, err = client.Groups.Get(context.Background(), "fast")
+	assert.NoError(t, err)
+	assert.Len(t, calls, 1)
+}
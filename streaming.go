@@ -0,0 +1,166 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// WithStreamingDecode makes list methods decode successful responses directly from the
+// connection instead of reading the whole body into memory first. Default is false.
+//
+// A buffered list response is held in memory twice while it is decoded: once as raw JSON
+// and once as the decoded slice. Streaming decodes the array element by element, so only
+// one raw element is held at a time, at the cost of keeping the connection open while
+// decoding and somewhat more CPU per element. The decoded slice itself is still built in
+// memory; for the largest responses prefer the paging methods (such as IterateMembers)
+// over a single large list call.
+//
+// Streaming applies to ListWithParams (and the methods built on it), ListSubGroups,
+// ListSubGroupsPaginated, ListMembers, Clients.ListRoles, Clients.ListProtocolMappers and
+// ListRealms. Error responses are always buffered. Streaming is not used when
+// WithSuccessValidator or WithHTTPRecorder is configured, since both need the raw body.
+//
+// Example:
+//
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithStreamingDecode(true),
+//	)
+func WithStreamingDecode(enabled bool) Option {
+	return func(c *Client) error {
+		c.streamingDecode = enabled
+		return nil
+	}
+}
+
+// onAfterResponse registers a response middleware with resty and remembers it, so that
+// executeList can run it for streamed responses, which resty does not pass to middleware.
+func (c *Client) onAfterResponse(m resty.ResponseMiddleware) {
+	c.responseMiddleware = append(c.responseMiddleware, m)
+	c.resty.OnAfterResponse(m)
+}
+
+// executeList executes a list request and decodes a successful JSON response into result,
+// streaming it from the connection when WithStreamingDecode is enabled.
+func (c *Client) executeList(req *resty.Request, result any, method, url string) (*resty.Response, error) {
+	if !c.streamingDecode || c.validator != nil || (c.recorder != nil && c.recorder.w != nil) {
+		return req.SetResult(result).Execute(method, url)
+	}
+
+	resp, err := req.SetDoNotParseResponse(true).Execute(method, url)
+	if err != nil {
+		return resp, err
+	}
+	raw := resp.RawBody()
+	defer raw.Close()
+
+	body, err := decompressedBody(resp, raw)
+	if err != nil {
+		return resp, err
+	}
+
+	if !resp.IsSuccess() {
+		// Error bodies are small; buffer them so that newAPIError and the middleware see them
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return resp, err
+		}
+		resp.SetBody(b)
+		if req.Error != nil && len(b) > 0 {
+			// Like resty, keep the response usable if the error body is not JSON
+			_ = unmarshalJSON(b, req.Error)
+		}
+	}
+
+	for _, m := range c.responseMiddleware {
+		if err := m(c.resty, resp); err != nil {
+			return resp, err
+		}
+	}
+
+	if resp.IsSuccess() {
+		// An empty body leaves result untouched, as it does for buffered responses
+		if err := decodeJSONArray(body, result); err != nil && !errors.Is(err, io.EOF) {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// decompressedBody returns the body of a streamed response, decompressing it if it is still
+// gzip-encoded. This is the case when Accept-Encoding was set explicitly (see WithForceGzip),
+// since the transport then leaves decompression to the caller, as resty does for buffered
+// responses. An empty gzip body reads as empty.
+func decompressedBody(resp *resty.Response, raw io.Reader) (io.Reader, error) {
+	if !strings.EqualFold(resp.Header().Get("Content-Encoding"), "gzip") || resp.RawResponse.ContentLength == 0 {
+		return raw, nil
+	}
+	body, err := gzip.NewReader(raw)
+	if errors.Is(err, io.EOF) {
+		return strings.NewReader(""), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip response body: %w", err)
+	}
+	return body, nil
+}
+
+// decodeJSONArray decodes a JSON array from r into the slice pointed to by v one element at
+// a time. Unlike json.Decoder.Decode, which reads a whole value into memory before decoding
+// it, this only holds one raw element at a time. Numbers are decoded like decodeJSON does.
+func decodeJSONArray(r io.Reader, v any) error {
+	slice := reflect.ValueOf(v)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("decodeJSONArray: expected a pointer to a slice, got %T", v)
+	}
+	slice = slice.Elem()
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		// null leaves the slice nil, as json.Unmarshal does
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected a JSON array, got %v", token)
+	}
+
+	elems := reflect.MakeSlice(slice.Type(), 0, 0)
+	for decoder.More() {
+		elem := reflect.New(slice.Type().Elem())
+		if err := decoder.Decode(elem.Interface()); err != nil {
+			return err
+		}
+		elems = reflect.Append(elems, elem.Elem())
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	slice.Set(elems)
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.companyinfo.dev/ptr"
+)
+
+func TestWithStreamingDecode(t *testing.T) {
+	client := &Client{}
+	require.NoError(t, WithStreamingDecode(true)(client))
+	assert.True(t, client.streamingDecode)
+	require.NoError(t, WithStreamingDecode(false)(client))
+	assert.False(t, client.streamingDecode)
+}
+
+func TestExecuteList_Streaming(t *testing.T) {
+	groups := []*Group{
+		{ID: ptr.String("g1"), Name: ptr.String("one"), Attributes: &map[string][]string{"k": {"v"}}},
+		{ID: ptr.String("g2"), Name: ptr.String("two")},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Trace", "t1")
+		switch r.URL.Path {
+		case "/admin/realms/test-realm/groups":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(groups)
+		case "/admin/realms/test-realm/groups/g1/children":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"forbidden","errorMessage":"no access"}`))
+		case "/admin/realms/test-realm/groups/g2/children":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"id":`))
+		case "/admin/realms/test-realm/groups/g3/members":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	buffered := newTestClient(server.URL)
+	streaming := newTestClient(server.URL, WithStreamingDecode(true))
+
+	t.Run("same result as buffered", func(t *testing.T) {
+		want, err := buffered.Groups.ListWithParams(context.Background(), SearchGroupParams{})
+		require.NoError(t, err)
+
+		var headers http.Header
+		ctx := CaptureHeaders(context.Background(), &headers, "X-Trace")
+		got, err := streaming.Groups.ListWithParams(ctx, SearchGroupParams{})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.Equal(t, "t1", headers.Get("X-Trace"), "response middleware must run for streamed responses")
+	})
+
+	t.Run("error body is decoded", func(t *testing.T) {
+		_, err := streaming.Groups.ListSubGroups(context.Background(), "g1")
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+		assert.Equal(t, "no access", apiErr.Response.Message)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		_, err := streaming.Groups.ListSubGroups(context.Background(), "g2")
+		assert.Error(t, err)
+	})
+
+	t.Run("middleware error", func(t *testing.T) {
+		_, err := streaming.Groups.ListMembers(context.Background(), "g3", GroupMembersParams{})
+		assert.ErrorIs(t, err, ErrMethodNotAllowed)
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		members, err := streaming.Groups.ListMembers(context.Background(), "g4", GroupMembersParams{})
+		require.NoError(t, err)
+		assert.Empty(t, members)
+	})
+
+	t.Run("validator disables streaming", func(t *testing.T) {
+		var seen []byte
+		client := newTestClient(server.URL, WithStreamingDecode(true), WithSuccessValidator(func(resp *http.Response, body []byte) error {
+			seen = body
+			if len(body) == 0 {
+				return errors.New("empty body")
+			}
+			return nil
+		}))
+		got, err := client.Groups.ListWithParams(context.Background(), SearchGroupParams{})
+		require.NoError(t, err)
+		assert.Len(t, got, 2)
+		assert.NotEmpty(t, seen)
+	})
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	var groups []*Group
+	require.NoError(t, decodeJSONArray(strings.NewReader(`[{"id":"g1","attributes":{"n":["1"]}},{"id":"g2"}]`), &groups))
+	require.Len(t, groups, 2)
+	assert.Equal(t, "g1", *groups[0].ID)
+	assert.Equal(t, "g2", *groups[1].ID)
+
+	var empty []*Group
+	require.NoError(t, decodeJSONArray(strings.NewReader(`[]`), &empty))
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+
+	var null []*Group
+	require.NoError(t, decodeJSONArray(strings.NewReader(`null`), &null))
+	assert.Nil(t, null)
+
+	var values []any
+	require.NoError(t, decodeJSONArray(strings.NewReader(`[1700000000123]`), &values))
+	assert.Equal(t, json.Number("1700000000123"), values[0])
+
+	assert.Error(t, decodeJSONArray(strings.NewReader(`{"id":"g1"}`), &groups))
+	assert.Error(t, decodeJSONArray(strings.NewReader(`[{"id":"g1"}`), &groups))
+	assert.Error(t, decodeJSONArray(strings.NewReader(`[]`), &Group{}))
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// WithTokenCacheFile persists the access token to the given file and reuses it on the
+// next run until it expires, so that command-line tools invoked repeatedly do not request
+// a new token every time. The file is written with 0600 permissions and only ever holds
+// the token, never the client secret. A token is only reused for the same token endpoint
+// and client ID, and the file is discarded when Keycloak rejects the token with 401.
+// Caching is best-effort: unreadable or unwritable files fall back to requesting tokens.
+// Has no effect when a custom client is supplied via WithHTTPClient.
+//
+// Example:
+//
+//	cacheDir, _ := os.UserCacheDir()
+//	client, err := keycloak.New(ctx, config,
+//	    keycloak.WithTokenCacheFile(filepath.Join(cacheDir, "my-cli", "token.json")),
+//	)
+func WithTokenCacheFile(path string) Option {
+	return func(c *Client) error {
+		if path == "" {
+			return fmt.Errorf("token cache file path cannot be empty")
+		}
+		c.tokenCacheFile = path
+		return nil
+	}
+}
+
+// cachedToken is the on-disk format of the token cache file.
+type cachedToken struct {
+	Key   string        `json:"key"`   // Token endpoint and client ID the token was issued for
+	Token *oauth2.Token `json:"token"` // Access token, type and expiry
+}
+
+// fileTokenSource is a token source that keeps the current token in memory and on disk.
+// It fetches a new token only when neither holds a valid one.
+type fileTokenSource struct {
+	path         string
+	key          string
+	fetch        func() (*oauth2.Token, error)
+	expiryBuffer time.Duration // See WithTokenExpiryBuffer; zero uses oauth2's validity check
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// newFileTokenSource creates a token source backed by the cache file at path.
+// The key identifies the token endpoint and client ID the token is issued for.
+func newFileTokenSource(path, key string, fetch func() (*oauth2.Token, error)) *fileTokenSource {
+	return &fileTokenSource{path: path, key: key, fetch: fetch}
+}
+
+// Token returns a valid token from memory, the cache file, or the token endpoint.
+func (s *fileTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tokenFresh(s.token, s.expiryBuffer, time.Now()) {
+		return s.token, nil
+	}
+	if token := s.load(); tokenFresh(token, s.expiryBuffer, time.Now()) {
+		s.token = token
+		return token, nil
+	}
+
+	token, err := s.fetch()
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	s.save(token)
+	return token, nil
+}
+
+// invalidate drops the current token from memory and disk.
+func (s *fileTokenSource) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = nil
+	_ = os.Remove(s.path)
+}
+
+// load reads the token from the cache file. It returns nil if the file is missing,
+// malformed, issued for another endpoint or client, or has no expiry.
+func (s *fileTokenSource) load() *oauth2.Token {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil
+	}
+	var cached cachedToken
+	if err := unmarshalJSON(b, &cached); err != nil {
+		return nil
+	}
+	if cached.Key != s.key || cached.Token == nil || cached.Token.Expiry.IsZero() {
+		return nil
+	}
+	return cached.Token
+}
+
+// save writes the token to the cache file atomically with 0600 permissions.
+// Only the fields needed to reuse the token are stored.
+func (s *fileTokenSource) save(token *oauth2.Token) {
+	b, err := json.Marshal(cachedToken{
+		Key: s.key,
+		Token: &oauth2.Token{
+			AccessToken: token.AccessToken,
+			TokenType:   token.TokenType,
+			Expiry:      token.Expiry,
+		},
+	})
+	if err != nil {
+		return
+	}
+	_ = writeFileAtomic(s.path, b)
+}
+
+// writeFileAtomic writes b to path through a temporary file renamed into place, so that
+// readers never see a partial file. The file gets 0600 permissions and missing directories
+// are created with 0700.
+func writeFileAtomic(path string, b []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	// CreateTemp creates the file with 0600 permissions
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	err = errors.Join(err, f.Close())
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+// invalidatingTransport drops the cached token when Keycloak rejects a request with 401,
+// so that the next request obtains a fresh token.
+type invalidatingTransport struct {
+	base   http.RoundTripper
+	source *fileTokenSource
+}
+
+// RoundTrip executes the request and invalidates the token cache on 401 responses.
+func (t *invalidatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		t.source.invalidate()
+	}
+	return resp, err
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTokenCacheFile(t *testing.T) {
+	t.Run("empty path", func(t *testing.T) {
+		client := &Client{resty: newTestRestyClient()}
+		assert.Error(t, WithTokenCacheFile("")(client))
+	})
+
+	t.Run("token reused across clients", func(t *testing.T) {
+		server := newTestOIDCServer(3600, nil)
+		defer server.Close()
+
+		path := filepath.Join(t.TempDir(), "cache", "token.json")
+
+		for i := 0; i < 2; i++ {
+			client, err := New(context.Background(), server.config(), WithTokenCacheFile(path))
+			require.NoError(t, err)
+			_, err = client.Groups.List(context.Background(), nil, true)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), server.tokenRequests.Load())
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(b), "token-1")
+		assert.NotContains(t, string(b), server.config().ClientSecret)
+	})
+
+	t.Run("token not reused for another client ID", func(t *testing.T) {
+		server := newTestOIDCServer(3600, nil)
+		defer server.Close()
+
+		path := filepath.Join(t.TempDir(), "token.json")
+		config := server.config()
+
+		for _, clientID := range []string{"client-a", "client-b"} {
+			config.ClientID = clientID
+			client, err := New(context.Background(), config, WithTokenCacheFile(path))
+			require.NoError(t, err)
+			_, err = client.Groups.List(context.Background(), nil, true)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), server.tokenRequests.Load())
+	})
+
+	t.Run("cache invalidated on 401", func(t *testing.T) {
+		var calls atomic.Int32
+		server := newTestOIDCServer(3600, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"HTTP 401 Unauthorized"}`))
+				return
+			}
+			assert.Equal(t, "Bearer token-2", r.Header.Get("Authorization"))
+			w.Write([]byte(`[]`))
+		})
+		defer server.Close()
+
+		path := filepath.Join(t.TempDir(), "token.json")
+		client, err := New(context.Background(), server.config(), WithTokenCacheFile(path))
+		require.NoError(t, err)
+
+		_, err = client.Groups.List(context.Background(), nil, true)
+		assert.Error(t, err)
+		assert.NoFileExists(t, path)
+
+		_, err = client.Groups.List(context.Background(), nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), server.tokenRequests.Load())
+		assert.FileExists(t, path)
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// newDialer returns a dialer with the same settings as the one of http.DefaultTransport.
+func newDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+}
+
+// newTransport returns a fresh transport with the same defaults as http.DefaultTransport,
+// dialing with dialer so that dialer-level options apply to it.
+// Each client gets its own copy so transport-level options never leak between clients.
+func newTransport(dialer *net.Dialer) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// configureProxy installs the WithProxy proxy, on the transport of the custom client if one
+// was supplied, and adds the WithProxyBasicAuth credentials to the proxy URL chosen by the
+// transport, so that net/http sends them as Proxy-Authorization. It must be called after all
+// options have been applied, so that it wraps the final proxy function.
+func (c *Client) configureProxy() {
+	if c.proxyURL != nil {
+		if c.customHTTPClient {
+			// The transport is shared with the caller's client, so the proxy is set on a copy
+			hc := c.resty.GetClient()
+			if transport, ok := hc.Transport.(*http.Transport); ok {
+				hc.Transport = transport.Clone()
+			}
+			c.resty.SetProxy(c.proxyURL.String())
+		} else {
+			c.transport.Proxy = http.ProxyURL(c.proxyURL)
+		}
+	}
+	if c.customHTTPClient || c.proxyAuth == nil || c.transport == nil || c.transport.Proxy == nil {
+		return
+	}
+	proxy, auth := c.transport.Proxy, c.proxyAuth
+	c.transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := proxy(req)
+		if err != nil || proxyURL == nil {
+			return proxyURL, err
+		}
+		withAuth := *proxyURL
+		withAuth.User = auth
+		return &withAuth, nil
+	}
+}
+
+// limitTransport is an http.RoundTripper that bounds the number of in-flight requests.
+// A slot is acquired before the request is sent and released once the response body
+// is closed (or immediately if the round trip fails), so the limit covers the full
+// lifetime of the underlying connection usage.
+type limitTransport struct {
+	base http.RoundTripper
+	sem  chan struct{}
+}
+
+// newLimitTransport wraps base so that at most limit requests are in flight at once.
+func newLimitTransport(base http.RoundTripper, limit int) *limitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &limitTransport{
+		base: base,
+		sem:  make(chan struct{}, limit),
+	}
+}
+
+// RoundTrip acquires a slot (honoring request context cancellation) and delegates to the base transport.
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		<-t.sem
+		return nil, err
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: func() { <-t.sem }}
+	return resp, nil
+}
+
+// releaseOnClose wraps a response body and invokes release exactly once when closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close closes the underlying body and releases the associated slot.
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
+
+// networkRetryTransport is an http.RoundTripper that retries idempotent requests failing
+// with transport-level errors such as connection resets or unexpected EOFs, which typically
+// occur when a load balancer closes an idle connection. HTTP error statuses are not retried
+// here; those are handled by WithRetry.
+type networkRetryTransport struct {
+	base  http.RoundTripper
+	count int
+}
+
+// newNetworkRetryTransport wraps base so that idempotent requests are retried up to count times.
+func newNetworkRetryTransport(base http.RoundTripper, count int) *networkRetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &networkRetryTransport{
+		base:  base,
+		count: count,
+	}
+}
+
+// RoundTrip sends the request, retrying it on retryable network errors.
+func (t *networkRetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	for attempt := 0; attempt < t.count && err != nil && isRetryableNetworkError(err) && isIdempotent(req); attempt++ {
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		resp, err = t.base.RoundTrip(req)
+	}
+	return resp, err
+}
+
+// isIdempotent reports whether the request method is safe to resend.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryableNetworkError reports whether err indicates a dropped connection.
+func isRetryableNetworkError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,410 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+	"go.companyinfo.dev/ptr"
+)
+
+var (
+	// ErrUserNotFound is returned when a requested user cannot be found.
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrImpersonationDisabled is returned by UsersClient.Impersonate unless the client
+	// was created with WithImpersonationEnabled(true).
+	ErrImpersonationDisabled = errors.New("impersonation is disabled")
+)
+
+// UsersClient provides methods for managing Keycloak users.
+type UsersClient interface {
+	// Create creates a new user and returns the newly created user's ID.
+	Create(ctx context.Context, user User) (string, error)
+
+	// Get retrieves a single user by its ID.
+	// Returns ErrUserNotFound if the user does not exist.
+	Get(ctx context.Context, userID string) (*User, error)
+
+	// Update updates an existing user with the provided user data.
+	Update(ctx context.Context, user User) error
+
+	// Delete deletes a user by its ID.
+	Delete(ctx context.Context, userID string) error
+
+	// DeleteWithCleanup removes the user from each of its groups before deleting it, so that
+	// every membership removal is reported to the audit hook. It is not atomic.
+	DeleteWithCleanup(ctx context.Context, userID string) error
+
+	// ResetPassword sets a new password for the user.
+	// If temporary is true, the user must change the password on next login.
+	ResetPassword(ctx context.Context, userID, password string, temporary bool) error
+
+	// AddToGroup makes the user a member of the specified group.
+	AddToGroup(ctx context.Context, userID, groupID string) error
+
+	// RemoveFromGroup removes the user from the specified group.
+	RemoveFromGroup(ctx context.Context, userID, groupID string) error
+
+	// Provision creates a user, sets its password and adds it to groups in one call,
+	// deleting the user again if any later step fails. See ProvisionUserRequest.
+	Provision(ctx context.Context, req ProvisionUserRequest) (*User, error)
+
+	// Impersonate starts a session as the user, for support tooling.
+	// Returns ErrImpersonationDisabled unless enabled with WithImpersonationEnabled(true),
+	// and an error wrapping ErrForbidden if the service account lacks the impersonation role.
+	Impersonate(ctx context.Context, userID string) (*ImpersonationResult, error)
+}
+
+// usersClient implements the UsersClient interface.
+type usersClient struct {
+	client *Client
+}
+
+// newUsersClient creates a new UsersClient implementation.
+func newUsersClient(client *Client) UsersClient {
+	return &usersClient{
+		client: client,
+	}
+}
+
+// Create creates a new user and returns the newly created user's ID.
+func (u *usersClient) Create(ctx context.Context, user User) (string, error) {
+	if ptr.IsZero(user.Username) {
+		return "", fmt.Errorf("the username of the user is required")
+	}
+
+	resp, err := u.getRequest(ctx).
+		SetBody(user).
+		Execute(endpointUsersCreate.Method, u.client.buildURL(endpointUsersCreate, nil))
+	if err != nil {
+		return "", fmt.Errorf("unable to create user: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return "", fmt.Errorf("unable to create user: %w", newAPIError(resp))
+	}
+
+	id, err := createdID(resp, u.client.realm, "users")
+	if err != nil {
+		return "", fmt.Errorf("unable to create user: %w", err)
+	}
+	return id, nil
+}
+
+// Get retrieves a single user by its ID.
+func (u *usersClient) Get(ctx context.Context, userID string) (*User, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID parameter cannot be empty")
+	}
+
+	var result User
+
+	resp, err := u.getRequest(ctx).
+		SetResult(&result).
+		Execute(endpointUserGet.Method, u.client.buildURL(endpointUserGet, map[string]string{"userID": userID}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get user: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("unable to get user: %w", newAPIError(resp))
+	}
+
+	return &result, nil
+}
+
+// Update updates an existing user with the provided user data.
+func (u *usersClient) Update(ctx context.Context, user User) error {
+	if ptr.IsZero(user.ID) {
+		return fmt.Errorf("the ID of the user is required")
+	}
+
+	resp, err := u.getRequest(ctx).
+		SetBody(user).
+		Execute(endpointUserUpdate.Method, u.client.buildURL(endpointUserUpdate, map[string]string{"userID": *user.ID}))
+	if err != nil {
+		return fmt.Errorf("unable to update user: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("unable to update user: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+// Delete deletes a user by its ID.
+func (u *usersClient) Delete(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("userID parameter cannot be empty")
+	}
+
+	resp, err := u.getRequest(ctx).
+		Execute(endpointUserDelete.Method, u.client.buildURL(endpointUserDelete, map[string]string{"userID": userID}))
+	if err != nil {
+		return fmt.Errorf("unable to delete user: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("unable to delete user: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+// DeleteWithCleanup removes the user from each of its groups, then deletes the user. Deleting
+// a user drops its memberships without a trace, so this reports every membership removal to
+// the audit hook (see WithAuditHook) before the deletion, for workflows requiring explicit
+// removal events.
+//
+// Keycloak's REST API has no transactions, so this is not atomic. The groups are listed
+// first and removal stops at the first failure, returning the error and leaving the user in
+// place; memberships removed until then are not restored. A membership already gone (the
+// group was deleted meanwhile) counts as removed. Bound the whole sequence with the context;
+// WithEndpointTimeouts bounds its steps, identified as "Users.ListGroups",
+// "Users.RemoveFromGroup" and "Users.Delete".
+func (u *usersClient) DeleteWithCleanup(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("userID parameter cannot be empty")
+	}
+
+	groupIDs, err := u.listGroupIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, groupID := range groupIDs {
+		var apiErr *APIError
+		if err := u.RemoveFromGroup(ctx, userID, groupID); err != nil &&
+			!(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) {
+			return fmt.Errorf("unable to delete user: %w", err)
+		}
+	}
+
+	return u.Delete(ctx, userID)
+}
+
+// listGroupIDs returns the IDs of the groups the user is a direct member of, paging with the
+// client's page size. Returns ErrUserNotFound if the user does not exist.
+func (u *usersClient) listGroupIDs(ctx context.Context, userID string) ([]string, error) {
+	var groupIDs []string
+	pageSize := u.client.pageSize
+	for page := 0; ; page++ {
+		if page >= u.client.maxPages {
+			return nil, fmt.Errorf("unable to list user groups: %w (%d pages of %d)", ErrPageLimitExceeded, u.client.maxPages, pageSize)
+		}
+
+		var groups []*Group
+		resp, err := u.getRequest(ctx).
+			SetQueryParams(map[string]string{
+				"briefRepresentation": "true",
+				"first":               strconv.Itoa(page * pageSize),
+				"max":                 strconv.Itoa(pageSize),
+			}).
+			SetResult(&groups).
+			Execute(endpointUserGroups.Method, u.client.buildURL(endpointUserGroups, map[string]string{"userID": userID}))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list user groups: %w", err)
+		}
+		if !resp.IsSuccess() {
+			if resp.StatusCode() == http.StatusNotFound {
+				return nil, ErrUserNotFound
+			}
+			return nil, fmt.Errorf("unable to list user groups: %w", newAPIError(resp))
+		}
+
+		for _, group := range groups {
+			if group != nil && !ptr.IsZero(group.ID) {
+				groupIDs = append(groupIDs, *group.ID)
+			}
+		}
+		if len(groups) < pageSize {
+			return groupIDs, nil
+		}
+	}
+}
+
+// ResetPassword sets a new password for the user.
+func (u *usersClient) ResetPassword(ctx context.Context, userID, password string, temporary bool) error {
+	if userID == "" {
+		return fmt.Errorf("userID parameter cannot be empty")
+	}
+	if password == "" {
+		return fmt.Errorf("password parameter cannot be empty")
+	}
+
+	credential := Credential{
+		Type:      ptr.String("password"),
+		Value:     &password,
+		Temporary: &temporary,
+	}
+
+	resp, err := u.getRequest(ctx).
+		SetBody(credential).
+		Execute(endpointUserResetPass.Method, u.client.buildURL(endpointUserResetPass, map[string]string{"userID": userID}))
+	if err != nil {
+		return fmt.Errorf("unable to reset password: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("unable to reset password: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+// AddToGroup makes the user a member of the specified group.
+func (u *usersClient) AddToGroup(ctx context.Context, userID, groupID string) error {
+	if userID == "" {
+		return fmt.Errorf("userID parameter cannot be empty")
+	}
+	if groupID == "" {
+		return fmt.Errorf("groupID parameter cannot be empty")
+	}
+
+	resp, err := u.getRequest(ctx).
+		Execute(endpointUserGroupJoin.Method, u.client.buildURL(endpointUserGroupJoin, map[string]string{"userID": userID, "groupID": groupID}))
+	if err != nil {
+		return fmt.Errorf("unable to add user to group: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("unable to add user to group: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+// RemoveFromGroup removes the user from the specified group.
+func (u *usersClient) RemoveFromGroup(ctx context.Context, userID, groupID string) error {
+	if userID == "" {
+		return fmt.Errorf("userID parameter cannot be empty")
+	}
+	if groupID == "" {
+		return fmt.Errorf("groupID parameter cannot be empty")
+	}
+
+	resp, err := u.getRequest(ctx).
+		Execute(endpointUserGroupLeave.Method, u.client.buildURL(endpointUserGroupLeave, map[string]string{"userID": userID, "groupID": groupID}))
+	if err != nil {
+		return fmt.Errorf("unable to remove user from group: %w", err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("unable to remove user from group: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+// Provision creates a user, sets its password and adds it to the requested groups.
+//
+// Keycloak's REST API has no transactions, so this is not truly atomic. The steps are:
+//  1. Create the user (with RequiredActions applied as part of the create body)
+//  2. Reset the password, if one is provided
+//  3. Add the user to each group in GroupIDs
+//  4. Fetch and return the created user
+//
+// If step 2 or 3 fails, the user is deleted again on a best-effort basis and the
+// original error is returned (joined with the rollback error if the delete also fails).
+// Group memberships added before the failure disappear together with the user.
+func (u *usersClient) Provision(ctx context.Context, req ProvisionUserRequest) (*User, error) {
+	user := req.User
+	if len(req.RequiredActions) > 0 {
+		user.RequiredActions = &req.RequiredActions
+	}
+
+	userID, err := u.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, fmt.Errorf("unable to provision user: no user ID returned by server")
+	}
+
+	if err := u.provisionSteps(ctx, userID, req); err != nil {
+		// Use a fresh context so that a cancelled request context does not prevent the rollback
+		if rollbackErr := u.Delete(context.WithoutCancel(ctx), userID); rollbackErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("rollback failed: %w", rollbackErr))
+		}
+		return nil, err
+	}
+
+	return u.Get(ctx, userID)
+}
+
+// provisionSteps runs the post-create steps of Provision.
+func (u *usersClient) provisionSteps(ctx context.Context, userID string, req ProvisionUserRequest) error {
+	if req.Password != "" {
+		if err := u.ResetPassword(ctx, userID, req.Password, req.TemporaryPassword); err != nil {
+			return fmt.Errorf("unable to provision user: %w", err)
+		}
+	}
+
+	for _, groupID := range req.GroupIDs {
+		if err := u.AddToGroup(ctx, userID, groupID); err != nil {
+			return fmt.Errorf("unable to provision user: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Impersonate starts a session as the user and returns where to redirect the browser,
+// together with the session cookies set by Keycloak.
+//
+// Impersonation grants full access to the user's account, so it must be enabled explicitly
+// with WithImpersonationEnabled(true) and every attempt is logged as a warning.
+func (u *usersClient) Impersonate(ctx context.Context, userID string) (*ImpersonationResult, error) {
+	if !u.client.impersonation {
+		return nil, ErrImpersonationDisabled
+	}
+	if userID == "" {
+		return nil, fmt.Errorf("userID parameter cannot be empty")
+	}
+
+	u.client.logEvent(ctx, slog.LevelWarn, "keycloak user impersonation", "realm", u.client.realm, "user_id", userID)
+
+	var result ImpersonationResult
+
+	resp, err := u.getRequest(ctx).
+		SetResult(&result).
+		Execute(endpointUserImpersonate.Method, u.client.buildURL(endpointUserImpersonate, map[string]string{"userID": userID}))
+	if err != nil {
+		return nil, fmt.Errorf("unable to impersonate user: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		switch resp.StatusCode() {
+		case http.StatusNotFound:
+			return nil, ErrUserNotFound
+		case http.StatusForbidden:
+			return nil, fmt.Errorf("unable to impersonate user: %w: %w", ErrForbidden, newAPIError(resp))
+		}
+		return nil, fmt.Errorf("unable to impersonate user: %w", newAPIError(resp))
+	}
+
+	result.Cookies = resp.Cookies()
+	return &result, nil
+}
+
+// getRequest creates an HTTP request with error handling configured.
+func (u *usersClient) getRequest(ctx context.Context) *resty.Request {
+	var err HTTPErrorResponse
+	return u.client.resty.R().SetContext(ctx).SetError(&err)
+}
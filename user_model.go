@@ -14,6 +14,8 @@
 
 package keycloak
 
+import "net/http"
+
 // User represents a Keycloak user with all their properties.
 // Returned by the group members endpoint and other user-related endpoints.
 // This struct maps to Keycloak's UserRepresentation.
@@ -120,3 +122,22 @@ type SocialLink struct {
 	SocialUserID   *string `json:"socialUserId,omitempty"`   // User ID in the social provider
 	SocialUsername *string `json:"socialUsername,omitempty"` // Username in the social provider
 }
+
+// ImpersonationResult is returned by UsersClient.Impersonate.
+// Redirect and SameRealm map to Keycloak's impersonation response; Cookies holds the
+// session cookies that establish the impersonated session in a browser.
+type ImpersonationResult struct {
+	Redirect  *string        `json:"redirect,omitempty"`  // URL to redirect the browser to (the account console)
+	SameRealm *bool          `json:"sameRealm,omitempty"` // Whether the user is in the same realm as the impersonator
+	Cookies   []*http.Cookie `json:"-"`                   // Session cookies set by Keycloak
+}
+
+// ProvisionUserRequest describes a user to create with UsersClient.Provision.
+// Only User.Username is required; all other fields are optional.
+type ProvisionUserRequest struct {
+	User              User     // User representation to create
+	Password          string   // Initial password (skipped if empty)
+	TemporaryPassword bool     // If true, the user must change the password on next login
+	RequiredActions   []string // Required actions to assign (e.g. "VERIFY_EMAIL", "UPDATE_PASSWORD")
+	GroupIDs          []string // IDs of the groups the user should join
+}
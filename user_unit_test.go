@@ -0,0 +1,427 @@
+// Copyright 2025 Company.info B.V.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.companyinfo.dev/ptr"
+)
+
+// recordingServer is a mock Keycloak server that records the method and path of every request.
+type recordingServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls []string
+}
+
+// newRecordingServer starts a mock server that records calls before delegating to handler.
+func newRecordingServer(handler http.HandlerFunc) *recordingServer {
+	rs := &recordingServer{}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs.mu.Lock()
+		rs.calls = append(rs.calls, r.Method+" "+r.URL.Path)
+		rs.mu.Unlock()
+		handler(w, r)
+	}))
+	return rs
+}
+
+// Calls returns a copy of the recorded calls.
+func (rs *recordingServer) Calls() []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return append([]string(nil), rs.calls...)
+}
+
+// TestUsersClient_GetWithServer tests Get with a mock HTTP server
+func TestUsersClient_GetWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		mockStatusCode int
+		wantErr        error
+	}{
+		{
+			name:           "existing user",
+			userID:         "user-1",
+			mockStatusCode: http.StatusOK,
+		},
+		{
+			name:           "user not found",
+			userID:         "missing",
+			mockStatusCode: http.StatusNotFound,
+			wantErr:        ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/admin/realms/test-realm/users/"+tt.userID, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.mockStatusCode)
+				if tt.mockStatusCode == http.StatusOK {
+					json.NewEncoder(w).Encode(User{ID: ptr.String(tt.userID), Username: ptr.String("jdoe")})
+				}
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			user, err := client.Users.Get(context.Background(), tt.userID)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, user)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.userID, *user.ID)
+			}
+		})
+	}
+}
+
+// TestUsersClient_GetLargeNumbers tests that large integers in responses keep their precision,
+// including those decoded into generic maps
+func TestUsersClient_GetLargeNumbers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "user-1",
+			"createdTimestamp": 1735689600123,
+			"userProfileMetadata": {"attributes": [{"name": "since", "annotations": {"since": 1735689600123}}]}
+		}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	user, err := client.Users.Get(context.Background(), "user-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1735689600123), *user.CreatedTimestamp)
+	annotations := *(*user.UserProfileMetadata.Attributes)[0].Annotations
+	assert.Equal(t, json.Number("1735689600123"), annotations["since"])
+}
+
+// TestUsersClient_ProvisionWithServer tests the full Provision sequence against a mock HTTP server
+func TestUsersClient_ProvisionWithServer(t *testing.T) {
+	var created User
+	var credential Credential
+
+	server := newRecordingServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /admin/realms/test-realm/users":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			w.Header().Set("Location", "/admin/realms/test-realm/users/new-user")
+			w.WriteHeader(http.StatusCreated)
+		case "PUT /admin/realms/test-realm/users/new-user/reset-password":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&credential))
+			w.WriteHeader(http.StatusNoContent)
+		case "PUT /admin/realms/test-realm/users/new-user/groups/group-1",
+			"PUT /admin/realms/test-realm/users/new-user/groups/group-2":
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /admin/realms/test-realm/users/new-user":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(User{ID: ptr.String("new-user"), Username: created.Username})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	user, err := client.Users.Provision(context.Background(), ProvisionUserRequest{
+		User:              User{Username: ptr.String("jdoe"), Enabled: ptr.Bool(true)},
+		Password:          "s3cret",
+		TemporaryPassword: true,
+		RequiredActions:   []string{"VERIFY_EMAIL"},
+		GroupIDs:          []string{"group-1", "group-2"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "new-user", *user.ID)
+	assert.Equal(t, []string{"VERIFY_EMAIL"}, *created.RequiredActions)
+	assert.Equal(t, "s3cret", *credential.Value)
+	assert.True(t, *credential.Temporary)
+	assert.Equal(t, []string{
+		"POST /admin/realms/test-realm/users",
+		"PUT /admin/realms/test-realm/users/new-user/reset-password",
+		"PUT /admin/realms/test-realm/users/new-user/groups/group-1",
+		"PUT /admin/realms/test-realm/users/new-user/groups/group-2",
+		"GET /admin/realms/test-realm/users/new-user",
+	}, server.Calls())
+}
+
+// TestUsersClient_ProvisionRollback tests that a failure after creation deletes the user again
+func TestUsersClient_ProvisionRollback(t *testing.T) {
+	tests := []struct {
+		name        string
+		deleteFails bool
+	}{
+		{
+			name:        "rollback succeeds",
+			deleteFails: false,
+		},
+		{
+			name:        "rollback fails",
+			deleteFails: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRecordingServer(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method + " " + r.URL.Path {
+				case "POST /admin/realms/test-realm/users":
+					w.Header().Set("Location", "/admin/realms/test-realm/users/new-user")
+					w.WriteHeader(http.StatusCreated)
+				case "PUT /admin/realms/test-realm/users/new-user/groups/missing-group":
+					w.WriteHeader(http.StatusNotFound)
+				case "DELETE /admin/realms/test-realm/users/new-user":
+					if tt.deleteFails {
+						w.WriteHeader(http.StatusInternalServerError)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			})
+			defer server.Close()
+
+			client := newTestClient(server.URL)
+			user, err := client.Users.Provision(context.Background(), ProvisionUserRequest{
+				User:     User{Username: ptr.String("jdoe")},
+				GroupIDs: []string{"missing-group"},
+			})
+
+			require.Error(t, err)
+			assert.Nil(t, user)
+			assert.Contains(t, err.Error(), "unable to add user to group")
+			if tt.deleteFails {
+				assert.Contains(t, err.Error(), "rollback failed")
+			} else {
+				assert.NotContains(t, err.Error(), "rollback failed")
+			}
+			assert.Equal(t, []string{
+				"POST /admin/realms/test-realm/users",
+				"PUT /admin/realms/test-realm/users/new-user/groups/missing-group",
+				"DELETE /admin/realms/test-realm/users/new-user",
+			}, server.Calls())
+		})
+	}
+}
+
+// TestUsersClient_ProvisionCreateFailure tests that nothing is rolled back when creation itself fails
+func TestUsersClient_ProvisionCreateFailure(t *testing.T) {
+	server := newRecordingServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(HTTPErrorResponse{Message: "User exists with same username"})
+	})
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	user, err := client.Users.Provision(context.Background(), ProvisionUserRequest{
+		User:     User{Username: ptr.String("jdoe")},
+		Password: "s3cret",
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, []string{"POST /admin/realms/test-realm/users"}, server.Calls())
+}
+
+// TestUsersClient_DeleteWithCleanupWithServer tests that DeleteWithCleanup removes every
+// membership, reporting each to the audit hook, before deleting the user
+func TestUsersClient_DeleteWithCleanupWithServer(t *testing.T) {
+	server := newRecordingServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /admin/realms/test-realm/users/u1/groups":
+			// A page size of 2 makes the listing take two pages
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Query().Get("first") == "0" {
+				w.Write([]byte(`[{"id":"g1"},{"id":"g2"}]`))
+			} else {
+				w.Write([]byte(`[{"id":"gone"}]`))
+			}
+		case "DELETE /admin/realms/test-realm/users/u1/groups/gone",
+			"GET /admin/realms/test-realm/users/missing/groups":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	defer server.Close()
+
+	events := make(chan AuditEvent, 10)
+	client := newTestClient(server.URL, WithPageSize(2), WithAuditHook(func(event AuditEvent) {
+		events <- event
+	}))
+	ctx := context.Background()
+
+	require.NoError(t, client.Users.DeleteWithCleanup(ctx, "u1"))
+	assert.Equal(t, []string{
+		"GET /admin/realms/test-realm/users/u1/groups",
+		"GET /admin/realms/test-realm/users/u1/groups",
+		"DELETE /admin/realms/test-realm/users/u1/groups/g1",
+		"DELETE /admin/realms/test-realm/users/u1/groups/g2",
+		"DELETE /admin/realms/test-realm/users/u1/groups/gone",
+		"DELETE /admin/realms/test-realm/users/u1",
+	}, server.Calls())
+
+	var actions []string
+	for range 3 {
+		event := receiveAuditEvent(t, events)
+		actions = append(actions, event.Action+" "+event.ResourceID)
+	}
+	assert.ElementsMatch(t, []string{"remove_member g1", "remove_member g2", "delete u1"}, actions)
+
+	t.Run("removal failure keeps the user", func(t *testing.T) {
+		failing := newRecordingServer(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.URL.Path {
+			case "GET /admin/realms/test-realm/users/u1/groups":
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[{"id":"g1"},{"id":"g2"}]`))
+			case "DELETE /admin/realms/test-realm/users/u1/groups/g1":
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+		defer failing.Close()
+
+		err := newTestClient(failing.URL).Users.DeleteWithCleanup(ctx, "u1")
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+		assert.Equal(t, []string{
+			"GET /admin/realms/test-realm/users/u1/groups",
+			"DELETE /admin/realms/test-realm/users/u1/groups/g1",
+		}, failing.Calls())
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		assert.ErrorIs(t, client.Users.DeleteWithCleanup(ctx, "missing"), ErrUserNotFound)
+	})
+}
+
+// TestUsersClient_Validation tests parameter validation of the users client
+// TestUsersClient_ImpersonateWithServer tests Impersonate with a mock HTTP server
+func TestUsersClient_ImpersonateWithServer(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		mockStatusCode int
+		wantErr        error
+		wantRequest    bool
+	}{
+		{
+			name:           "impersonated",
+			enabled:        true,
+			mockStatusCode: http.StatusOK,
+			wantRequest:    true,
+		},
+		{
+			name:    "disabled",
+			enabled: false,
+			wantErr: ErrImpersonationDisabled,
+		},
+		{
+			name:           "forbidden",
+			enabled:        true,
+			mockStatusCode: http.StatusForbidden,
+			wantErr:        ErrForbidden,
+			wantRequest:    true,
+		},
+		{
+			name:           "user not found",
+			enabled:        true,
+			mockStatusCode: http.StatusNotFound,
+			wantErr:        ErrUserNotFound,
+			wantRequest:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRecordingServer(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tt.mockStatusCode == http.StatusOK {
+					http.SetCookie(w, &http.Cookie{Name: "KEYCLOAK_IDENTITY", Value: "session"})
+				}
+				w.WriteHeader(tt.mockStatusCode)
+				if tt.mockStatusCode == http.StatusOK {
+					w.Write([]byte(`{"redirect":"https://keycloak.example.com/realms/test-realm/account","sameRealm":true}`))
+				}
+			})
+			defer server.Close()
+
+			logger := &testLogger{}
+			client := newTestClient(server.URL, WithImpersonationEnabled(tt.enabled), WithLogger(logger))
+			result, err := client.Users.Impersonate(context.Background(), "u1")
+
+			if tt.wantRequest {
+				assert.Equal(t, []string{"POST /admin/realms/test-realm/users/u1/impersonation"}, server.Calls())
+				assert.Contains(t, logger.Lines(), "WARN keycloak user impersonation realm=test-realm user_id=u1")
+			} else {
+				assert.Empty(t, server.Calls())
+				assert.Empty(t, logger.Lines())
+			}
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://keycloak.example.com/realms/test-realm/account", ptr.ToString(result.Redirect))
+			assert.Equal(t, ptr.Bool(true), result.SameRealm)
+			require.Len(t, result.Cookies, 1)
+			assert.Equal(t, "KEYCLOAK_IDENTITY", result.Cookies[0].Name)
+		})
+	}
+}
+
+func TestUsersClient_Validation(t *testing.T) {
+	client := &Client{resty: newTestRestyClient()}
+	uc := &usersClient{client: client}
+	ctx := context.Background()
+
+	_, err := uc.Create(ctx, User{})
+	assert.Error(t, err)
+
+	_, err = uc.Get(ctx, "")
+	assert.Error(t, err)
+
+	assert.Error(t, uc.Update(ctx, User{}))
+	assert.Error(t, uc.Delete(ctx, ""))
+	assert.Error(t, uc.ResetPassword(ctx, "", "password", false))
+	assert.Error(t, uc.ResetPassword(ctx, "user-id", "", false))
+	assert.Error(t, uc.AddToGroup(ctx, "", "group-id"))
+	assert.Error(t, uc.AddToGroup(ctx, "user-id", ""))
+	assert.Error(t, uc.RemoveFromGroup(ctx, "", "group-id"))
+	assert.Error(t, uc.RemoveFromGroup(ctx, "user-id", ""))
+
+	client.impersonation = true
+	_, err = uc.Impersonate(ctx, "")
+	assert.Error(t, err)
+}
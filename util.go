@@ -15,10 +15,95 @@
 package keycloak
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 )
 
+// decodeJSON decodes a JSON document from r into v with UseNumber enabled, so that numbers
+// landing in interface{} values are kept as json.Number instead of float64. This keeps
+// large integers such as millisecond timestamps exact. Like json.Unmarshal, it rejects empty
+// documents and data after the value. All JSON decoding in the client should go through this
+// helper.
+func decodeJSON(r io.Reader, v any) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return decodeValue(decoder, v)
+}
+
+// errEmptyJSON is returned for documents without a value, with json.Unmarshal's message.
+var errEmptyJSON = errors.New("unexpected end of JSON input")
+
+// decodeValue decodes the only value of the decoder's input into v. Unlike Decode, which
+// stops after the first value, it fails if anything but whitespace follows.
+func decodeValue(decoder *json.Decoder, v any) error {
+	if err := decoder.Decode(v); err != nil {
+		if err == io.EOF {
+			return errEmptyJSON
+		}
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+// maxPooledDecodeSize is the largest document decoded with a pooled decoder. A decoder keeps
+// its buffer at the size of the largest document it has seen, so larger documents use a
+// fresh decoder to avoid pinning their memory in the pool.
+const maxPooledDecodeSize = 1 << 20
+
+// pooledDecoder is a JSON decoder reading from a resettable reader, so that the decoder and
+// its internal buffer can be reused for many documents instead of being allocated per response.
+type pooledDecoder struct {
+	reader  bytes.Reader
+	decoder *json.Decoder
+}
+
+var decoderPool = sync.Pool{
+	New: func() any {
+		d := &pooledDecoder{}
+		d.decoder = json.NewDecoder(&d.reader)
+		d.decoder.UseNumber()
+		return d
+	},
+}
+
+// unmarshalJSON is the byte-slice counterpart of decodeJSON, used as resty's JSON unmarshaler.
+// It decodes with a pooled decoder, which behaves exactly like decodeJSON.
+func unmarshalJSON(b []byte, v any) error {
+	// Trailing whitespace is insignificant, and trimming it lets a successful decode consume
+	// the whole input, leaving the decoder in a clean state for reuse.
+	b = bytes.TrimRight(b, " \t\r\n")
+	if len(b) > maxPooledDecodeSize {
+		return decodeJSON(bytes.NewReader(b), v)
+	}
+
+	d := decoderPool.Get().(*pooledDecoder)
+	d.reader.Reset(b)
+	err := decodeValue(d.decoder, v)
+
+	// A decoder that failed may keep its error or unread input, so only clean ones are reused
+	var rest [1]byte
+	if n, _ := d.decoder.Buffered().Read(rest[:]); err == nil && n == 0 && d.reader.Len() == 0 {
+		d.reader.Reset(nil)
+		decoderPool.Put(d)
+	}
+	if err != nil {
+		// A reused decoder reports syntax error offsets from the start of its first document;
+		// decode again with a fresh one for an error matching decodeJSON
+		return decodeJSON(bytes.NewReader(b), v)
+	}
+	return nil
+}
+
 // mapper converts a struct to a map[string]string for use as query parameters.
 // The struct fields must have json tags with "omitempty" for proper serialization.
 // Note: Fields with `json:"name,string,omitempty"` will have quotes in values.
@@ -37,7 +122,7 @@ func mapper(s any) (map[string]string, error) {
 	}
 
 	var generic map[string]any
-	if err := json.Unmarshal(b, &generic); err != nil {
+	if err := unmarshalJSON(b, &generic); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal json to map: %w", err)
 	}
 
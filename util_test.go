@@ -15,10 +15,15 @@
 package keycloak
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.companyinfo.dev/ptr"
 )
 
 func TestMapper(t *testing.T) {
@@ -138,3 +143,113 @@ func TestMapperWithUnmarshallableType(t *testing.T) {
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "failed to marshal struct")
 }
+
+func TestDecodeJSON(t *testing.T) {
+	const body = `{"createdTimestamp": 1735689600123, "config": {"expires": 1735689600123}}`
+
+	t.Run("typed fields", func(t *testing.T) {
+		var user User
+		require.NoError(t, decodeJSON(strings.NewReader(body), &user))
+		require.NotNil(t, user.CreatedTimestamp)
+		assert.Equal(t, int64(1735689600123), *user.CreatedTimestamp)
+	})
+
+	t.Run("generic maps keep numbers exact", func(t *testing.T) {
+		var generic map[string]any
+		require.NoError(t, decodeJSON(strings.NewReader(body), &generic))
+		assert.Equal(t, json.Number("1735689600123"), generic["createdTimestamp"])
+		assert.Equal(t, json.Number("1735689600123"), generic["config"].(map[string]any)["expires"])
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		for _, body := range []string{"{", "", "  \n", `{"id":"g3"} trailing`, `{"id":"g3"}}`, `{} {}`} {
+			var generic map[string]any
+			assert.Error(t, decodeJSON(strings.NewReader(body), &generic), body)
+			assert.Error(t, json.Unmarshal([]byte(body), &generic), body)
+		}
+		var generic map[string]any
+		assert.NoError(t, decodeJSON(strings.NewReader("{}  \n"), &generic))
+	})
+}
+
+func TestUnmarshalJSONPooled(t *testing.T) {
+	t.Run("matches decodeJSON", func(t *testing.T) {
+		bodies := []string{
+			`{"createdTimestamp": 1735689600123}` + "\n",
+			`[{"id":"g1"},{"id":"g2"}]`,
+			`{"id":`,
+			`{"id":"g4"}`,
+			``,
+			"  \n",
+			`42`,
+		}
+		for i := 0; i < 3; i++ {
+			for _, body := range bodies {
+				var got, want any
+				gotErr := unmarshalJSON([]byte(body), &got)
+				wantErr := decodeJSON(strings.NewReader(body), &want)
+				assert.Equal(t, wantErr, gotErr, body)
+				assert.Equal(t, want, got, body)
+			}
+		}
+	})
+
+	t.Run("rejects data after the value", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			var got map[string]any
+			assert.Error(t, unmarshalJSON([]byte(`{"id":"g3"} trailing`), &got))
+			assert.Error(t, unmarshalJSON([]byte(`{"id":"g3"} {"id":"g4"}`), &got))
+			assert.Error(t, unmarshalJSON(nil, &got))
+
+			// The pool still hands out working decoders
+			got = nil
+			require.NoError(t, unmarshalJSON([]byte(`{"id":"g5"}`), &got))
+			assert.Equal(t, map[string]any{"id": "g5"}, got)
+		}
+	})
+
+	t.Run("generic maps keep numbers exact", func(t *testing.T) {
+		// 2^53+1 is the smallest integer a float64 cannot represent
+		var generic map[string]any
+		require.NoError(t, unmarshalJSON([]byte(`{"id": 9007199254740993}`), &generic))
+		number, ok := generic["id"].(json.Number)
+		require.True(t, ok, "got %T", generic["id"])
+		n, err := number.Int64()
+		require.NoError(t, err)
+		assert.Equal(t, int64(9007199254740993), n)
+	})
+
+	t.Run("large documents", func(t *testing.T) {
+		body := `["` + strings.Repeat("x", maxPooledDecodeSize) + `"]`
+		var got []string
+		require.NoError(t, unmarshalJSON([]byte(body), &got))
+		require.Len(t, got, 1)
+		assert.Len(t, got[0], maxPooledDecodeSize)
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					var group Group
+					assert.NoError(t, unmarshalJSON([]byte(fmt.Sprintf(`{"id":"g-%d-%d"}`, i, j)), &group))
+					assert.Equal(t, fmt.Sprintf("g-%d-%d", i, j), ptr.ToString(group.ID))
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+func TestMapperLargeInteger(t *testing.T) {
+	type Params struct {
+		Since int64 `json:"since,omitempty"`
+	}
+
+	result, err := mapper(Params{Since: 1735689600123})
+	require.NoError(t, err)
+	assert.Equal(t, "1735689600123", result["since"])
+}